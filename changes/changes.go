@@ -0,0 +1,242 @@
+package changes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// DefaultBaseRef is the ref changes are computed against when none is configured:
+// uncommitted (staged and unstaged) and untracked changes.
+const DefaultBaseRef = "HEAD"
+
+// Package is a package of the module as reported by `go list`.
+type Package struct {
+	ImportPath   string
+	Dir          string
+	Imports      []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// Result describes the packages affected by a set of changed files.
+type Result struct {
+	BaseRef  string
+	Files    []string // Changed files, relative to the module root
+	Changed  []string // Packages containing changed files, as "./dir" patterns
+	Affected []string // Changed packages plus their reverse dependencies, as "./dir" patterns
+}
+
+// Affected computes which packages of the module rooted at rootDir are affected by the changes
+// against baseRef: the packages that contain changed files, plus every package that imports
+// them (directly or transitively, including through tests).
+func Affected(rootDir, baseRef string) (*Result, error) {
+	if baseRef == "" {
+		baseRef = DefaultBaseRef
+	}
+
+	files, err := ChangedFiles(rootDir, baseRef)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{BaseRef: baseRef, Files: files}
+	if len(files) == 0 {
+		return result, nil
+	}
+
+	result.Changed, result.Affected, err = Packages(rootDir, files)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Changes against %s: %d files, %d changed packages, %d affected packages.", baseRef, len(files), len(result.Changed), len(result.Affected))
+	return result, nil
+}
+
+// Packages maps files (relative to rootDir) to the packages containing them and to those
+// packages plus their reverse dependencies, both as "./dir" patterns.
+func Packages(rootDir string, files []string) (changed, affected []string, err error) {
+	packages, err := ListPackages(rootDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	changedPaths := PackagesForFiles(rootDir, packages, files)
+	affectedPaths := ReverseDependencies(packages, changedPaths)
+	return patterns(rootDir, packages, changedPaths), patterns(rootDir, packages, affectedPaths), nil
+}
+
+// ChangedFiles returns the files under rootDir that differ from baseRef (including staged,
+// unstaged and untracked files), relative to rootDir.
+func ChangedFiles(rootDir, baseRef string) ([]string, error) {
+	diff, err := git(rootDir, "diff", "--name-only", "--relative", baseRef, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(rootDir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, line := range append(strings.Split(diff, "\n"), strings.Split(untracked, "\n")...) {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		files = append(files, filepath.ToSlash(line))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// git runs a git command in dir and returns its standard output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// ListPackages returns every package of the module rooted at rootDir, using `go list -json ./...`.
+func ListPackages(rootDir string) ([]*Package, error) {
+	cmd := exec.Command("go", "list", "-e", "-json=ImportPath,Dir,Imports,TestImports,XTestImports", "./...")
+	cmd.Dir = rootDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var packages []*Package
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var pkg Package
+		if err := decoder.Decode(&pkg); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("could not decode go list output: %w", err)
+		}
+		packages = append(packages, &pkg)
+	}
+	return packages, nil
+}
+
+// PackagesForFiles maps changed files to the import paths of the packages they belong to.
+// Files outside any package directory (e.g. testdata) belong to the closest enclosing package.
+// A change to go.mod or go.sum affects every package.
+func PackagesForFiles(rootDir string, packages []*Package, files []string) []string {
+	byDir := make(map[string]string, len(packages))
+	for _, pkg := range packages {
+		byDir[filepath.Clean(pkg.Dir)] = pkg.ImportPath
+	}
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		absRoot = rootDir
+	}
+
+	seen := make(map[string]bool)
+	var changed []string
+	add := func(importPath string) {
+		if !seen[importPath] {
+			seen[importPath] = true
+			changed = append(changed, importPath)
+		}
+	}
+
+	for _, file := range files {
+		base := filepath.Base(file)
+		if base == "go.mod" || base == "go.sum" {
+			for _, pkg := range packages {
+				add(pkg.ImportPath)
+			}
+			continue
+		}
+		if !strings.HasSuffix(file, ".go") && !strings.Contains(file, "testdata/") {
+			continue // Docs, configs and the like do not affect tests.
+		}
+
+		// Walk up from the file's directory until a package directory is found.
+		dir := filepath.Dir(filepath.Join(absRoot, filepath.FromSlash(file)))
+		for {
+			if importPath, ok := byDir[dir]; ok {
+				add(importPath)
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir || !strings.HasPrefix(parent, absRoot) {
+				break
+			}
+			dir = parent
+		}
+	}
+	return changed
+}
+
+// ReverseDependencies returns the given packages plus every package of the module that
+// depends on them, directly or transitively. Test imports count as dependencies, since
+// a package's tests must rerun when a package they import changes.
+func ReverseDependencies(packages []*Package, changed []string) []string {
+	importedBy := make(map[string][]string)
+	for _, pkg := range packages {
+		deps := append(append(append([]string{}, pkg.Imports...), pkg.TestImports...), pkg.XTestImports...)
+		for _, dep := range deps {
+			importedBy[dep] = append(importedBy[dep], pkg.ImportPath)
+		}
+	}
+
+	seen := make(map[string]bool)
+	queue := append([]string{}, changed...)
+	var affected []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+		affected = append(affected, current)
+		queue = append(queue, importedBy[current]...)
+	}
+	sort.Strings(affected)
+	return affected
+}
+
+// patterns converts import paths to "./dir" patterns relative to rootDir, which is what
+// the runner passes to `go test`.
+func patterns(rootDir string, packages []*Package, importPaths []string) []string {
+	dirs := make(map[string]string, len(packages))
+	for _, pkg := range packages {
+		dirs[pkg.ImportPath] = pkg.Dir
+	}
+	absRoot, err := filepath.Abs(rootDir)
+	if err != nil {
+		absRoot = rootDir
+	}
+
+	var result []string
+	for _, importPath := range importPaths {
+		rel, err := filepath.Rel(absRoot, dirs[importPath])
+		if err != nil {
+			log.Warnf("Could not make %s relative to %s: %v", dirs[importPath], absRoot, err)
+			continue
+		}
+		result = append(result, "./"+filepath.ToSlash(rel))
+	}
+	sort.Strings(result)
+	return result
+}
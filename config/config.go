@@ -0,0 +1,535 @@
+// Package config loads the settings of gdd. They are layered: built-in defaults, then the
+// user's file in the XDG config directory, then the project's file at the module root,
+// then command line flags. Every layer only overrides the settings it sets.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gdd/history"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/log"
+)
+
+// Names of the configuration files. When both the TOML and the JSON file exist in a
+// directory, the TOML one is used.
+const (
+	ProjectTOML = ".gdd.toml"   // Project file at the module root
+	ProjectJSON = ".gdd.json"   // Project file at the module root
+	UserTOML    = "config.toml" // User file in UserDir
+	UserJSON    = "config.json" // User file in UserDir
+)
+
+// Config holds the settings of gdd.
+type Config struct {
+	// Theme is the color theme of the TUI and its reports: one of BuiltinThemes, "auto" picking
+	// "dark" or "light" depending on the terminal's background, or the name of one of Themes.
+	// NO_COLOR in the environment selects "no-color" whatever the setting.
+	Theme string `json:"theme,omitempty"`
+	// Themes defines custom themes by name.
+	Themes map[string]ThemeConfig `json:"themes,omitempty"`
+
+	Test TestConfig `json:"test"`
+
+	// Profile is the profile of test runs at startup, one of Profiles; empty means none.
+	Profile string `json:"profile,omitempty"`
+	// Profiles are named sets of flags, environment variables and build tags added to
+	// test runs, e.g. "unit" or "integration", which the TUI switches between.
+	Profiles map[string]ProfileConfig `json:"profiles,omitempty"`
+
+	Finder  FinderConfig  `json:"finder"`
+	Export  ExportConfig  `json:"export"`
+	History HistoryConfig `json:"history"`
+	Queue   QueueConfig   `json:"queue"`
+
+	// KeyPreset is the set of keybindings Keys apply to: "default" or "vim".
+	KeyPreset string `json:"key_preset,omitempty"`
+	// Keys remaps keybindings, by view and action, e.g. Keys["report"]["back_to_list"] = ["esc", "h"].
+	// Keys separated by spaces form a chord, pressed one after the other, e.g. "g g".
+	// The views and actions are defined by the TUI, which validates them.
+	Keys map[string]map[string]KeyList `json:"keys,omitempty"`
+}
+
+// BuiltinThemes are the themes that can be used without defining them.
+var BuiltinThemes = []string{"auto", "dark", "light", "high-contrast", "no-color"}
+
+// ThemeConfig defines a custom theme on top of a built-in one.
+type ThemeConfig struct {
+	// Base is the built-in theme the colors and the Markdown style default to; empty means "auto".
+	Base string `json:"base,omitempty"`
+	// Markdown is the glamour style of the reports: a standard style such as "dracula" or
+	// "light", or the path of a JSON style file. Empty means the style of Base, with the
+	// headings in the theme's accent colors.
+	Markdown string `json:"markdown,omitempty"`
+	// Colors overrides colors of Base by role, e.g. {"accent": "#7D56F4", "fail": "9"}, as hex
+	// RGB or ANSI color numbers. The roles are defined by the TUI, which validates them.
+	Colors map[string]string `json:"colors,omitempty"`
+}
+
+// TestConfig holds the defaults of every `go test` run.
+type TestConfig struct {
+	Flags []string          `json:"flags,omitempty"` // Extra flags, e.g. ["-short", "-timeout=5m"]
+	Env   map[string]string `json:"env,omitempty"`   // Extra environment variables, e.g. {"GOFLAGS": "-mod=mod"}
+	// Jobs is the number of packages tested at once, each by its own `go test`, when a run
+	// covers several. 1, the default, tests them all with a single `go test`, which already
+	// tests packages in parallel; 0 means one per CPU.
+	Jobs int `json:"jobs,omitempty"`
+	// Precompile builds the test binary of each package with `go test -c` into the .gdd/testbin
+	// cache and runs it directly, so that runs of unchanged code skip the build. Toggled in the
+	// TUI.
+	Precompile bool `json:"precompile,omitempty"`
+}
+
+// ParallelJobs returns the number of packages tested at once: Jobs, or the number of CPUs if 0.
+func (t TestConfig) ParallelJobs() int {
+	if t.Jobs == 0 {
+		return runtime.NumCPU()
+	}
+	return t.Jobs
+}
+
+// ProfileConfig is a named set of settings for test runs, added to those of TestConfig.
+type ProfileConfig struct {
+	Description string            `json:"description,omitempty"` // Shown in the profile picker
+	Flags       []string          `json:"flags,omitempty"`       // Extra flags, after those of TestConfig
+	Env         map[string]string `json:"env,omitempty"`         // Extra environment variables, overriding those of TestConfig
+	Tags        []string          `json:"tags,omitempty"`        // Build tags, passed as -tags
+}
+
+// FinderConfig configures test discovery.
+type FinderConfig struct {
+	// ExcludeDirs are directories that are not searched for tests, matched against both the
+	// name of a directory and its path from the module root, e.g. "testdata" or "internal/gen/*".
+	// vendor and hidden directories are always skipped.
+	ExcludeDirs []string `json:"exclude_dirs,omitempty"`
+}
+
+// ExportConfig configures where exported files are written.
+type ExportConfig struct {
+	Dir string `json:"dir,omitempty"` // Directory of exported reports; empty means the working directory
+}
+
+// HistoryConfig configures how much run history is kept.
+type HistoryConfig struct {
+	MaxRuns int      `json:"max_runs"` // Keep at most this many runs; 0 means no limit
+	MaxAge  Duration `json:"max_age"`  // Drop older runs, e.g. "30d" or "72h"; 0 means no limit
+}
+
+// Retention returns the history retention of the settings.
+func (h HistoryConfig) Retention() history.Retention {
+	return history.Retention{MaxRuns: h.MaxRuns, MaxAge: time.Duration(h.MaxAge)}
+}
+
+// QueuePolicies are the ways a run requested while another one is in progress joins the queue:
+// "coalesce" drops it if the same run is already queued, and merges the packages of queued
+// watch mode runs; "replace" drops the queued runs, so that only the latest one waits; "append"
+// queues every run.
+var QueuePolicies = []string{"coalesce", "replace", "append"}
+
+// QueueConfig configures the queue of runs waiting for the run in progress.
+type QueueConfig struct {
+	Policy string `json:"policy,omitempty"` // One of QueuePolicies
+}
+
+// Duration is a time.Duration written as a string such as "90m" or "30d", or as a number of seconds.
+type Duration time.Duration
+
+// ParseDuration parses a duration as accepted by time.ParseDuration, or a number of days such as "30d".
+func ParseDuration(s string) (Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return Duration(d), nil
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("a duration must be a string such as \"30d\" or a number of seconds")
+	}
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// KeyList is the list of keys of a keybinding. It can be written as a single string.
+type KeyList []string
+
+func (k *KeyList) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*k = KeyList{key}
+		return nil
+	}
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("keys must be a string or a list of strings")
+	}
+	*k = keys
+	return nil
+}
+
+// Default returns the built-in settings.
+func Default() Config {
+	return Config{
+		Theme: "auto",
+		Test:  TestConfig{Jobs: 1},
+		Queue: QueueConfig{Policy: "coalesce"},
+		History: HistoryConfig{
+			MaxRuns: history.DefaultRetention.MaxRuns,
+			MaxAge:  Duration(history.DefaultRetention.MaxAge),
+		},
+	}
+}
+
+// UserDir returns the directory of the user's configuration file, e.g. ~/.config/gdd.
+func UserDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gdd"), nil
+}
+
+// Load returns the settings of the module rooted at rootDir: the defaults overridden by the
+// user's file, the project's file and flags, if not nil. Problems don't prevent loading:
+// a file that can't be read or decoded is skipped, an invalid setting keeps its default,
+// and every problem is returned so that it can be shown.
+func Load(rootDir string, flags *Flags) (Config, []error) {
+	var errs []error
+	merged := make(map[string]any)
+
+	var files []string
+	if dir, err := UserDir(); err != nil {
+		log.Warnf("config: No user config directory: %v", err)
+	} else {
+		path, err := findFile(dir, UserTOML, UserJSON)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	path, err := findFile(rootDir, ProjectTOML, ProjectJSON)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if path != "" {
+		files = append(files, path)
+	}
+
+	for _, path := range files {
+		layer, err := readFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w (file ignored)", path, err))
+			continue
+		}
+		log.Debugf("config: Loaded %s", path)
+		mergeMaps(merged, layer)
+	}
+
+	cfg := Default()
+	if err := decode(merged, &cfg); err != nil {
+		// Every layer decoded on its own, so this is not expected.
+		errs = append(errs, fmt.Errorf("merging config files: %w", err))
+		cfg = Default()
+	}
+	if flags != nil {
+		errs = append(errs, flags.apply(&cfg)...)
+	}
+	errs = append(errs, cfg.validate()...)
+	return cfg, errs
+}
+
+// findFile returns the path of the TOML or else the JSON file in dir, or "" if there is neither.
+func findFile(dir, tomlName, jsonName string) (string, error) {
+	tomlPath, jsonPath := filepath.Join(dir, tomlName), filepath.Join(dir, jsonName)
+	_, tomlErr := os.Stat(tomlPath)
+	_, jsonErr := os.Stat(jsonPath)
+	switch {
+	case tomlErr == nil && jsonErr == nil:
+		return tomlPath, fmt.Errorf("both %s and %s exist, %s is ignored", tomlPath, jsonName, jsonName)
+	case tomlErr == nil:
+		return tomlPath, nil
+	case jsonErr == nil:
+		return jsonPath, nil
+	}
+	return "", nil
+}
+
+// readFile reads a configuration file into nested maps, checking that it decodes into a Config.
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var layer map[string]any
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(data, &layer)
+	} else {
+		err = json.Unmarshal(data, &layer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var check Config
+	if err := decode(layer, &check); err != nil {
+		return nil, err
+	}
+	return layer, nil
+}
+
+// decode decodes nested maps into cfg, rejecting unknown settings.
+func decode(layer map[string]any, cfg *Config) error {
+	data, err := json.Marshal(layer)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		// The field names in the errors of encoding/json are those of the files.
+		return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return nil
+}
+
+// mergeMaps merges src into dst: tables are merged recursively, other values replaced.
+func mergeMaps(dst, src map[string]any) {
+	for k, v := range src {
+		if table, ok := v.(map[string]any); ok {
+			if existing, ok := dst[k].(map[string]any); ok {
+				mergeMaps(existing, table)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// reservedFlags are `go test` flags that gdd sets itself and that can't be changed. Those
+// following "exec" are set from the TUI toggles and the profile's tags: as extra flags come
+// after them, they would silently override them.
+var reservedFlags = []string{"json", "v", "run", "c", "o", "coverprofile", "exec",
+	"count", "race", "shuffle", "cpu", "tags", "cover", "covermode", "coverpkg"}
+
+// boolFlags are the boolean `go test` flags, which never take the following token as their value.
+var boolFlags = []string{"short", "failfast", "benchmem", "fullpath", "a", "n", "x", "work",
+	"trimpath", "linkshared", "msan", "asan", "modcacherw", "json", "v", "race", "cover", "c"}
+
+// validate resets invalid settings to their defaults, returning a problem for each.
+func (c *Config) validate() []error {
+	var errs []error
+	defaults := Default()
+
+	for _, name := range slices.Sorted(maps.Keys(c.Themes)) {
+		if slices.Contains(BuiltinThemes, name) {
+			errs = append(errs, fmt.Errorf("themes.%s: built-in themes can't be redefined, ignored", name))
+			delete(c.Themes, name)
+			continue
+		}
+		errs = append(errs, c.validateTheme(name)...)
+	}
+	if _, ok := c.Themes[c.Theme]; !ok && !slices.Contains(BuiltinThemes, c.Theme) {
+		errs = append(errs, fmt.Errorf("theme: unknown theme %q, using %q", c.Theme, defaults.Theme))
+		c.Theme = defaults.Theme
+	}
+
+	if c.Test.Jobs < 0 {
+		errs = append(errs, fmt.Errorf("test.jobs: must not be negative, using %d", defaults.Test.Jobs))
+		c.Test.Jobs = defaults.Test.Jobs
+	}
+
+	var flagErrs, envErrs []error
+	c.Test.Flags, flagErrs = validateFlags("test.flags", c.Test.Flags)
+	envErrs = validateEnv("test.env", c.Test.Env)
+	errs = append(append(errs, flagErrs...), envErrs...)
+
+	for _, name := range slices.Sorted(maps.Keys(c.Profiles)) {
+		profile := c.Profiles[name]
+		profile.Flags, flagErrs = validateFlags("profiles."+name+".flags", profile.Flags)
+		envErrs = validateEnv("profiles."+name+".env", profile.Env)
+		errs = append(append(errs, flagErrs...), envErrs...)
+		tags := profile.Tags[:0]
+		for _, tag := range profile.Tags {
+			if tag == "" || strings.ContainsAny(tag, ", \t") {
+				errs = append(errs, fmt.Errorf("profiles.%s.tags: invalid build tag %q, ignored", name, tag))
+				continue
+			}
+			tags = append(tags, tag)
+		}
+		profile.Tags = tags
+		c.Profiles[name] = profile
+	}
+	if _, ok := c.Profiles[c.Profile]; !ok && c.Profile != "" {
+		errs = append(errs, fmt.Errorf("profile: unknown profile %q (one of %s), using none", c.Profile, strings.Join(slices.Sorted(maps.Keys(c.Profiles)), ", ")))
+		c.Profile = ""
+	}
+
+	dirs := c.Finder.ExcludeDirs[:0]
+	for _, dir := range c.Finder.ExcludeDirs {
+		if _, err := filepath.Match(dir, ""); err != nil || dir == "" {
+			errs = append(errs, fmt.Errorf("finder.exclude_dirs: invalid pattern %q, ignored", dir))
+			continue
+		}
+		dirs = append(dirs, filepath.Clean(dir))
+	}
+	c.Finder.ExcludeDirs = dirs
+
+	if dir, ok := strings.CutPrefix(c.Export.Dir, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			c.Export.Dir = filepath.Join(home, dir)
+		}
+	}
+
+	if !slices.Contains(QueuePolicies, c.Queue.Policy) {
+		errs = append(errs, fmt.Errorf("queue.policy: unknown policy %q (one of %s), using %q", c.Queue.Policy, strings.Join(QueuePolicies, ", "), defaults.Queue.Policy))
+		c.Queue.Policy = defaults.Queue.Policy
+	}
+
+	if c.History.MaxRuns < 0 {
+		errs = append(errs, fmt.Errorf("history.max_runs: must not be negative, using %d", defaults.History.MaxRuns))
+		c.History.MaxRuns = defaults.History.MaxRuns
+	}
+	if c.History.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("history.max_age: must not be negative, using %s", time.Duration(defaults.History.MaxAge)))
+		c.History.MaxAge = defaults.History.MaxAge
+	}
+	return errs
+}
+
+// validateFlags returns the valid `go test` flags of a setting, with a problem for each other one.
+func validateFlags(setting string, flags []string) ([]string, []error) {
+	var errs []error
+	valid := flags[:0]
+	for i := 0; i < len(flags); i++ {
+		flag := flags[i]
+		name, _, hasValue := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+		// A value given after a space, as in "-timeout 5m", is joined to its flag: kept
+		// apart, the flag would take the package path as its value.
+		if strings.HasPrefix(flag, "-") && !hasValue && !slices.Contains(boolFlags, name) &&
+			i+1 < len(flags) && !strings.HasPrefix(flags[i+1], "-") {
+			i++
+			flag += "=" + flags[i]
+		}
+		switch {
+		case !strings.HasPrefix(flag, "-"):
+			errs = append(errs, fmt.Errorf("%s: %q is not a flag, ignored", setting, flag))
+		case name == "tags":
+			errs = append(errs, fmt.Errorf("%s: %q is set by gdd from the tags of the profile, ignored", setting, flag))
+		case slices.Contains(reservedFlags, name):
+			errs = append(errs, fmt.Errorf("%s: %q is set by gdd, ignored", setting, flag))
+		default:
+			valid = append(valid, flag)
+		}
+	}
+	return valid, errs
+}
+
+// validateEnv removes the variables of a setting with invalid names, returning a problem for each.
+func validateEnv(setting string, env map[string]string) []error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		if name == "" || strings.ContainsAny(name, "= \t") {
+			errs = append(errs, fmt.Errorf("%s: invalid variable name %q, ignored", setting, name))
+			delete(env, name)
+		}
+	}
+	return errs
+}
+
+// validateTheme resets the invalid settings of the custom theme name, returning a problem for each.
+func (c *Config) validateTheme(name string) []error {
+	var errs []error
+	theme := c.Themes[name]
+	if theme.Base != "" && !slices.Contains(BuiltinThemes, theme.Base) {
+		errs = append(errs, fmt.Errorf("themes.%s.base: %q is not a built-in theme (one of %s), using \"auto\"", name, theme.Base, strings.Join(BuiltinThemes, ", ")))
+		theme.Base = ""
+	}
+
+	if dir, ok := strings.CutPrefix(theme.Markdown, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			theme.Markdown = filepath.Join(home, dir)
+		}
+	}
+	if _, standard := glamour.DefaultStyles[theme.Markdown]; !standard && theme.Markdown != "" && theme.Markdown != glamour.AutoStyle {
+		if _, err := os.Stat(theme.Markdown); err != nil {
+			errs = append(errs, fmt.Errorf("themes.%s.markdown: %q is neither a glamour style nor a readable file, ignored", name, theme.Markdown))
+			theme.Markdown = ""
+		}
+	}
+
+	for _, role := range slices.Sorted(maps.Keys(theme.Colors)) {
+		if !validColor(theme.Colors[role]) {
+			errs = append(errs, fmt.Errorf("themes.%s.colors.%s: invalid color %q (hex such as \"#7D56F4\" or an ANSI number from 0 to 255), ignored", name, role, theme.Colors[role]))
+			delete(theme.Colors, role)
+		}
+	}
+	c.Themes[name] = theme
+	return errs
+}
+
+// validColor reports whether color is a hex RGB color such as "#7D56F4" or "#FFF", or an ANSI color number.
+func validColor(color string) bool {
+	if hex, ok := strings.CutPrefix(color, "#"); ok {
+		_, err := strconv.ParseUint(hex, 16, 32)
+		return err == nil && (len(hex) == 3 || len(hex) == 6)
+	}
+	n, err := strconv.Atoi(color)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// RunOptions returns the extra flags, environment variables ("KEY=value" pairs, sorted by name)
+// and build tags of test runs with the named profile: those of the test settings, followed by
+// the profile's, whose variables override those of the same name. No profile adds nothing.
+func (c *Config) RunOptions(profile string) (flags, env, tags []string) {
+	p := c.Profiles[profile]
+	flags = slices.Concat(c.Test.Flags, p.Flags)
+
+	vars := maps.Clone(c.Test.Env)
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+	maps.Copy(vars, p.Env)
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		env = append(env, name+"="+vars[name])
+	}
+	return flags, env, slices.Clone(p.Tags)
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Flags are the command line flags that override the configuration files.
+// Only the flags given on the command line override anything.
+type Flags struct {
+	fs          *flag.FlagSet
+	theme       string
+	testFlags   string
+	env         envFlag
+	exclude     string
+	exportDir   string
+	historyRuns int
+	historyAge  string
+	profile     string
+	jobs        int
+	precompile  bool
+}
+
+// RegisterFlags defines the configuration flags on fs. Pass the result to Load once fs is parsed.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.theme, "theme", "", "color theme: auto, dark, light, high-contrast, no-color or a custom theme of the config")
+	fs.StringVar(&f.testFlags, "testflags", "", "extra `go test` flags for every run, space separated (e.g. \"-short -timeout=5m\")")
+	fs.Var(&f.env, "env", "extra environment `KEY=VALUE` for every test run (repeatable)")
+	fs.StringVar(&f.exclude, "exclude", "", "comma separated directories not searched for tests (e.g. \"testdata,internal/gen/*\")")
+	fs.StringVar(&f.exportDir, "export-dir", "", "directory exported reports are written to")
+	fs.IntVar(&f.historyRuns, "history-runs", 0, "number of runs kept in the history (0: no limit)")
+	fs.StringVar(&f.historyAge, "history-age", "", "age after which runs are dropped from the history, e.g. 30d (0: no limit)")
+	fs.StringVar(&f.profile, "profile", "", "profile of test runs, as defined in the config (e.g. \"integration\")")
+	fs.IntVar(&f.jobs, "jobs", 1, "number of packages tested at once, each by its own go test process (1: a single process, 0: one per CPU)")
+	fs.BoolVar(&f.precompile, "precompile", false, "build test binaries once into .gdd/testbin and reuse them until the code changes")
+	return f
+}
+
+// apply overrides the settings of cfg with the flags that were set.
+func (f *Flags) apply(cfg *Config) []error {
+	var errs []error
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "theme":
+			cfg.Theme = f.theme
+		case "testflags":
+			cfg.Test.Flags = strings.Fields(f.testFlags)
+		case "env":
+			if cfg.Test.Env == nil {
+				cfg.Test.Env = make(map[string]string)
+			}
+			for _, kv := range f.env {
+				name, value, _ := strings.Cut(kv, "=")
+				cfg.Test.Env[name] = value
+			}
+		case "exclude":
+			cfg.Finder.ExcludeDirs = nil
+			for _, dir := range strings.Split(f.exclude, ",") {
+				if dir = strings.TrimSpace(dir); dir != "" {
+					cfg.Finder.ExcludeDirs = append(cfg.Finder.ExcludeDirs, dir)
+				}
+			}
+		case "export-dir":
+			cfg.Export.Dir = f.exportDir
+		case "history-runs":
+			cfg.History.MaxRuns = f.historyRuns
+		case "history-age":
+			age, err := ParseDuration(f.historyAge)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("-history-age: %w", err))
+				return
+			}
+			cfg.History.MaxAge = age
+		case "profile":
+			cfg.Profile = f.profile
+		case "jobs":
+			cfg.Test.Jobs = f.jobs
+		case "precompile":
+			cfg.Test.Precompile = f.precompile
+		}
+	})
+	return errs
+}
+
+// envFlag collects the values of a repeatable KEY=VALUE flag.
+type envFlag []string
+
+func (e *envFlag) String() string { return strings.Join(*e, " ") }
+
+func (e *envFlag) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected KEY=VALUE, got %q", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
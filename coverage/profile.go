@@ -0,0 +1,143 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Block is a single coverage block of a cover profile: a range of source
+// code with the number of statements it contains and how often it ran.
+type Block struct {
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	NumStmt   int
+	Count     int
+}
+
+// Profile holds the coverage blocks of one source file.
+type Profile struct {
+	FileName string // As written in the profile: import path + file name (e.g. "gdd/parser/parser.go")
+	Mode     string // "set", "count" or "atomic"
+	Blocks   []Block
+}
+
+// Package returns the import path of the package the profiled file belongs to.
+func (p *Profile) Package() string {
+	return path.Dir(p.FileName)
+}
+
+// Statements returns the number of covered and total statements in the file.
+func (p *Profile) Statements() (covered, total int64) {
+	for _, b := range p.Blocks {
+		total += int64(b.NumStmt)
+		if b.Count > 0 {
+			covered += int64(b.NumStmt)
+		}
+	}
+	return covered, total
+}
+
+// Percent returns the statement coverage of covered/total as a percentage (0 if total is 0).
+func Percent(covered, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total) * 100
+}
+
+// e.g. "gdd/parser/parser.go:12.34,15.2 3 1"
+var profileLineRe = regexp.MustCompile(`^(.+):(\d+)\.(\d+),(\d+)\.(\d+) (\d+) (\d+)$`)
+
+// ParseProfiles reads a cover profile as written by `go test -coverprofile`.
+// Blocks that appear several times (which happens with -coverpkg, where every test
+// binary reports every selected package) are merged. Profiles are sorted by file name.
+func ParseProfiles(fileName string) ([]*Profile, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("could not open cover profile %s: %w", fileName, err)
+	}
+	defer f.Close()
+
+	files := make(map[string]*Profile)
+	// Key: file name + block position, used to merge duplicate blocks.
+	blockIndex := make(map[string]int)
+	mode := ""
+
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "mode: ") {
+			mode = strings.TrimPrefix(line, "mode: ")
+			continue
+		}
+
+		m := profileLineRe.FindStringSubmatch(line)
+		if m == nil {
+			log.Warnf("Skipping malformed cover profile line %d: %s", lineNumber, line)
+			continue
+		}
+		block := Block{
+			StartLine: atoi(m[2]),
+			StartCol:  atoi(m[3]),
+			EndLine:   atoi(m[4]),
+			EndCol:    atoi(m[5]),
+			NumStmt:   atoi(m[6]),
+			Count:     atoi(m[7]),
+		}
+
+		p, ok := files[m[1]]
+		if !ok {
+			p = &Profile{FileName: m[1], Mode: mode}
+			files[m[1]] = p
+		}
+
+		key := m[1] + ":" + strings.Join(m[2:6], ",")
+		if idx, seen := blockIndex[key]; seen {
+			if mode == "set" {
+				p.Blocks[idx].Count = max(p.Blocks[idx].Count, block.Count)
+			} else {
+				p.Blocks[idx].Count += block.Count
+			}
+			continue
+		}
+		blockIndex[key] = len(p.Blocks)
+		p.Blocks = append(p.Blocks, block)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading cover profile %s: %w", fileName, err)
+	}
+
+	profiles := make([]*Profile, 0, len(files))
+	for _, p := range files {
+		sort.Slice(p.Blocks, func(i, j int) bool {
+			if p.Blocks[i].StartLine != p.Blocks[j].StartLine {
+				return p.Blocks[i].StartLine < p.Blocks[j].StartLine
+			}
+			return p.Blocks[i].StartCol < p.Blocks[j].StartCol
+		})
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].FileName < profiles[j].FileName })
+
+	return profiles, nil
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,250 @@
+package coverage
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gdd/finder"
+
+	"github.com/charmbracelet/log"
+)
+
+// FuncStats is the statement coverage of a single function.
+type FuncStats struct {
+	Package   string // Import path of the package
+	File      string // Path of the source file relative to the module root
+	Name      string // Function name, with receiver for methods (e.g. "(*Server).Start")
+	StartLine int
+	Covered   int64
+	Total     int64
+}
+
+// Percent returns the function's statement coverage as a percentage.
+func (f FuncStats) Percent() float64 {
+	return Percent(f.Covered, f.Total)
+}
+
+// PackageStats is the statement coverage of a package, computed from the profile.
+type PackageStats struct {
+	Package string
+	Covered int64
+	Total   int64
+}
+
+// Percent returns the package's statement coverage as a percentage.
+func (p PackageStats) Percent() float64 {
+	return Percent(p.Covered, p.Total)
+}
+
+// Report is the result of loading a cover profile for a test run.
+type Report struct {
+	ProfilePath string
+	Mode        string
+	Profiles    []*Profile
+	Packages    []PackageStats
+	Funcs       []FuncStats
+	Covered     int64
+	Total       int64
+
+	resolver *Resolver
+}
+
+// Percent returns the overall statement coverage as a percentage.
+func (r *Report) Percent() float64 {
+	return Percent(r.Covered, r.Total)
+}
+
+// SourcePath returns the path on disk of a file named in the profile, or an error if it cannot be resolved.
+func (r *Report) SourcePath(profileFile string) (string, error) {
+	return r.resolver.Resolve(profileFile)
+}
+
+// RelPath returns the path of a profile file relative to the module root,
+// or the profile file name itself if it is outside of the module.
+func (r *Report) RelPath(profileFile string) string {
+	if rel, ok := r.resolver.Rel(profileFile); ok {
+		return rel
+	}
+	return profileFile
+}
+
+// Resolver maps file names from a cover profile (import path + file) to paths on disk.
+type Resolver struct {
+	rootDir    string
+	modulePath string
+}
+
+// NewResolver creates a Resolver for the module rooted at rootDir.
+func NewResolver(rootDir string) (*Resolver, error) {
+	modulePath, err := finder.ModulePath(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{rootDir: rootDir, modulePath: modulePath}, nil
+}
+
+// Resolve returns the path on disk of a profile file name. Only files of the
+// module itself can be resolved; absolute paths are returned as is.
+func (r *Resolver) Resolve(profileFile string) (string, error) {
+	if filepath.IsAbs(profileFile) {
+		return profileFile, nil
+	}
+	if rel, ok := r.Rel(profileFile); ok {
+		return filepath.Join(r.rootDir, filepath.FromSlash(rel)), nil
+	}
+	return "", fmt.Errorf("file %s is outside of module %s", profileFile, r.modulePath)
+}
+
+// Rel returns the slash-separated path of a profile file relative to the module root.
+func (r *Resolver) Rel(profileFile string) (string, bool) {
+	if rel, ok := strings.CutPrefix(profileFile, r.modulePath+"/"); ok {
+		return rel, true
+	}
+	return "", false
+}
+
+// Load parses the cover profile at profilePath and computes per-package and
+// per-function statistics for the module rooted at rootDir.
+func Load(profilePath, rootDir string) (*Report, error) {
+	profiles, err := ParseProfiles(profilePath)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := NewResolver(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{ProfilePath: profilePath, Profiles: profiles, resolver: resolver}
+
+	packages := make(map[string]*PackageStats)
+	var packageOrder []string
+	for _, p := range profiles {
+		report.Mode = p.Mode
+		covered, total := p.Statements()
+		report.Covered += covered
+		report.Total += total
+
+		pkg, ok := packages[p.Package()]
+		if !ok {
+			pkg = &PackageStats{Package: p.Package()}
+			packages[p.Package()] = pkg
+			packageOrder = append(packageOrder, p.Package())
+		}
+		pkg.Covered += covered
+		pkg.Total += total
+
+		funcs, err := funcStats(p, resolver)
+		if err != nil {
+			log.Warnf("Could not compute function coverage for %s: %v", p.FileName, err)
+			continue
+		}
+		report.Funcs = append(report.Funcs, funcs...)
+	}
+	for _, name := range packageOrder {
+		report.Packages = append(report.Packages, *packages[name])
+	}
+
+	log.Infof("Loaded cover profile %s: %d files, %.1f%% of statements.", profilePath, len(profiles), report.Percent())
+	return report, nil
+}
+
+// funcStats computes per-function coverage for a profiled file by parsing its source
+// and attributing every block to the function whose body contains it.
+func funcStats(p *Profile, resolver *Resolver) ([]FuncStats, error) {
+	fileName, err := resolver.Resolve(p.FileName)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, fileName, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", fileName, err)
+	}
+
+	relFile, _ := resolver.Rel(p.FileName)
+
+	var funcs []FuncStats
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		start := fset.Position(fn.Pos())
+		end := fset.Position(fn.End())
+
+		stats := FuncStats{
+			Package:   p.Package(),
+			File:      relFile,
+			Name:      funcName(fn),
+			StartLine: start.Line,
+		}
+		for _, b := range p.Blocks {
+			if !blockWithin(b, start, end) {
+				continue
+			}
+			stats.Total += int64(b.NumStmt)
+			if b.Count > 0 {
+				stats.Covered += int64(b.NumStmt)
+			}
+		}
+		funcs = append(funcs, stats)
+	}
+	return funcs, nil
+}
+
+// blockWithin reports whether a block lies between the start and end positions.
+func blockWithin(b Block, start, end token.Position) bool {
+	afterStart := b.StartLine > start.Line || (b.StartLine == start.Line && b.StartCol >= start.Column)
+	beforeEnd := b.EndLine < end.Line || (b.EndLine == end.Line && b.EndCol <= end.Column)
+	return afterStart && beforeEnd
+}
+
+// funcName returns the name of a function, prefixed with its receiver type for methods.
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	prefix := ""
+	if star, ok := recv.(*ast.StarExpr); ok {
+		prefix = "*"
+		recv = star.X
+	}
+	// Strip type parameters, e.g. List[T].
+	if idx, ok := recv.(*ast.IndexExpr); ok {
+		recv = idx.X
+	} else if idx, ok := recv.(*ast.IndexListExpr); ok {
+		recv = idx.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return fmt.Sprintf("(%s%s).%s", prefix, ident.Name, fn.Name.Name)
+	}
+	return fn.Name.Name
+}
+
+// LeastCovered returns up to n functions with statements, sorted by ascending coverage.
+func (r *Report) LeastCovered(n int) []FuncStats {
+	var funcs []FuncStats
+	for _, f := range r.Funcs {
+		if f.Total > 0 {
+			funcs = append(funcs, f)
+		}
+	}
+	sort.SliceStable(funcs, func(i, j int) bool {
+		if funcs[i].Percent() != funcs[j].Percent() {
+			return funcs[i].Percent() < funcs[j].Percent()
+		}
+		return funcs[i].Total > funcs[j].Total // Bigger uncovered functions first
+	})
+	if n > 0 && len(funcs) > n {
+		funcs = funcs[:n]
+	}
+	return funcs
+}
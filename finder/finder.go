@@ -6,7 +6,9 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/log"
@@ -18,6 +20,10 @@ type TestInfo struct {
 	PackageName string // Package name declared in the file (e.g., "mypackage")
 	PackageDir  string // Directory containing the test file, relative to rootDir (e.g., "app/server")
 	FilePath    string // Full path to the test file
+	// Subtests are the full names (e.g. "TestMyFunction/empty_input") of the subtests started
+	// with a string literal name, as `go test` reports them. Table-driven subtests whose names
+	// are only known at run time are not listed.
+	Subtests []string
 	// The "run" target for 'go test' for a single test is typically '<PackageDir> -run ^TestName$'
 	// The "run" target for a package is typically '<PackageDir>'
 }
@@ -25,7 +31,8 @@ type TestInfo struct {
 // FindTests scans the given root directory for Go test files and extracts test functions.
 // It searches recursively starting from the rootDir.
 // rootDir should be the module root. PackageDir will be relative to this root.
-func FindTests(rootDir string) ([]TestInfo, error) {
+// Directories matching one of excludeDirs (see Excluded) are not searched.
+func FindTests(rootDir string, excludeDirs ...string) ([]TestInfo, error) {
 	var tests []TestInfo
 	fset := token.NewFileSet()
 
@@ -48,6 +55,10 @@ func FindTests(rootDir string) ([]TestInfo, error) {
 				log.Debugf("Skipping directory: %s", path)
 				return filepath.SkipDir
 			}
+			if rel, err := filepath.Rel(absRootDir, path); err == nil && rel != "." && Excluded(rel, excludeDirs) {
+				log.Debugf("Skipping excluded directory: %s", path)
+				return filepath.SkipDir
+			}
 		}
 
 		if !info.IsDir() && strings.HasSuffix(info.Name(), "_test.go") {
@@ -107,6 +118,7 @@ func FindTests(rootDir string) ([]TestInfo, error) {
 							PackageName: declaredPackageName,
 							FilePath:    path,       // Store full path, can be useful
 							PackageDir:  packageDir, // Relative path for `go test` command
+							Subtests:    findSubtests(fn.Body, paramName(fn.Type), fn.Name.Name),
 						})
 					}
 				}
@@ -153,3 +165,84 @@ func isValidTestFunc(fn *ast.FuncDecl) bool {
 	// We only care about *testing.T for runnable tests via `go test -run TestFunc`
 	return pkgIdent.Name == "testing" && selectorExpr.Sel.Name == "T"
 }
+
+// findSubtests returns the full names of the subtests started in body with tName.Run and a
+// string literal name, recursing into subtests whose function is a literal.
+func findSubtests(body *ast.BlockStmt, tName, prefix string) []string {
+	if body == nil || tName == "" || tName == "_" {
+		return nil
+	}
+	var subtests []string
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Run" {
+			return true
+		}
+		if recv, ok := sel.X.(*ast.Ident); !ok || recv.Name != tName {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		name, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		// `go test` replaces spaces in subtest names with underscores.
+		fullName := prefix + "/" + strings.ReplaceAll(name, " ", "_")
+		subtests = append(subtests, fullName)
+		if fn, ok := call.Args[1].(*ast.FuncLit); ok {
+			subtests = append(subtests, findSubtests(fn.Body, paramName(fn.Type), fullName)...)
+			return false // Its body was searched with the subtest's *testing.T
+		}
+		return true
+	})
+	return subtests
+}
+
+// paramName returns the name of the single parameter of a function, or "" if it has none.
+func paramName(fnType *ast.FuncType) string {
+	if fnType.Params == nil || len(fnType.Params.List) != 1 || len(fnType.Params.List[0].Names) != 1 {
+		return ""
+	}
+	return fnType.Params.List[0].Names[0].Name
+}
+
+// ModulePath reads the module path declared in the go.mod file of rootDir (e.g. "github.com/user/project").
+// It is used to map package import paths, as found in coverage profiles, back to directories.
+func ModulePath(rootDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(rootDir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("could not read go.mod in %s: %w", rootDir, err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("no module directive found in %s", filepath.Join(rootDir, "go.mod"))
+}
+
+// Excluded reports whether the directory at relDir, relative to the module root, matches one
+// of the patterns, either by its name (e.g. "testdata") or by its path (e.g. "internal/gen/*").
+func Excluded(relDir string, patterns []string) bool {
+	relDir = filepath.ToSlash(relDir)
+	name := path.Base(relDir)
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(pattern)
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, relDir); ok {
+			return true
+		}
+	}
+	return false
+}
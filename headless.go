@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"gdd/changes"
+	"gdd/config"
+	"gdd/parser"
+	"gdd/quarantine"
+	"gdd/runner"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// Exit codes of headless runs, suitable for git hooks and CI.
+const (
+	exitOK          = 0 // All tests passed (or there was nothing to run)
+	exitTestsFailed = 1 // At least one test or package failed
+	exitError       = 2 // The run could not be set up
+)
+
+// runChangedHeadless runs the tests of the packages affected by the git changes against
+// baseRef without starting the TUI, e.g. from a pre-push hook, with the test flags,
+// environment and profile of cfg. It returns the exit code.
+func runChangedHeadless(baseRef string, cfg config.Config) int {
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not get working directory: %v\n", err)
+		return exitError
+	}
+
+	result, err := changes.Affected(wd, baseRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not determine changed packages: %v\n", err)
+		return exitError
+	}
+	if len(result.Affected) == 0 {
+		fmt.Printf("No packages affected by changes against %s (%d files changed).\n", result.BaseRef, len(result.Files))
+		return exitOK
+	}
+
+	fmt.Printf("%d files changed against %s, testing %d affected packages:\n", len(result.Files), result.BaseRef, len(result.Affected))
+	for _, pkg := range result.Affected {
+		fmt.Printf("  %s\n", pkg)
+	}
+	fmt.Println()
+
+	runCfg := runner.TestRunConfig{
+		Type:        runner.ChangedTests,
+		Packages:    result.Affected,
+		BaseRef:     result.BaseRef,
+		WorkingDir:  wd,
+		Profile:     cfg.Profile,
+		Jobs:        cfg.Test.ParallelJobs(),
+		Precompiled: cfg.Test.Precompile,
+	}
+	runCfg.Flags, runCfg.Env, runCfg.Tags = cfg.RunOptions(cfg.Profile)
+	if cfg.Profile != "" {
+		fmt.Printf("Using profile %s.\n\n", cfg.Profile)
+	}
+	return runHeadless(runCfg)
+}
+
+// runHeadless runs the tests of the given config, printing their output as `go test -v` would,
+// followed by a summary. It returns the exit code.
+func runHeadless(cfg runner.TestRunConfig) int {
+	var output bytes.Buffer
+	var complete runner.TestRunCompleteMsg
+
+	// Run calls back from the watchdog goroutine only for slow-test warnings, which are disabled
+	// here, but from one goroutine per job when it tests packages at once.
+	var mu sync.Mutex
+	runner.Run(cfg, func(msg tea.Msg) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch msg := msg.(type) {
+		case runner.TestOutputLineMsg:
+			output.WriteString(msg.Line + "\n")
+			var event parser.TestEvent
+			if err := json.Unmarshal([]byte(msg.Line), &event); err != nil {
+				fmt.Println(msg.Line) // Not JSON, e.g. a build error
+				return
+			}
+			if event.Action == "output" {
+				fmt.Print(event.Output)
+			}
+		case runner.TestRunCompleteMsg:
+			complete = msg
+		}
+	})
+
+	results, err := parser.Parse(output.Bytes())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not parse test output: %v\n", err)
+		return exitError
+	}
+
+	quarantined, err := quarantine.Load(cfg.WorkingDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; quarantined tests will fail the run.\n", err)
+	}
+
+	var passed, failed, skipped int
+	var flaky []string // Failures of quarantined tests, which do not fail the run
+	packagesFailed := 0
+	for _, pkg := range results {
+		if quarantined.Failed(pkg) {
+			packagesFailed++
+		}
+		for _, t := range pkg.Tests {
+			switch t.Status {
+			case parser.StatusPass:
+				passed++
+			case parser.StatusSkip:
+				skipped++
+			case parser.StatusFail, parser.StatusTimeout:
+				if quarantined.FailureQuarantined(pkg, t.Name) {
+					flaky = append(flaky, fmt.Sprintf("%s (%s)", t.Name, pkg.PackageName))
+				} else {
+					failed++
+				}
+			}
+		}
+	}
+
+	if len(flaky) > 0 {
+		fmt.Printf("\n%d quarantined tests failed (not counted as failures):\n", len(flaky))
+		for _, name := range flaky {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+	fmt.Printf("\n%d passed, %d failed, %d skipped in %d packages (%d failed).\n", passed, failed, skipped, len(results), packagesFailed)
+	if complete.Err != nil {
+		log.Warnf("Headless run finished with error: %v", complete.Err)
+	}
+	if failed > 0 || packagesFailed > 0 || (complete.Err != nil && len(results) == 0) {
+		return exitTestsFailed
+	}
+	return exitOK
+}
@@ -0,0 +1,235 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"gdd/parser"
+)
+
+// Thresholds for a duration change to count as significant: both must be exceeded,
+// so that tiny tests jittering by a few microseconds are not reported.
+const (
+	SignificantDurationRatio = 0.2                   // Relative change, e.g. 0.2 = 20% slower or faster
+	SignificantDurationDelta = 50 * time.Millisecond // Absolute change
+)
+
+// maxDiffLines bounds the output diff of a test, since the line diff is quadratic.
+const maxDiffLines = 1000
+
+// TestChange pairs the results of one test in two runs. Base or Head is nil
+// when the test did not exist in that run.
+type TestChange struct {
+	Package string
+	Name    string
+	Base    *parser.TestResult
+	Head    *parser.TestResult
+}
+
+// DurationDelta returns how much slower (positive) or faster (negative) the test got.
+func (c TestChange) DurationDelta() time.Duration {
+	if c.Base == nil || c.Head == nil {
+		return 0
+	}
+	return c.Head.Duration - c.Base.Duration
+}
+
+// OutputDiff returns a line diff of the test's output between the two runs, with lines
+// prefixed by "-" (only in base), "+" (only in head) or " " (in both).
+func (c TestChange) OutputDiff() []string {
+	if c.Base == nil || c.Head == nil {
+		return nil
+	}
+	return DiffLines(trimLines(c.Base.Output), trimLines(c.Head.Output))
+}
+
+// trimLines strips the trailing newlines that `go test -json` output lines carry.
+func trimLines(lines []string) []string {
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimRight(line, "\r\n")
+	}
+	return trimmed
+}
+
+// PackageChange pairs the results of one package in two runs.
+type PackageChange struct {
+	Package string
+	Base    *parser.PackageResult
+	Head    *parser.PackageResult
+}
+
+// DurationDelta returns how much slower (positive) or faster (negative) the package got.
+func (c PackageChange) DurationDelta() time.Duration {
+	if c.Base == nil || c.Head == nil {
+		return 0
+	}
+	return c.Head.Duration - c.Base.Duration
+}
+
+// RunDiff is the comparison of a base run with a later head run.
+type RunDiff struct {
+	NewlyFailing []TestChange // Passed (or was skipped) in base, fails in head
+	NewlyPassing []TestChange // Failed in base, passes in head
+	StillFailing []TestChange // Fails in both runs
+	Added        []TestChange // Only in head
+	Removed      []TestChange // Only in base
+	Slower       []TestChange // Significantly slower in head, slowest first
+	Faster       []TestChange // Significantly faster in head, fastest first
+	Packages     []PackageChange
+}
+
+// Unchanged reports whether the runs have the same outcome for every test.
+func (d *RunDiff) Unchanged() bool {
+	return len(d.NewlyFailing) == 0 && len(d.NewlyPassing) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// testKey identifies a test across runs.
+type testKey struct{ pkg, name string }
+
+// Compare compares the results of a base run with those of a head run.
+// Packages are listed when their status changed or their duration changed significantly.
+func Compare(base, head []*parser.PackageResult) *RunDiff {
+	baseTests, baseOrder := indexTests(base)
+	headTests, headOrder := indexTests(head)
+	diff := &RunDiff{}
+
+	for _, key := range headOrder {
+		h := headTests[key]
+		b, ok := baseTests[key]
+		change := TestChange{Package: key.pkg, Name: key.name, Base: b, Head: h}
+		if !ok {
+			diff.Added = append(diff.Added, change)
+			continue
+		}
+
+		switch {
+		case failed(b.Status) && failed(h.Status):
+			diff.StillFailing = append(diff.StillFailing, change)
+		case failed(h.Status):
+			diff.NewlyFailing = append(diff.NewlyFailing, change)
+		case failed(b.Status) && h.Status == parser.StatusPass:
+			diff.NewlyPassing = append(diff.NewlyPassing, change)
+		}
+
+		if significant(b.Duration, h.Duration) {
+			if h.Duration > b.Duration {
+				diff.Slower = append(diff.Slower, change)
+			} else {
+				diff.Faster = append(diff.Faster, change)
+			}
+		}
+	}
+	for _, key := range baseOrder {
+		if _, ok := headTests[key]; !ok {
+			diff.Removed = append(diff.Removed, TestChange{Package: key.pkg, Name: key.name, Base: baseTests[key]})
+		}
+	}
+	sort.SliceStable(diff.Slower, func(i, j int) bool { return diff.Slower[i].DurationDelta() > diff.Slower[j].DurationDelta() })
+	sort.SliceStable(diff.Faster, func(i, j int) bool { return diff.Faster[i].DurationDelta() < diff.Faster[j].DurationDelta() })
+
+	basePackages := make(map[string]*parser.PackageResult, len(base))
+	for _, pkg := range base {
+		basePackages[pkg.PackageName] = pkg
+	}
+	headPackages := make(map[string]bool, len(head))
+	for _, h := range head {
+		headPackages[h.PackageName] = true
+		b, ok := basePackages[h.PackageName]
+		if !ok || b.Status != h.Status || significant(b.Duration, h.Duration) {
+			diff.Packages = append(diff.Packages, PackageChange{Package: h.PackageName, Base: b, Head: h})
+		}
+	}
+	for _, b := range base {
+		if !headPackages[b.PackageName] {
+			diff.Packages = append(diff.Packages, PackageChange{Package: b.PackageName, Base: b})
+		}
+	}
+
+	return diff
+}
+
+// indexTests maps every test of a run by package and name, keeping the run's order.
+func indexTests(results []*parser.PackageResult) (map[testKey]*parser.TestResult, []testKey) {
+	tests := make(map[testKey]*parser.TestResult)
+	var order []testKey
+	for _, pkg := range results {
+		for _, t := range pkg.Tests {
+			key := testKey{pkg: pkg.PackageName, name: t.Name}
+			if _, dup := tests[key]; !dup {
+				order = append(order, key)
+			}
+			tests[key] = t
+		}
+	}
+	return tests, order
+}
+
+// failed reports whether a status counts as a failure.
+func failed(status parser.TestStatus) bool {
+	return status == parser.StatusFail || status == parser.StatusTimeout
+}
+
+// significant reports whether a duration change exceeds both significance thresholds.
+func significant(base, head time.Duration) bool {
+	delta := head - base
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta < SignificantDurationDelta {
+		return false
+	}
+	return base == 0 || float64(delta)/float64(base) >= SignificantDurationRatio
+}
+
+// DiffLines computes a line diff of a and b based on their longest common subsequence.
+// Lines are prefixed by "-" (only in a), "+" (only in b) or " " (in both).
+// Inputs longer than maxDiffLines are truncated.
+func DiffLines(a, b []string) []string {
+	if len(a) > maxDiffLines {
+		a = a[:maxDiffLines]
+	}
+	if len(b) > maxDiffLines {
+		b = b[:maxDiffLines]
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+"+b[j])
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package history
+
+import (
+	"gdd/parser"
+
+	"github.com/charmbracelet/log"
+)
+
+// Flakiness scoring. A test that failed and then passed on the very next run without
+// any change to the sources is almost certainly flaky; a test whose status keeps
+// alternating is likely flaky too, although code changes may explain some of the flips.
+const (
+	// RerunPassWeight is added to the score for every failure that passed on an immediate
+	// rerun of the same sources.
+	RerunPassWeight = 0.5
+	// MinFlips is how many status changes a test needs before alternation counts towards
+	// its score. A test that broke and was fixed flips twice, which is not flakiness.
+	MinFlips = 3
+	// FlakyThreshold is the score from which a test is considered flaky.
+	FlakyThreshold = 0.3
+)
+
+// Flakiness summarizes how a test behaved across the stored runs.
+type Flakiness struct {
+	Package     string
+	Test        string
+	Runs        int     // Runs in which the test passed or failed (skips are ignored)
+	Failures    int     // Runs in which the test failed or timed out
+	RerunPasses int     // Failures followed by a pass in the next run of the same sources
+	Flips       int     // Status changes between consecutive runs of the test
+	Score       float64 // Between 0 (stable) and 1 (certainly flaky)
+}
+
+// Flaky reports whether the score reaches FlakyThreshold.
+func (f *Flakiness) Flaky() bool {
+	return f.Score >= FlakyThreshold
+}
+
+// TestKey returns the key of a test in the maps returned by Store.Flakiness and Store.LastResults.
+func TestKey(pkg, test string) string {
+	return pkg + "\x00" + test
+}
+
+// observation is the outcome of a test in one stored run.
+type observation struct {
+	failed     bool
+	sourceHash string
+}
+
+// Flakiness loads every stored run, oldest first, and scores each test that ran in them.
+// The result is keyed by TestKey(package import path, test name). Subtests are scored
+// on their own, under their full name.
+func (s *Store) Flakiness() (map[string]*Flakiness, error) {
+	scores := make(map[string]*Flakiness)
+	last := make(map[string]observation)
+	runs, err := s.eachRun(func(entry *Entry, results []*parser.PackageResult) {
+		for _, pkg := range results {
+			for _, t := range pkg.Tests {
+				var obs observation
+				switch t.Status {
+				case parser.StatusPass:
+				case parser.StatusFail, parser.StatusTimeout:
+					obs.failed = true
+				default:
+					continue
+				}
+				obs.sourceHash = entry.SourceHash
+
+				key := TestKey(pkg.PackageName, t.Name)
+				f, ok := scores[key]
+				if !ok {
+					f = &Flakiness{Package: pkg.PackageName, Test: t.Name}
+					scores[key] = f
+				}
+				f.Runs++
+				if obs.failed {
+					f.Failures++
+				}
+				if prev, seen := last[key]; seen && prev.failed != obs.failed {
+					f.Flips++
+					if prev.failed && obs.sourceHash != "" && obs.sourceHash == prev.sourceHash {
+						f.RerunPasses++
+					}
+				}
+				last[key] = obs
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range scores {
+		f.Score = f.score()
+	}
+	log.Debugf("Flakiness: scored %d tests over %d runs.", len(scores), runs)
+	return scores, nil
+}
+
+// score combines the rerun passes and the rate of status changes into a score between 0 and 1.
+func (f *Flakiness) score() float64 {
+	score := RerunPassWeight * float64(f.RerunPasses)
+	if f.Flips >= MinFlips && f.Runs > 1 {
+		score += float64(f.Flips) / float64(f.Runs-1)
+	}
+	return min(score, 1)
+}
@@ -0,0 +1,264 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gdd/parser"
+	"gdd/runner"
+
+	"github.com/charmbracelet/log"
+)
+
+// DefaultDir is where runs are stored, relative to the module root.
+const DefaultDir = ".gdd/history"
+
+// File suffixes of the files making up a stored run, named after the run ID.
+const (
+	metaSuffix   = ".json"  // Entry metadata
+	eventsSuffix = ".jsonl" // Raw `go test -json` event stream
+	coverSuffix  = ".cover" // Copy of the cover profile, if coverage was collected
+)
+
+// ErrNotFound is returned by Load when a run is not in the store (e.g. it was pruned).
+var ErrNotFound = errors.New("run not found in history")
+
+// Retention limits how much history is kept. Zero values mean no limit.
+type Retention struct {
+	MaxRuns int           // Keep at most this many runs, newest first
+	MaxAge  time.Duration // Drop runs older than this
+}
+
+// DefaultRetention keeps the last 100 runs of the past 30 days.
+var DefaultRetention = Retention{MaxRuns: 100, MaxAge: 30 * 24 * time.Hour}
+
+// Entry is the metadata of a stored run, enough to list it without loading its events.
+type Entry struct {
+	ID        string               `json:"id"`
+	StartedAt time.Time            `json:"startedAt"`
+	Duration  time.Duration        `json:"duration"`
+	Config    runner.TestRunConfig `json:"config"`
+	Status    parser.TestStatus    `json:"status"`
+	Packages  int                  `json:"packages"`
+	Passed    int                  `json:"passed"`
+	Failed    int                  `json:"failed"`
+	Skipped   int                  `json:"skipped"`
+	Coverage  bool                 `json:"coverage"` // Whether a cover profile was stored with the run
+	// SourceHash fingerprints the module's sources when the run started (see watch.Fingerprint),
+	// so that reruns without code changes can be told apart. Empty if unknown.
+	SourceHash string `json:"sourceHash,omitempty"`
+}
+
+// Store persists test runs under a directory.
+type Store struct {
+	dir       string
+	retention Retention
+}
+
+// Open returns the store of the module rooted at rootDir. The directory is created on first save.
+func Open(rootDir string, retention Retention) *Store {
+	return &Store{dir: filepath.Join(rootDir, DefaultDir), retention: retention}
+}
+
+// NewEntry summarizes a completed run.
+func NewEntry(cfg runner.TestRunConfig, results []*parser.PackageResult, startedAt time.Time, duration time.Duration) *Entry {
+	entry := &Entry{
+		// Sortable and unique enough for runs started by one user.
+		ID:        startedAt.UTC().Format("20060102T150405.000000000Z"),
+		StartedAt: startedAt,
+		Duration:  duration,
+		Config:    cfg,
+		Status:    parser.StatusPass,
+		Packages:  len(results),
+	}
+	for _, pkg := range results {
+		if pkg.Status == parser.StatusFail || pkg.Status == parser.StatusTimeout {
+			entry.Status = parser.StatusFail
+		}
+		for _, t := range pkg.Tests {
+			switch t.Status {
+			case parser.StatusPass:
+				entry.Passed++
+			case parser.StatusFail, parser.StatusTimeout:
+				entry.Failed++
+				entry.Status = parser.StatusFail
+			case parser.StatusSkip:
+				entry.Skipped++
+			}
+		}
+	}
+	return entry
+}
+
+// Save stores a run: its metadata, its raw event stream and, if coverProfile is set,
+// a copy of the cover profile. Runs beyond the retention limits are pruned afterwards.
+func (s *Store) Save(entry *Entry, events []byte, coverProfile string) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("could not create history directory: %w", err)
+	}
+
+	if err := os.WriteFile(s.path(entry.ID, eventsSuffix), events, 0o644); err != nil {
+		return fmt.Errorf("could not write run events: %w", err)
+	}
+	if coverProfile != "" {
+		if err := copyFile(coverProfile, s.path(entry.ID, coverSuffix)); err != nil {
+			log.Warnf("Could not store cover profile of run %s: %v", entry.ID, err)
+		} else {
+			entry.Coverage = true
+		}
+	}
+
+	// The metadata is written last, so a run only shows up in List once it is complete.
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode run metadata: %w", err)
+	}
+	if err := os.WriteFile(s.path(entry.ID, metaSuffix), data, 0o644); err != nil {
+		return fmt.Errorf("could not write run metadata: %w", err)
+	}
+	log.Infof("Saved run %s (%s, %d passed, %d failed) to history.", entry.ID, entry.Config.Type, entry.Passed, entry.Failed)
+
+	return s.prune()
+}
+
+// List returns the stored runs, newest first.
+func (s *Store) List() ([]*Entry, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read history directory: %w", err)
+	}
+
+	var entries []*Entry
+	for _, de := range dirEntries {
+		id, ok := strings.CutSuffix(de.Name(), metaSuffix)
+		if !ok || de.IsDir() {
+			continue
+		}
+		entry, err := s.readEntry(id)
+		if err != nil {
+			log.Warnf("Skipping unreadable history entry %s: %v", de.Name(), err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].StartedAt.After(entries[j].StartedAt) })
+	return entries, nil
+}
+
+// Load returns the metadata and the raw event stream of a stored run.
+func (s *Store) Load(id string) (*Entry, []byte, error) {
+	entry, err := s.readEntry(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	events, err := os.ReadFile(s.path(id, eventsSuffix))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read events of run %s: %w", id, err)
+	}
+	return entry, events, nil
+}
+
+// eachRun parses every stored run and calls fn with it, oldest first. Runs that can't be
+// loaded or parsed are skipped. It returns the number of runs passed to fn.
+func (s *Store) eachRun(fn func(entry *Entry, results []*parser.PackageResult)) (int, error) {
+	entries, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+	runs := 0
+	for i := len(entries) - 1; i >= 0; i-- { // List is newest first
+		entry, events, err := s.Load(entries[i].ID)
+		if err != nil {
+			log.Warnf("Skipping run %s: %v", entries[i].ID, err)
+			continue
+		}
+		results, err := parser.Parse(events)
+		if err != nil {
+			log.Warnf("Skipping unparsable run %s: %v", entry.ID, err)
+			continue
+		}
+		fn(entry, results)
+		runs++
+	}
+	return runs, nil
+}
+
+// CoverProfile returns the path of the stored cover profile of a run, or "" if it has none.
+func (s *Store) CoverProfile(entry *Entry) string {
+	if !entry.Coverage {
+		return ""
+	}
+	return s.path(entry.ID, coverSuffix)
+}
+
+// readEntry reads the metadata of a run.
+func (s *Store) readEntry(id string) (*Entry, error) {
+	data, err := os.ReadFile(s.path(id, metaSuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read run %s: %w", id, err)
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("could not decode run %s: %w", id, err)
+	}
+	return &entry, nil
+}
+
+// prune removes the runs exceeding the retention limits.
+func (s *Store) prune() error {
+	entries, err := s.List()
+	if err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		tooMany := s.retention.MaxRuns > 0 && i >= s.retention.MaxRuns
+		tooOld := s.retention.MaxAge > 0 && time.Since(entry.StartedAt) > s.retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		log.Debugf("Pruning run %s from history.", entry.ID)
+		for _, suffix := range []string{metaSuffix, eventsSuffix, coverSuffix} {
+			if err := os.Remove(s.path(entry.ID, suffix)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Warnf("Could not remove %s: %v", s.path(entry.ID, suffix), err)
+			}
+		}
+	}
+	return nil
+}
+
+// path returns the path of one of the files of a run.
+func (s *Store) path(id, suffix string) string {
+	return filepath.Join(s.dir, id+suffix)
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,44 @@
+package history
+
+import (
+	"time"
+
+	"gdd/parser"
+
+	"github.com/charmbracelet/log"
+)
+
+// LastResult is the most recent known outcome of a test.
+type LastResult struct {
+	Status   parser.TestStatus
+	Duration time.Duration
+	RunAt    time.Time // When the run that produced the result started
+	Output   []string  // Output of the test in that run
+}
+
+// LastResults returns the most recent outcome of every test found in the stored runs,
+// keyed by TestKey(package import path, test name).
+func (s *Store) LastResults() (map[string]*LastResult, error) {
+	last := make(map[string]*LastResult)
+	runs, err := s.eachRun(func(entry *Entry, results []*parser.PackageResult) {
+		RecordResults(last, results, entry.StartedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("LastResults: found results of %d tests over %d runs.", len(last), runs)
+	return last, nil
+}
+
+// RecordResults updates last with the outcomes of a run started at runAt. Tests that
+// did not finish (e.g. in a package that failed to build) keep their previous result.
+func RecordResults(last map[string]*LastResult, results []*parser.PackageResult, runAt time.Time) {
+	for _, pkg := range results {
+		for _, t := range pkg.Tests {
+			switch t.Status {
+			case parser.StatusPass, parser.StatusFail, parser.StatusSkip, parser.StatusTimeout:
+				last[TestKey(pkg.PackageName, t.Name)] = &LastResult{Status: t.Status, Duration: t.Duration, RunAt: runAt, Output: t.Output}
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package impact
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gdd/coverage"
+	"gdd/finder"
+	"gdd/runner"
+
+	"github.com/charmbracelet/log"
+)
+
+// Progress is called before each test is run while updating the index.
+type Progress func(done, total int, test string)
+
+// UpdateResult summarizes an index update.
+type UpdateResult struct {
+	Ran     int // Tests that were (re)run with coverage
+	Reused  int // Tests whose coverage was still up to date
+	Removed int // Tests that no longer exist
+	Failed  int // Tests that could not be indexed (no cover profile)
+}
+
+// Update brings the index up to date with the discovered tests. Each test whose data is
+// missing or stale is run on its own with coverage of the whole module. A test's data is
+// stale when its test file changed, or when any source file it covered changed.
+func (idx *Index) Update(tests []finder.TestInfo, progress Progress) (UpdateResult, error) {
+	var result UpdateResult
+
+	absRoot, err := filepath.Abs(idx.rootDir)
+	if err != nil {
+		return result, fmt.Errorf("could not get absolute path for %s: %w", idx.rootDir, err)
+	}
+	resolver, err := coverage.NewResolver(idx.rootDir)
+	if err != nil {
+		return result, err
+	}
+
+	// Files that changed since they were indexed invalidate every test that covered them.
+	stale := make(map[string]bool)
+	for _, file := range idx.ChangedFiles() {
+		for _, b := range idx.Files[file].Blocks {
+			for _, key := range b.Tests {
+				stale[key] = true
+			}
+		}
+	}
+
+	discovered := make(map[string]bool)
+	var toRun []finder.TestInfo
+	for _, t := range tests {
+		key := TestKey(t.PackageDir, t.Name)
+		discovered[key] = true
+
+		entry, ok := idx.Tests[key]
+		if ok && !stale[key] && idx.hashFile(entry.File) == entry.FileHash {
+			result.Reused++
+			continue
+		}
+		toRun = append(toRun, t)
+	}
+
+	for key := range idx.Tests {
+		if !discovered[key] {
+			idx.removeTest(key)
+			result.Removed++
+		}
+	}
+
+	log.Infof("Updating impact index: %d tests to run, %d up to date, %d removed.", len(toRun), result.Reused, result.Removed)
+
+	for i, t := range toRun {
+		if progress != nil {
+			progress(i, len(toRun), t.Name)
+		}
+		key := TestKey(t.PackageDir, t.Name)
+		idx.removeTest(key)
+
+		relFile, err := filepath.Rel(absRoot, t.FilePath)
+		if err != nil {
+			relFile = t.FilePath
+		}
+		relFile = filepath.ToSlash(relFile)
+
+		profiles, err := runWithCoverage(idx.rootDir, t)
+		if err != nil {
+			log.Warnf("Could not index test %s (%s): %v", t.Name, t.PackageDir, err)
+			result.Failed++
+			continue
+		}
+
+		entry := &TestEntry{
+			Name:       t.Name,
+			PackageDir: t.PackageDir,
+			File:       relFile,
+			FileHash:   idx.hashFile(relFile),
+			IndexedAt:  time.Now(),
+		}
+		for _, p := range profiles {
+			rel, ok := resolver.Rel(p.FileName)
+			if !ok {
+				continue
+			}
+			if idx.addCoverage(rel, key, p) {
+				entry.Files = append(entry.Files, rel)
+			}
+		}
+		idx.Tests[key] = entry
+		result.Ran++
+	}
+
+	return result, nil
+}
+
+// addCoverage records that the test executed the covered blocks of a profiled file.
+// It reports whether the test covered anything in the file.
+func (idx *Index) addCoverage(relFile, testKey string, profile *coverage.Profile) bool {
+	fc, existing := idx.Files[relFile]
+	if !existing {
+		fc = &FileCoverage{}
+	}
+
+	type position struct{ sl, sc, el, ec int }
+	blocks := make(map[position]*Block, len(fc.Blocks))
+	for _, b := range fc.Blocks {
+		blocks[position{b.StartLine, b.StartCol, b.EndLine, b.EndCol}] = b
+	}
+
+	covered := false
+	for _, pb := range profile.Blocks {
+		if pb.Count == 0 {
+			continue
+		}
+		covered = true
+		pos := position{pb.StartLine, pb.StartCol, pb.EndLine, pb.EndCol}
+		b, ok := blocks[pos]
+		if !ok {
+			b = &Block{StartLine: pb.StartLine, StartCol: pb.StartCol, EndLine: pb.EndLine, EndCol: pb.EndCol}
+			blocks[pos] = b
+			fc.Blocks = append(fc.Blocks, b)
+		}
+		b.Tests = append(b.Tests, testKey)
+	}
+
+	if !covered {
+		return false
+	}
+	// The file is hashed as the test saw it, even if other tests still hold its blocks: they
+	// are re-run by the same update when the file changed, as every test that covered it is.
+	fc.Hash = idx.hashFile(relFile)
+	if !existing {
+		idx.Files[relFile] = fc
+	}
+	return true
+}
+
+// runWithCoverage runs a single test with coverage of the whole module and returns its profiles.
+func runWithCoverage(rootDir string, t finder.TestInfo) ([]*coverage.Profile, error) {
+	_, complete := runner.RunSync(runner.TestRunConfig{
+		Type:        runner.SingleTest,
+		PackagePath: "./" + t.PackageDir,
+		TestName:    t.Name,
+		WorkingDir:  rootDir,
+		Coverage:    true,
+		CoverPkg:    "./...",
+		CoverMode:   "set",
+	})
+	if complete.CoverProfile == "" {
+		return nil, fmt.Errorf("no cover profile produced: %v", complete.Err)
+	}
+	defer os.Remove(complete.CoverProfile)
+
+	// A failing test still produces useful coverage, so complete.Err is not fatal here.
+	profiles, err := coverage.ParseProfiles(complete.CoverProfile)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("empty cover profile: %v", complete.Err)
+	}
+	return profiles, nil
+}
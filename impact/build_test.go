@@ -0,0 +1,81 @@
+package impact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gdd/finder"
+)
+
+// writeFile writes a file of the test module, creating its directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateRehashesFileCoveredByTwoTests(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/m\n\ngo 1.24\n")
+	source := filepath.Join(root, "calc", "calc.go")
+	writeFile(t, source, "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	testFile := filepath.Join(root, "calc", "calc_test.go")
+	writeFile(t, testFile, `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("1+2 != 3")
+	}
+}
+
+func TestAddZero(t *testing.T) {
+	if Add(0, 0) != 0 {
+		t.Fatal("0+0 != 0")
+	}
+}
+`)
+	tests := []finder.TestInfo{
+		{Name: "TestAdd", PackageName: "calc", PackageDir: "calc", FilePath: testFile},
+		{Name: "TestAddZero", PackageName: "calc", PackageDir: "calc", FilePath: testFile},
+	}
+
+	idx := NewIndex(root)
+	result, err := idx.Update(tests, nil)
+	if err != nil {
+		t.Fatalf("first Update: %v", err)
+	}
+	if result.Ran != 2 || result.Failed != 0 {
+		t.Fatalf("first Update = %+v, want both tests run", result)
+	}
+
+	// Both tests cover the edited file, so both are stale once.
+	writeFile(t, source, "package calc\n\n// Add returns a+b.\nfunc Add(a, b int) int {\n\treturn a + b\n}\n")
+	if changed := idx.ChangedFiles(); len(changed) != 1 || changed[0] != "calc/calc.go" {
+		t.Fatalf("ChangedFiles() after edit = %v, want [calc/calc.go]", changed)
+	}
+	result, err = idx.Update(tests, nil)
+	if err != nil {
+		t.Fatalf("second Update: %v", err)
+	}
+	if result.Ran != 2 {
+		t.Fatalf("second Update = %+v, want both tests re-run", result)
+	}
+	if changed := idx.ChangedFiles(); len(changed) != 0 {
+		t.Fatalf("ChangedFiles() after re-running its tests = %v, want none", changed)
+	}
+
+	result, err = idx.Update(tests, nil)
+	if err != nil {
+		t.Fatalf("third Update: %v", err)
+	}
+	if result.Reused != 2 || result.Ran != 0 {
+		t.Fatalf("third Update = %+v, want both tests reused", result)
+	}
+}
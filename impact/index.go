@@ -0,0 +1,308 @@
+package impact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// indexVersion is bumped whenever the on-disk format changes; older indexes are discarded.
+const indexVersion = 1
+
+// DefaultIndexPath is where the index is stored, relative to the module root.
+const DefaultIndexPath = ".gdd/impact.json"
+
+// ErrNoIndex is returned by Load when no index has been built yet.
+var ErrNoIndex = errors.New("no test impact index found")
+
+// Block is a covered source range together with the tests that executed it.
+type Block struct {
+	StartLine int      `json:"startLine"`
+	StartCol  int      `json:"startCol"`
+	EndLine   int      `json:"endLine"`
+	EndCol    int      `json:"endCol"`
+	Tests     []string `json:"tests"` // Test keys (see TestKey)
+}
+
+// FileCoverage holds the blocks of one source file and the hash of the file when it was indexed.
+// Once the file changes on disk, its line numbers no longer match and its blocks are stale.
+type FileCoverage struct {
+	Hash   string   `json:"hash"`
+	Blocks []*Block `json:"blocks"`
+}
+
+// TestEntry is an indexed test.
+type TestEntry struct {
+	Name       string    `json:"name"`
+	PackageDir string    `json:"packageDir"` // Relative to the module root, as in finder.TestInfo
+	File       string    `json:"file"`       // Test file, relative to the module root
+	FileHash   string    `json:"fileHash"`   // Hash of the test file when the test was indexed
+	IndexedAt  time.Time `json:"indexedAt"`
+	Files      []string  `json:"files"` // Source files the test covered
+}
+
+// Key returns the key identifying the test in the index.
+func (t *TestEntry) Key() string {
+	return TestKey(t.PackageDir, t.Name)
+}
+
+// TestKey builds the key identifying a test in the index.
+func TestKey(packageDir, name string) string {
+	return packageDir + ":" + name
+}
+
+// Index maps source blocks of the module to the tests that exercise them.
+// File paths are slash-separated and relative to the module root.
+type Index struct {
+	Version int                      `json:"version"`
+	Files   map[string]*FileCoverage `json:"files"`
+	Tests   map[string]*TestEntry    `json:"tests"`
+
+	rootDir string
+}
+
+// NewIndex creates an empty index for the module rooted at rootDir.
+func NewIndex(rootDir string) *Index {
+	return &Index{
+		Version: indexVersion,
+		Files:   make(map[string]*FileCoverage),
+		Tests:   make(map[string]*TestEntry),
+		rootDir: rootDir,
+	}
+}
+
+// Load reads the index of the module rooted at rootDir. It returns ErrNoIndex
+// if none exists, or if it was written by an incompatible version.
+func Load(rootDir string) (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(rootDir, DefaultIndexPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoIndex
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read impact index: %w", err)
+	}
+
+	idx := NewIndex(rootDir)
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("could not decode impact index: %w", err)
+	}
+	if idx.Version != indexVersion {
+		log.Warnf("Discarding impact index with version %d (expected %d).", idx.Version, indexVersion)
+		return nil, ErrNoIndex
+	}
+	return idx, nil
+}
+
+// Save writes the index to DefaultIndexPath under the module root.
+func (idx *Index) Save() error {
+	path := filepath.Join(idx.rootDir, DefaultIndexPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create index directory: %w", err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("could not encode impact index: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("could not write impact index: %w", err)
+	}
+	log.Infof("Saved impact index with %d tests and %d files to %s.", len(idx.Tests), len(idx.Files), path)
+	return nil
+}
+
+// hashFile returns the hex SHA-256 of a file under the module root, or "" if it cannot be read.
+func (idx *Index) hashFile(relPath string) string {
+	data, err := os.ReadFile(filepath.Join(idx.rootDir, filepath.FromSlash(relPath)))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChangedFiles returns the indexed source files whose content differs from when they were indexed.
+func (idx *Index) ChangedFiles() []string {
+	var changed []string
+	for file, fc := range idx.Files {
+		if idx.hashFile(file) != fc.Hash {
+			changed = append(changed, file)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// removeTest drops a test and all of its block references from the index.
+func (idx *Index) removeTest(key string) {
+	entry, ok := idx.Tests[key]
+	if !ok {
+		return
+	}
+	for _, file := range entry.Files {
+		fc, ok := idx.Files[file]
+		if !ok {
+			continue
+		}
+		kept := fc.Blocks[:0]
+		for _, b := range fc.Blocks {
+			b.Tests = slices.DeleteFunc(b.Tests, func(t string) bool { return t == key })
+			if len(b.Tests) > 0 {
+				kept = append(kept, b)
+			}
+		}
+		fc.Blocks = kept
+		if len(fc.Blocks) == 0 {
+			delete(idx.Files, file)
+		}
+	}
+	delete(idx.Tests, key)
+}
+
+// TestsForFile returns the tests that executed any code in the given source file,
+// or the tests defined in it if it is a test file.
+func (idx *Index) TestsForFile(relPath string) []*TestEntry {
+	relPath = filepath.ToSlash(relPath)
+	keys := make(map[string]bool)
+
+	if strings.HasSuffix(relPath, "_test.go") {
+		for key, entry := range idx.Tests {
+			if entry.File == relPath {
+				keys[key] = true
+			}
+		}
+	}
+	if fc, ok := idx.Files[relPath]; ok {
+		for _, b := range fc.Blocks {
+			for _, t := range b.Tests {
+				keys[t] = true
+			}
+		}
+	}
+	return idx.entries(keys)
+}
+
+// TestsForLines returns the tests that executed code between the given lines (inclusive) of a source file.
+func (idx *Index) TestsForLines(relPath string, startLine, endLine int) []*TestEntry {
+	keys := make(map[string]bool)
+	if fc, ok := idx.Files[filepath.ToSlash(relPath)]; ok {
+		for _, b := range fc.Blocks {
+			if b.EndLine < startLine || b.StartLine > endLine {
+				continue
+			}
+			for _, t := range b.Tests {
+				keys[t] = true
+			}
+		}
+	}
+	return idx.entries(keys)
+}
+
+// FuncTests pairs a function with the tests that execute it.
+type FuncTests struct {
+	Name      string
+	StartLine int
+	Tests     []*TestEntry
+}
+
+// TestsByFunc returns, for every function of a source file, the tests that execute it.
+func (idx *Index) TestsByFunc(relPath string) ([]FuncTests, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(idx.rootDir, filepath.FromSlash(relPath)), nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", relPath, err)
+	}
+
+	var funcs []FuncTests
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		start, end := fset.Position(fn.Pos()).Line, fset.Position(fn.End()).Line
+		funcs = append(funcs, FuncTests{
+			Name:      fn.Name.Name,
+			StartLine: start,
+			Tests:     idx.TestsForLines(relPath, start, end),
+		})
+	}
+	return funcs, nil
+}
+
+// TestsForFunc returns the tests that execute the named function (or method) of a source file.
+func (idx *Index) TestsForFunc(relPath, funcName string) ([]*TestEntry, error) {
+	funcs, err := idx.TestsByFunc(relPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range funcs {
+		if f.Name == funcName {
+			return f.Tests, nil
+		}
+	}
+	return nil, fmt.Errorf("function %s not found in %s", funcName, relPath)
+}
+
+// TestsForChanges returns the tests that should run after the given files changed:
+// the tests covering them, plus the tests defined in changed test files.
+// Files that the index knows nothing about (e.g. new files) are returned as unknown.
+func (idx *Index) TestsForChanges(files []string) (tests []*TestEntry, unknown []string) {
+	keys := make(map[string]bool)
+	for _, file := range files {
+		matched := idx.TestsForFile(file)
+		if len(matched) == 0 {
+			if _, indexed := idx.Files[filepath.ToSlash(file)]; !indexed {
+				unknown = append(unknown, file)
+			}
+		}
+		for _, entry := range matched {
+			keys[entry.Key()] = true
+		}
+	}
+	return idx.entries(keys), unknown
+}
+
+// ImpactedTests returns the tests affected by everything that changed since the index was built:
+// tests covering changed source files and tests whose own test file changed.
+func (idx *Index) ImpactedTests() []*TestEntry {
+	tests, _ := idx.TestsForChanges(idx.ChangedFiles())
+	keys := make(map[string]bool)
+	for _, t := range tests {
+		keys[t.Key()] = true
+	}
+	for key, entry := range idx.Tests {
+		if idx.hashFile(entry.File) != entry.FileHash {
+			keys[key] = true
+		}
+	}
+	return idx.entries(keys)
+}
+
+// entries resolves test keys to entries, sorted by package and name.
+func (idx *Index) entries(keys map[string]bool) []*TestEntry {
+	var entries []*TestEntry
+	for key := range keys {
+		if entry, ok := idx.Tests[key]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].PackageDir != entries[j].PackageDir {
+			return entries[i].PackageDir < entries[j].PackageDir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
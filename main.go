@@ -1,9 +1,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
+	"gdd/changes"
+	"gdd/config"
+
 	"gdd/tui" // This will hold our TUI logic
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,6 +15,11 @@ import (
 )
 
 func main() {
+	changed := flag.Bool("changed", false, "run the tests affected by the current git changes without starting the TUI (exits non-zero on failure)")
+	baseRef := flag.String("base", changes.DefaultBaseRef, "git ref that changes are computed against (e.g. origin/main)")
+	configFlags := config.RegisterFlags(flag.CommandLine) // Override the config files, see package config
+	flag.Parse()
+
 	// Configure logging for the entire application.
 	// TUI applications often benefit from logging to a file to avoid corrupting the display.
 	// charmbracelet/log is TUI-aware and can handle this gracefully.
@@ -50,13 +59,25 @@ func main() {
 
 	log.Debugf("Logging initialized. Level: %s, File: %s", logLevel.String(), logFilePath)
 
+	// Problems with the configuration don't stop gdd: they are reported and the defaults used instead.
+	cfg, cfgErrs := config.Load(".", configFlags)
+
+	if *changed {
+		for _, err := range cfgErrs {
+			fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		}
+		code := runChangedHeadless(*baseRef, cfg)
+		f.Close() // os.Exit skips deferred calls
+		os.Exit(code)
+	}
+
 	// Initialize the main TUI model.
 	// The NewMainModel function should also initialize its own internal logger
 	// or use the global one configured here.
-	// For this project, tui.NewMainModel() will set up its own logger,
+	// For this project, tui.NewMainModel(tui.Options{BaseRef: *baseRef}) will set up its own logger,
 	// but it's also fine for other packages like finder, parser, runner
 	// to directly use the global `charmbracelet/log`.
-	initialModel, err := tui.NewMainModel()
+	initialModel, err := tui.NewMainModel(tui.Options{BaseRef: *baseRef, Config: &cfg, ConfigErrors: cfgErrs})
 	if err != nil {
 		// Log the error using our configured logger before exiting
 		log.Fatalf("Could not initialize TUI model: %v", err)
@@ -0,0 +1,251 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// AssertionKind identifies the library or idiom that produced an assertion failure.
+type AssertionKind string
+
+const (
+	AssertTestify AssertionKind = "testify"  // assert.Equal and friends: "Error: Not equal: expected: ... actual: ..."
+	AssertCmp     AssertionKind = "go-cmp"   // A cmp.Diff report: "mismatch (-want +got):" followed by the diff
+	AssertGotWant AssertionKind = "got/want" // reflect.DeepEqual-style messages: "Foo() = x, want y", "got x, want y"
+)
+
+// Assertion is an equality assertion failure found in the output of a test, with the
+// values that were compared.
+type Assertion struct {
+	Kind     AssertionKind
+	Location string // File and line of the failed assertion, e.g. "foo_test.go:42", if known
+	Message  string // What was compared or the message passed to the assertion, e.g. "Not equal"
+	Expected string // Expected value as printed, lines separated by "\n"
+	Actual   string // Actual value as printed, lines separated by "\n"
+
+	// Diff is the diff printed by the assertion library, if any, with every line prefixed
+	// by "-" (expected), "+" (actual), " " (both) or "@" (a hunk header). The sides of
+	// reports printed as "(-got +want)" are swapped so that "-" is always the expected value.
+	Diff []string
+
+	Start int // Index of the first output line of the failure
+	End   int // Index after the last output line of the failure
+}
+
+var (
+	// e.g. "    foo_test.go:42: message", indented by 4 spaces more for every subtest level
+	logEntryRe = regexp.MustCompile(`^( *)([\w.\-]+\.go:\d+): ?(.*)$`)
+
+	// e.g. "Foo() mismatch (-want +got):"
+	cmpHeaderRe = regexp.MustCompile(`^(.*?)\s*\(([-+])(\w+) ([-+])(\w+)\):?\s*$`)
+
+	// Single-line got/want messages, in the forms of the Go code review comments and their common variants.
+	gotWantRes = []struct {
+		re               *regexp.Regexp
+		expected, actual int // Submatch holding each value
+	}{
+		{regexp.MustCompile(`^(.*?)\s=\s(.+?),?\s+want:?\s+(.+)$`), 3, 2},                                        // Foo(x) = 1, want 2
+		{regexp.MustCompile(`(?i)^(.*?)\bgot:?\s+(.+?),?\s+want(?:ed)?:?\s+(.+)$`), 3, 2},                        // got 1, want 2
+		{regexp.MustCompile(`(?i)^(.*?)\bwant(?:ed)?:?\s+(.+?),?\s+got:?\s+(.+)$`), 2, 3},                        // want 2, got 1
+		{regexp.MustCompile(`(?i)^(.*?)\bexpected:?\s+(.+?),?\s+(?:but\s+)?(?:got|was|actual):?\s+(.+)$`), 2, 3}, // expected 2, but got 1
+	}
+
+	// Two-line got/want messages: "got: 1" followed by "want: 2", in either order.
+	gotWantLineRe = regexp.MustCompile(`(?i)^\s*(got|actual|want|expected)\s*:\s*(.*)$`)
+)
+
+// expectedLabels are the names go-cmp reports and got/want lines use for the expected value.
+var expectedLabels = map[string]bool{"want": true, "wanted": true, "expected": true, "exp": true, "expect": true}
+
+// ParseAssertions finds the equality assertion failures in the output of a test.
+// Every failure is a log entry of the test: a "file.go:line:" line and the lines
+// indented below it.
+func ParseAssertions(lines []string) []*Assertion {
+	var assertions []*Assertion
+	for i := 0; i < len(lines); i++ {
+		m := logEntryRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+
+		// Continuation lines of a log entry are indented by 4 more spaces than its first line.
+		indent := m[1] + "    "
+		var body []string
+		for _, line := range lines[i+1:] {
+			if !strings.HasPrefix(line, indent) && strings.TrimSpace(line) != "" {
+				break
+			}
+			body = append(body, strings.TrimPrefix(line, indent))
+		}
+		// Trailing blank lines most likely belong to whatever comes next.
+		for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+			body = body[:len(body)-1]
+		}
+
+		a := parseTestify(body)
+		if a == nil {
+			a = parseCmp(m[3], body)
+		}
+		if a == nil {
+			a = parseGotWant(m[3], body)
+		}
+		if a != nil {
+			a.Location = m[2]
+			a.Start = i
+			a.End = i + 1 + len(body)
+			assertions = append(assertions, a)
+		}
+		i += len(body)
+	}
+	return assertions
+}
+
+// parseTestify parses the body of a testify failure, made of "\tLabel:\tvalue" fields
+// continued by "\t<spaces>\tvalue" lines.
+func parseTestify(body []string) *Assertion {
+	var labels []string
+	fields := make(map[string][]string)
+	for _, line := range body {
+		if !strings.HasPrefix(line, "\t") {
+			return nil
+		}
+		label, value, ok := strings.Cut(line[1:], "\t")
+		if !ok {
+			return nil
+		}
+		label = strings.TrimSuffix(strings.TrimSpace(label), ":")
+		if label == "" {
+			if len(labels) == 0 {
+				return nil
+			}
+			label = labels[len(labels)-1]
+		} else {
+			labels = append(labels, label)
+		}
+		fields[label] = append(fields[label], value)
+	}
+
+	errLines := fields["Error"]
+	if len(errLines) == 0 || !strings.HasPrefix(strings.TrimSpace(errLines[0]), "Not equal") {
+		return nil
+	}
+
+	a := &Assertion{Kind: AssertTestify, Message: "Not equal"}
+	if msgs := fields["Messages"]; len(msgs) > 0 {
+		a.Message += ": " + strings.Join(msgs, " ")
+	}
+	inDiff := false
+	for _, line := range errLines[1:] {
+		switch {
+		case inDiff:
+			switch {
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+				// File headers: "--- Expected", "+++ Actual"
+			case strings.HasPrefix(line, "@@"):
+				a.Diff = append(a.Diff, "@"+line)
+			case line == "":
+				a.Diff = append(a.Diff, " ")
+			default:
+				a.Diff = append(a.Diff, line)
+			}
+		case strings.HasPrefix(line, "expected:"):
+			a.Expected = strings.TrimSpace(strings.TrimPrefix(line, "expected:"))
+		case strings.HasPrefix(line, "actual  :"), strings.HasPrefix(line, "actual:"):
+			a.Actual = strings.TrimSpace(line[strings.Index(line, ":")+1:])
+		case strings.TrimSpace(line) == "Diff:":
+			inDiff = true
+		}
+	}
+	return a
+}
+
+// parseCmp parses a go-cmp report: a header naming the sides, e.g. "(-want +got):",
+// followed by the diff, one line per line of the body.
+func parseCmp(message string, body []string) *Assertion {
+	header, diff := message, body
+	if !cmpHeaderRe.MatchString(header) {
+		// The header is often on its own line, below a message.
+		for i, line := range body {
+			if cmpHeaderRe.MatchString(line) {
+				header, diff = line, body[i+1:]
+				break
+			}
+		}
+	}
+	m := cmpHeaderRe.FindStringSubmatch(header)
+	if m == nil || len(diff) == 0 || m[2] == m[4] {
+		return nil
+	}
+	minusLabel := m[3]
+	if m[2] == "+" {
+		minusLabel = m[5]
+	}
+	swap := !expectedLabels[strings.ToLower(minusLabel)]
+
+	a := &Assertion{Kind: AssertCmp, Message: strings.TrimSpace(m[1])}
+	if a.Message == "" {
+		a.Message = strings.TrimSpace(message)
+	}
+	var expected, actual []string
+	for _, line := range diff {
+		// go-cmp randomly uses non-breaking spaces to discourage parsing its output.
+		line = strings.ReplaceAll(line, "\u00a0", " ")
+		if line == "" {
+			line = " "
+		}
+		marker, text := line[0], strings.TrimPrefix(line[1:], " ")
+		switch {
+		case marker == '-' && !swap, marker == '+' && swap:
+			a.Diff = append(a.Diff, "-"+text)
+			expected = append(expected, text)
+		case marker == '+' && !swap, marker == '-' && swap:
+			a.Diff = append(a.Diff, "+"+text)
+			actual = append(actual, text)
+		default:
+			a.Diff = append(a.Diff, " "+text)
+			expected = append(expected, text)
+			actual = append(actual, text)
+		}
+	}
+	a.Expected = strings.Join(expected, "\n")
+	a.Actual = strings.Join(actual, "\n")
+	return a
+}
+
+// parseGotWant recognizes got/want messages, on the first line of a log entry or any line of its body.
+func parseGotWant(message string, body []string) *Assertion {
+	lines := append([]string{message}, body...)
+	for _, line := range lines {
+		for _, gw := range gotWantRes {
+			if m := gw.re.FindStringSubmatch(line); m != nil {
+				return &Assertion{
+					Kind:     AssertGotWant,
+					Message:  strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[1]), ":")),
+					Expected: strings.TrimSpace(m[gw.expected]),
+					Actual:   strings.TrimSpace(m[gw.actual]),
+				}
+			}
+		}
+	}
+
+	// "got: x" and "want: y" on consecutive lines, after the message if any.
+	for i := 0; i+1 < len(lines); i++ {
+		first, second := gotWantLineRe.FindStringSubmatch(lines[i]), gotWantLineRe.FindStringSubmatch(lines[i+1])
+		if first == nil || second == nil {
+			continue
+		}
+		firstExpected := expectedLabels[strings.ToLower(first[1])]
+		if firstExpected == expectedLabels[strings.ToLower(second[1])] {
+			continue // Both sides have the same role, e.g. "got:" twice
+		}
+		a := &Assertion{Kind: AssertGotWant, Expected: second[2], Actual: first[2]}
+		if firstExpected {
+			a.Expected, a.Actual = first[2], second[2]
+		}
+		if i > 0 {
+			a.Message = strings.TrimSuffix(strings.TrimSpace(lines[0]), ":")
+		}
+		return a
+	}
+	return nil
+}
@@ -5,8 +5,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,6 +34,7 @@ const (
 	StatusPass    TestStatus = "PASS"
 	StatusFail    TestStatus = "FAIL"
 	StatusSkip    TestStatus = "SKIP"
+	StatusTimeout TestStatus = "TIMEOUT" // Test was still running when the test binary hit its -timeout
 	StatusUnknown TestStatus = "UNKNOWN" // Default status before a final event
 )
 
@@ -54,7 +57,10 @@ type TestResult struct {
 	Name        string
 	Status      TestStatus
 	Output      []string
+	Events      []TestEvent // Every event reported for the test, in order, with the exact output and its timestamps
 	Duration    time.Duration
+	Races       []*RaceReport // Data races detected while this test was running (requires -race)
+	Assertions  []*Assertion  // Equality assertion failures found in Output, with the compared values
 }
 
 // PackageResult holds all test results for a single package.
@@ -64,6 +70,9 @@ type PackageResult struct {
 	SummaryOutput []string
 	Tests         []*TestResult
 	Duration      time.Duration
+	Races         []*RaceReport // Data races reported outside of any test (e.g. in TestMain or init)
+	Timeout       *TimeoutInfo  // Set if the test binary panicked with "test timed out"
+	Coverage      *float64      // Statement coverage percentage from the "coverage:" line, nil if not reported
 }
 
 // Parse processes the raw byte output from `go test -json` and returns a slice of PackageResult.
@@ -135,6 +144,7 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 					Name:        event.Test,
 					Status:      StatusRunning,
 					Output:      []string{},
+					Events:      []TestEvent{event},
 				}
 				log.Debugf("Test run: %s/%s", event.Package, event.Test)
 			} else { // Package started
@@ -147,6 +157,7 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 			if event.Test != "" {                               // Output belongs to a specific test
 				if tr, ok := currentTestResults[testKey]; ok {
 					tr.Output = append(tr.Output, outputLine)
+					tr.Events = append(tr.Events, event)
 				} else {
 					// Output for a test that hasn't had a "run" event or has already finished.
 					// This can happen with t.Log after t.Fatal, or complex TestMain scenarios.
@@ -155,6 +166,7 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 					for _, t := range pkgResult.Tests {
 						if t.Name == event.Test {
 							t.Output = append(t.Output, outputLine)
+							t.Events = append(t.Events, event)
 							foundAndAppended = true
 							break
 						}
@@ -187,7 +199,7 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 					var newPkgSummary []string
 					for _, line := range pkgResult.SummaryOutput {
 						if strings.HasPrefix(line, fmt.Sprintf("[%s]", event.Test)) {
-							tr.Output = append(tr.Output, strings.TrimSpace(strings.TrimPrefix(line, fmt.Sprintf("[%s]", event.Test))))
+							tr.Output = append(tr.Output, strings.TrimPrefix(line, fmt.Sprintf("[%s] ", event.Test)))
 						} else {
 							newPkgSummary = append(newPkgSummary, line)
 						}
@@ -196,6 +208,7 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 				}
 				tr.Status = status
 				tr.Duration = duration
+				tr.Events = append(tr.Events, event)
 
 				pkgResult.Tests = append(pkgResult.Tests, tr)
 				delete(currentTestResults, testKey) // Test is complete
@@ -205,12 +218,24 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 				pkgResult.Duration = duration
 				log.Debugf("Package %s: %s (%.2fs)", status, event.Package, event.Elapsed)
 
+				// A timeout panic leaves the running tests without a final event; detect it so they
+				// can be reported as timed out instead of as generic failures.
+				if timeout := findTimeout(pkgResult, currentTestResults); timeout != nil {
+					log.Warnf("Package %s timed out after %s.", event.Package, timeout.After)
+					pkgResult.Timeout = timeout
+					pkgResult.Status = StatusTimeout
+				}
+
 				// Consolidate remaining currentTestResults for this package if any (shouldn't happen often if go test is well-behaved)
 				for key, unfinishedTest := range currentTestResults {
 					if unfinishedTest.PackageName == event.Package {
-						log.Warnf("Test %s/%s was 'run' but did not complete before package %s finished. Marking as FAIL.", unfinishedTest.PackageName, unfinishedTest.Name, event.Package)
-						unfinishedTest.Status = StatusFail
-						unfinishedTest.Output = append(unfinishedTest.Output, "Test did not report completion before package finished.")
+						if pkgResult.Timeout != nil {
+							markTimedOut(unfinishedTest, pkgResult.Timeout)
+						} else {
+							log.Warnf("Test %s/%s was 'run' but did not complete before package %s finished. Marking as FAIL.", unfinishedTest.PackageName, unfinishedTest.Name, event.Package)
+							unfinishedTest.Status = StatusFail
+							unfinishedTest.Output = append(unfinishedTest.Output, "Test did not report completion before package finished.")
+						}
 						pkgResult.Tests = append(pkgResult.Tests, unfinishedTest)
 						delete(currentTestResults, key)
 						// If package passed but contains failed test, mark package as failed
@@ -257,7 +282,12 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 					}
 				}
 			}
-		// Ignoring "cont", "pause", "bench" actions for this tool's scope.
+		case "pause", "cont":
+			// Only kept for the output viewer: they explain gaps in the timestamps of parallel tests.
+			if tr, ok := currentTestResults[testKey]; ok {
+				tr.Events = append(tr.Events, event)
+			}
+		// Ignoring "bench" actions for this tool's scope.
 		default:
 			log.Debugf("Unhandled event action: %s for package %s, test %s", event.Action, event.Package, event.Test)
 		}
@@ -271,14 +301,25 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 	// This can happen if the `go test` process crashes or is killed.
 	for _, tr := range currentTestResults {
 		if tr.Status == StatusRunning {
-			log.Warnf("Test %s in package %s was 'run' but never completed. Marking as FAIL.", tr.Name, tr.PackageName)
-			tr.Status = StatusFail
-			tr.Output = append(tr.Output, "Test did not complete (process might have crashed, timed out, or was terminated).")
+			pkgResult, ok := packageResults[tr.PackageName]
+			if ok && pkgResult.Timeout == nil {
+				pkgResult.Timeout = findTimeout(pkgResult, currentTestResults)
+			}
+
+			if ok && pkgResult.Timeout != nil {
+				markTimedOut(tr, pkgResult.Timeout)
+			} else {
+				log.Warnf("Test %s in package %s was 'run' but never completed. Marking as FAIL.", tr.Name, tr.PackageName)
+				tr.Status = StatusFail
+				tr.Output = append(tr.Output, "Test did not complete (process might have crashed, timed out, or was terminated).")
+			}
 
-			if pkgResult, ok := packageResults[tr.PackageName]; ok {
+			if ok {
 				pkgResult.Tests = append(pkgResult.Tests, tr)
 				// Ensure package status reflects failure if it wasn't already failed.
-				if pkgResult.Status != StatusFail {
+				if pkgResult.Timeout != nil {
+					pkgResult.Status = StatusTimeout
+				} else if pkgResult.Status != StatusFail {
 					pkgResult.Status = StatusFail // Mark package as failed too
 					log.Debugf("Marking package %s as FAIL due to incomplete test %s", tr.PackageName, tr.Name)
 				}
@@ -293,6 +334,16 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 	finalResults := make([]*PackageResult, 0, len(orderedPackageNames))
 	for _, pkgName := range orderedPackageNames {
 		if pkg, ok := packageResults[pkgName]; ok {
+			// Extract structured data race reports from the captured output.
+			for _, tr := range pkg.Tests {
+				tr.Races = ParseRaceReports(tr.Output)
+				if tr.Status == StatusFail || tr.Status == StatusTimeout {
+					tr.Assertions = ParseAssertions(tr.Output)
+				}
+			}
+			pkg.Races = ParseRaceReports(pkg.SummaryOutput)
+			pkg.Coverage = parseCoverage(pkg.SummaryOutput)
+
 			// Sort tests within each package alphabetically by name for consistent display
 			sort.Slice(pkg.Tests, func(i, j int) bool {
 				return pkg.Tests[i].Name < pkg.Tests[j].Name
@@ -303,3 +354,55 @@ func Parse(jsonData []byte) ([]*PackageResult, error) {
 
 	return finalResults, nil
 }
+
+// IsFraming reports whether a test output line is one of the lines `go test -v` prints
+// around every test, which carry no information about a failure.
+func IsFraming(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// findTimeout searches the package output and the output of the package's unfinished tests
+// for a test timeout panic. The panic is attributed by test2json to whichever test was
+// last reported as running, so it can end up in any of them.
+func findTimeout(pkg *PackageResult, running map[string]*TestResult) *TimeoutInfo {
+	if timeout := ParseTimeout(pkg.SummaryOutput); timeout != nil {
+		return timeout
+	}
+	for _, tr := range running {
+		if tr.PackageName != pkg.PackageName {
+			continue
+		}
+		if timeout := ParseTimeout(tr.Output); timeout != nil {
+			return timeout
+		}
+	}
+	return nil
+}
+
+// markTimedOut finalizes a test that was still running when its package timed out.
+func markTimedOut(tr *TestResult, timeout *TimeoutInfo) {
+	log.Warnf("Test %s/%s was still running when the test binary timed out after %s.", tr.PackageName, tr.Name, timeout.After)
+	tr.Status = StatusTimeout
+	tr.Output = append(tr.Output, fmt.Sprintf("Test was still running when the test binary timed out after %s.", timeout.After))
+}
+
+// e.g. "coverage: 75.0% of statements" or "ok  \tpkg\t0.1s\tcoverage: 75.0% of statements in ./..."
+var coverageLineRe = regexp.MustCompile(`coverage: (\d+(?:\.\d+)?)% of statements`)
+
+// parseCoverage returns the statement coverage reported in a package's output, or nil if there is none.
+func parseCoverage(lines []string) *float64 {
+	for _, line := range lines {
+		if m := coverageLineRe.FindStringSubmatch(line); m != nil {
+			if pct, err := strconv.ParseFloat(m[1], 64); err == nil {
+				return &pct
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// StackFrame is a single frame of a goroutine stack as printed by the Go runtime
+// or the race detector (a function line followed by a "file:line +0xoff" line).
+type StackFrame struct {
+	Function string // e.g. "mypkg.(*Server).handle()"
+	File     string // e.g. "/home/user/project/mypkg/server.go"
+	Line     int
+}
+
+// String renders the frame as "function (file:line)".
+func (f StackFrame) String() string {
+	if f.File == "" {
+		return f.Function
+	}
+	return f.Function + " (" + f.File + ":" + strconv.Itoa(f.Line) + ")"
+}
+
+// RaceAccess is one of the conflicting memory accesses reported in a data race.
+type RaceAccess struct {
+	Kind      string // e.g. "Read", "Write", "Previous write", "Atomic read"
+	Address   string // e.g. "0x00c0000182a8"
+	Goroutine string // Goroutine id ("8") or "main"
+	Stack     []StackFrame
+}
+
+// RaceGoroutine describes where a goroutine involved in a race was created.
+type RaceGoroutine struct {
+	ID        string // Goroutine id, e.g. "8"
+	State     string // e.g. "running", "finished"
+	CreatedAt []StackFrame
+}
+
+// RaceReport is a structured `WARNING: DATA RACE` block emitted by the race detector.
+type RaceReport struct {
+	Accesses   []RaceAccess    // Usually two: the current access and the previous conflicting one
+	Goroutines []RaceGoroutine // Creation sites of the goroutines involved
+	Raw        []string        // Original lines of the block, for fallback rendering
+}
+
+// Key returns a stable identity for the race, used to deduplicate identical races
+// reported by several tests. Addresses and goroutine ids are ignored because they
+// differ from run to run; only the access kinds and their top stack frames matter.
+func (r *RaceReport) Key() string {
+	parts := make([]string, 0, len(r.Accesses))
+	for _, a := range r.Accesses {
+		kind := strings.TrimPrefix(strings.ToLower(a.Kind), "previous ")
+		top := ""
+		if len(a.Stack) > 0 {
+			top = a.Stack[0].String()
+		}
+		parts = append(parts, kind+"@"+top)
+	}
+	return strings.Join(parts, "|")
+}
+
+// RaceGroup is a deduplicated race together with every test it was observed in.
+type RaceGroup struct {
+	Report *RaceReport
+	Tests  []string // "package/TestName" (or just the package for unattributed races)
+	Count  int      // Total number of occurrences across all tests
+}
+
+const (
+	raceSeparator = "=================="
+	raceWarning   = "WARNING: DATA RACE"
+)
+
+var (
+	// e.g. "Previous write at 0x00c0000182a8 by goroutine 7:" or "Read at 0x... by main goroutine:"
+	raceAccessRe = regexp.MustCompile(`^(.+?) at (0x[0-9a-fA-F]+) by (?:goroutine (\d+)|(main) goroutine):$`)
+	// e.g. "Goroutine 8 (running) created at:"
+	raceCreatedRe = regexp.MustCompile(`^Goroutine (\d+) \(([^)]+)\) created at:$`)
+	// e.g. "/tmp/pkg/file.go:12 +0x33" or "_testmain.go:46 +0x164"
+	frameLocationRe = regexp.MustCompile(`^(.+\.go):(\d+)(?: \+0x[0-9a-fA-F]+)?$`)
+)
+
+// ParseRaceReports extracts all data race reports from a slice of output lines.
+// Lines outside of `WARNING: DATA RACE` blocks are ignored.
+func ParseRaceReports(lines []string) []*RaceReport {
+	var reports []*RaceReport
+
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != raceWarning {
+			continue
+		}
+
+		// Collect the block until the closing separator (or end of output).
+		end := i + 1
+		for end < len(lines) && strings.TrimSpace(lines[end]) != raceSeparator {
+			end++
+		}
+		block := lines[i:end]
+
+		report := parseRaceBlock(block[1:])
+		report.Raw = append([]string{raceSeparator}, block...)
+		report.Raw = append(report.Raw, raceSeparator)
+		reports = append(reports, report)
+
+		i = end
+	}
+
+	return reports
+}
+
+// parseRaceBlock parses the body of a race report (the lines after `WARNING: DATA RACE`).
+func parseRaceBlock(lines []string) *RaceReport {
+	report := &RaceReport{}
+
+	// stack points to the frame slice currently being filled (an access or a creation site).
+	var stack *[]StackFrame
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+
+		if m := raceAccessRe.FindStringSubmatch(line); m != nil {
+			goroutine := m[3]
+			if m[4] != "" {
+				goroutine = m[4]
+			}
+			report.Accesses = append(report.Accesses, RaceAccess{Kind: m[1], Address: m[2], Goroutine: goroutine})
+			stack = &report.Accesses[len(report.Accesses)-1].Stack
+			continue
+		}
+
+		if m := raceCreatedRe.FindStringSubmatch(line); m != nil {
+			report.Goroutines = append(report.Goroutines, RaceGoroutine{ID: m[1], State: m[2]})
+			stack = &report.Goroutines[len(report.Goroutines)-1].CreatedAt
+			continue
+		}
+
+		if stack == nil {
+			continue // Unknown header line, nothing to attach frames to.
+		}
+
+		frame, consumed := parseStackFrame(lines, i)
+		*stack = append(*stack, frame)
+		i += consumed - 1
+	}
+
+	return report
+}
+
+// parseStackFrame parses a frame starting at lines[i]. A frame is a function line,
+// optionally followed by a location line. It returns the frame and the number of lines consumed.
+func parseStackFrame(lines []string, i int) (StackFrame, int) {
+	frame := StackFrame{Function: strings.TrimSpace(lines[i])}
+	if i+1 >= len(lines) {
+		return frame, 1
+	}
+
+	m := frameLocationRe.FindStringSubmatch(strings.TrimSpace(lines[i+1]))
+	if m == nil {
+		return frame, 1
+	}
+	frame.File = m[1]
+	frame.Line, _ = strconv.Atoi(m[2])
+	return frame, 2
+}
+
+// GroupRaces collects the race reports of all packages and tests and deduplicates
+// identical races (see RaceReport.Key), keeping the order in which they were first seen.
+func GroupRaces(results []*PackageResult) []*RaceGroup {
+	var groups []*RaceGroup
+	byKey := make(map[string]*RaceGroup)
+
+	add := func(report *RaceReport, where string) {
+		group, ok := byKey[report.Key()]
+		if !ok {
+			group = &RaceGroup{Report: report}
+			byKey[report.Key()] = group
+			groups = append(groups, group)
+		}
+		group.Count++
+		for _, t := range group.Tests {
+			if t == where {
+				return
+			}
+		}
+		group.Tests = append(group.Tests, where)
+	}
+
+	for _, pkg := range results {
+		for _, test := range pkg.Tests {
+			for _, report := range test.Races {
+				add(report, pkg.PackageName+"/"+test.Name)
+			}
+		}
+		for _, report := range pkg.Races {
+			add(report, pkg.PackageName)
+		}
+	}
+
+	return groups
+}
@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Goroutine is a single goroutine from a Go runtime stack dump,
+// such as the one printed when a test binary hits its `-timeout`.
+type Goroutine struct {
+	ID        int
+	State     string // e.g. "chan receive", "sync.Mutex.Lock, 9 minutes"
+	Stack     []StackFrame
+	CreatedBy *StackFrame // The `created by` frame, if present
+}
+
+// RunningTest is an entry of the "running tests:" list printed on timeout.
+type RunningTest struct {
+	Name    string // Test name, possibly including subtests (e.g. "TestFoo/bar")
+	Elapsed string // As printed by the testing package, e.g. "10m0s"
+}
+
+// TimeoutInfo describes a `panic: test timed out after ...` emitted by a test binary.
+type TimeoutInfo struct {
+	After        string // Configured timeout, e.g. "10m0s"
+	RunningTests []RunningTest
+	Goroutines   []Goroutine
+}
+
+var (
+	timeoutPanicRe    = regexp.MustCompile(`^panic: test timed out after (\S+)`)
+	runningTestRe     = regexp.MustCompile(`^(\S+) \(([^)]+)\)$`)
+	goroutineHeaderRe = regexp.MustCompile(`^goroutine (\d+) \[([^\]]+)\]:$`)
+	createdByRe       = regexp.MustCompile(`^created by (\S+)(?: in goroutine \d+)?$`)
+)
+
+// ParseTimeout looks for a test timeout panic in the given output lines and, if found,
+// returns the list of running tests and the goroutine dump that follows it.
+// It returns nil if the output does not contain a timeout panic.
+func ParseTimeout(lines []string) *TimeoutInfo {
+	start := -1
+	var info *TimeoutInfo
+	for i, line := range lines {
+		if m := timeoutPanicRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			info = &TimeoutInfo{After: m[1]}
+			start = i + 1
+			break
+		}
+	}
+	if info == nil {
+		return nil
+	}
+
+	i := start
+	// "running tests:" followed by one indented "TestName (elapsed)" line per test.
+	if i < len(lines) && strings.TrimSpace(lines[i]) == "running tests:" {
+		for i++; i < len(lines); i++ {
+			m := runningTestRe.FindStringSubmatch(strings.TrimSpace(lines[i]))
+			if m == nil {
+				break
+			}
+			info.RunningTests = append(info.RunningTests, RunningTest{Name: m[1], Elapsed: m[2]})
+		}
+	}
+
+	info.Goroutines = parseGoroutineDump(lines[i:])
+	return info
+}
+
+// parseGoroutineDump parses consecutive "goroutine N [state]:" blocks.
+func parseGoroutineDump(lines []string) []Goroutine {
+	var goroutines []Goroutine
+	var current *Goroutine
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			current = nil
+			continue
+		}
+
+		if m := goroutineHeaderRe.FindStringSubmatch(line); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			goroutines = append(goroutines, Goroutine{ID: id, State: m[2]})
+			current = &goroutines[len(goroutines)-1]
+			continue
+		}
+		if current == nil {
+			continue // Not inside a goroutine block (e.g. trailing FAIL lines).
+		}
+
+		if m := createdByRe.FindStringSubmatch(line); m != nil {
+			frame, consumed := parseStackFrame(lines, i)
+			frame.Function = m[1]
+			current.CreatedBy = &frame
+			i += consumed - 1
+			continue
+		}
+
+		frame, consumed := parseStackFrame(lines, i)
+		current.Stack = append(current.Stack, frame)
+		i += consumed - 1
+	}
+
+	return goroutines
+}
+
+// GoroutinesFor returns the goroutines whose stack (or creation site) belongs to the given test.
+// Subtests are matched through their top-level test function, since that is what appears in stacks.
+func (t *TimeoutInfo) GoroutinesFor(testName string) []Goroutine {
+	fn := testName
+	if idx := strings.Index(fn, "/"); idx >= 0 {
+		fn = fn[:idx]
+	}
+
+	belongs := func(frame StackFrame) bool {
+		return strings.Contains(frame.Function, "."+fn+"(") ||
+			strings.Contains(frame.Function, "."+fn+".") ||
+			strings.HasSuffix(frame.Function, "."+fn)
+	}
+
+	var matched []Goroutine
+	for _, g := range t.Goroutines {
+		related := g.CreatedBy != nil && belongs(*g.CreatedBy)
+		for _, frame := range g.Stack {
+			if related {
+				break
+			}
+			related = belongs(frame)
+		}
+		if related {
+			matched = append(matched, g)
+		}
+	}
+	return matched
+}
+
+// IsRunning reports whether the test (or one of its subtests) was listed as running when the timeout fired.
+func (t *TimeoutInfo) IsRunning(testName string) bool {
+	for _, rt := range t.RunningTests {
+		if rt.Name == testName || strings.HasPrefix(rt.Name, testName+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStdlibFrame reports whether a frame belongs to the runtime or the testing machinery,
+// which is rarely where a hang originates. Used to highlight user frames in stack dumps.
+func IsStdlibFrame(frame StackFrame) bool {
+	for _, prefix := range []string{"runtime.", "testing.", "sync.", "internal/", "time.", "main.main"} {
+		if strings.HasPrefix(frame.Function, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(frame.File, "_testmain.go")
+}
@@ -0,0 +1,205 @@
+// Package quarantine manages the list of known-flaky tests whose failures are reported
+// separately and do not fail a run.
+//
+// The list is a plain text file at the module root, meant to be committed, with one
+// test per line: the package import path, the test name and an optional comment.
+//
+//	# Known-flaky tests
+//	example.com/project/server TestReconnect  # races with the listener, see #42
+//
+// Quarantining a test also quarantines its subtests, and a parent test that only failed
+// because of quarantined subtests.
+package quarantine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gdd/parser"
+
+	"github.com/charmbracelet/log"
+)
+
+// DefaultFile is the name of the quarantine file, relative to the module root.
+const DefaultFile = ".gdd-quarantine"
+
+// header is written at the top of a quarantine file created by gdd.
+const header = `# Tests quarantined by gdd: their failures are reported separately and do not fail a run.
+# One test per line: <package import path> <test name>  # optional reason
+`
+
+// Entry is a quarantined test.
+type Entry struct {
+	Package string
+	Test    string
+	Reason  string // Comment following the test on its line, if any
+}
+
+// List is the content of a quarantine file. It keeps the lines it does not
+// understand (comments, blank lines) so that saving preserves manual edits.
+type List struct {
+	path  string
+	lines []string
+}
+
+// Load reads the quarantine file of the module rooted at rootDir. A missing file is an empty list.
+func Load(rootDir string) (*List, error) {
+	l := &List{path: filepath.Join(rootDir, DefaultFile)}
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read quarantine file: %w", err)
+	}
+	l.lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for i, line := range l.lines {
+		content, _, _ := strings.Cut(line, "#")
+		if _, ok := parseLine(line); !ok && strings.TrimSpace(content) != "" {
+			log.Warnf("Quarantine: ignoring malformed line %d of %s: %q", i+1, l.path, line)
+		}
+	}
+	log.Debugf("Quarantine: loaded %d tests from %s.", len(l.Entries()), l.path)
+	return l, nil
+}
+
+// Path returns the path of the quarantine file.
+func (l *List) Path() string {
+	return l.path
+}
+
+// Entries returns the quarantined tests, in file order.
+func (l *List) Entries() []Entry {
+	var entries []Entry
+	for _, line := range l.lines {
+		if e, ok := parseLine(line); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Contains reports whether the test (or the test it is a subtest of) is quarantined.
+func (l *List) Contains(pkg, test string) bool {
+	if l == nil {
+		return false
+	}
+	for _, line := range l.lines {
+		e, ok := parseLine(line)
+		if ok && e.Package == pkg && (e.Test == test || strings.HasPrefix(test, e.Test+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a copy of the list that can be changed and saved without affecting l.
+func (l *List) Clone() *List {
+	return &List{path: l.path, lines: slices.Clone(l.lines)}
+}
+
+// Toggle quarantines the test, or releases it if it is quarantined, and returns whether
+// it is now quarantined. Call Save to persist the change.
+func (l *List) Toggle(pkg, test, reason string) bool {
+	for i, line := range l.lines {
+		if e, ok := parseLine(line); ok && e.Package == pkg && e.Test == test {
+			l.lines = append(l.lines[:i], l.lines[i+1:]...)
+			return false
+		}
+	}
+	if len(l.lines) == 0 {
+		l.lines = strings.Split(strings.TrimRight(header, "\n"), "\n")
+	}
+	line := pkg + " " + test
+	if reason != "" {
+		line += "  # " + reason
+	}
+	l.lines = append(l.lines, line)
+	return true
+}
+
+// EnsureFile creates the quarantine file with an explanatory header if it does not exist yet,
+// so that it can be opened in an editor.
+func (l *List) EnsureFile() error {
+	if _, err := os.Stat(l.path); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.WriteFile(l.path, []byte(header), 0o644); err != nil {
+		return fmt.Errorf("could not create quarantine file: %w", err)
+	}
+	return nil
+}
+
+// Save writes the list back to the quarantine file.
+func (l *List) Save() error {
+	data := strings.Join(l.lines, "\n") + "\n"
+	if err := os.WriteFile(l.path, []byte(data), 0o644); err != nil {
+		return fmt.Errorf("could not write quarantine file: %w", err)
+	}
+	log.Infof("Quarantine: saved %d tests to %s.", len(l.Entries()), l.path)
+	return nil
+}
+
+// Failed reports whether a package failed for a reason other than its quarantined tests:
+// a failing test that is not quarantined, a timeout, or a failure outside of any test
+// (e.g. a build error or a panic in TestMain).
+func (l *List) Failed(pkg *parser.PackageResult) bool {
+	if pkg.Status == parser.StatusTimeout {
+		return true
+	}
+	failedTests := 0
+	for _, t := range pkg.Tests {
+		if t.Status != parser.StatusFail && t.Status != parser.StatusTimeout {
+			continue
+		}
+		failedTests++
+		if !l.FailureQuarantined(pkg, t.Name) {
+			return true
+		}
+	}
+	return pkg.Status == parser.StatusFail && failedTests == 0
+}
+
+// FailureQuarantined reports whether the failure of a test of pkg is that of a quarantined
+// test: the test is quarantined, or it is a parent that failed because its subtests did and
+// every subtest failing on its own (without failed subtests of its own) is quarantined.
+// A parent without failed subtests failed by itself.
+func (l *List) FailureQuarantined(pkg *parser.PackageResult, test string) bool {
+	if l.Contains(pkg.PackageName, test) {
+		return true
+	}
+	failed := func(t *parser.TestResult) bool {
+		return t.Status == parser.StatusFail || t.Status == parser.StatusTimeout
+	}
+	hasFailedSubtests := func(name string) bool {
+		for _, t := range pkg.Tests {
+			if failed(t) && strings.HasPrefix(t.Name, name+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasFailedSubtests(test) {
+		return false
+	}
+	for _, t := range pkg.Tests {
+		if failed(t) && strings.HasPrefix(t.Name, test+"/") && !hasFailedSubtests(t.Name) && !l.Contains(pkg.PackageName, t.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLine parses a line of the quarantine file. Blank lines and comments are not entries.
+func parseLine(line string) (Entry, bool) {
+	content, reason, _ := strings.Cut(line, "#")
+	fields := strings.Fields(content)
+	if len(fields) != 2 {
+		return Entry{}, false
+	}
+	return Entry{Package: fields[0], Test: fields[1], Reason: strings.TrimSpace(reason)}, true
+}
@@ -0,0 +1,265 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// JobStatus is the state of one `go test` process of a run split by package.
+type JobStatus int
+
+const (
+	// JobQueued waits for a worker.
+	JobQueued JobStatus = iota
+	// JobRunning is being tested.
+	JobRunning
+	// JobPassed finished with exit code 0.
+	JobPassed
+	// JobFailed finished with failed tests, a build error or a process error.
+	JobFailed
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobPassed:
+		return "passed"
+	case JobFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// JobStatusMsg reports that a job of a run split by package (see TestRunConfig.Jobs) changed
+// state. Every job is reported queued before the first one starts.
+type JobStatusMsg struct {
+	Job     int // Index of the job, in the order of the packages
+	Jobs    int // Number of jobs of the run
+	Package string
+	Status  JobStatus
+	Err     error         // Why the job failed, if it did
+	Elapsed time.Duration // How long the job ran, once it finished
+}
+
+// jobPackages returns the packages a run is split into, one `go test` process each, or nil
+// if it isn't split: it tests a single package or runs in a single process.
+func jobPackages(config TestRunConfig) []string {
+	if config.Jobs < 2 {
+		return nil
+	}
+	var packages []string
+	switch config.Type {
+	case ChangedTests:
+		packages = config.Packages
+	case AllTests:
+		var err error
+		if packages, err = testPackages(config); err != nil {
+			log.Warnf("Could not list the packages to test, testing them in one process: %v", err)
+			return nil
+		}
+	}
+	if len(packages) < 2 {
+		return nil
+	}
+	return packages
+}
+
+// testPackages lists the packages of the module that have test files, with the build tags
+// and environment of the run, as `go test ./...` would test them.
+func testPackages(config TestRunConfig) ([]string, error) {
+	args := []string{"list", "-f", "{{if or .TestGoFiles .XTestGoFiles}}{{.ImportPath}}{{end}}"}
+	if len(config.Tags) > 0 {
+		args = append(args, "-tags="+strings.Join(config.Tags, ","))
+	}
+	args = append(args, "./...")
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = config.WorkingDir
+	if cmd.Dir == "" {
+		cmd.Dir = "."
+	}
+	if len(config.Env) > 0 {
+		cmd.Env = append(os.Environ(), config.Env...)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("go list: %w", err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// runJobs tests each package with its own `go test` process, running up to config.Jobs of
+// them at once. The output of every job is passed to send as it comes, so the events of the
+// packages interleave line by line; a job that fails doesn't stop the others. It finishes
+// with a single TestRunCompleteMsg, whose error joins those of the failed jobs and whose cover
+// profile merges theirs.
+func runJobs(config TestRunConfig, packages []string, send func(tea.Msg)) {
+	workers := min(config.Jobs, len(packages))
+	log.Infof("Testing %d packages with %d parallel `go test` processes", len(packages), workers)
+
+	for i, pkg := range packages {
+		send(JobStatusMsg{Job: i, Jobs: len(packages), Package: pkg, Status: JobQueued})
+	}
+
+	profiles := make([]string, len(packages))
+	timings := make([]*BinaryTiming, len(packages))
+	errs := make([]error, len(packages))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				profiles[i], timings[i], errs[i] = runJob(config, i, packages, send)
+			}
+		}()
+	}
+	for i := range packages {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	complete := TestRunCompleteMsg{Err: errors.Join(errs...)}
+	if config.Coverage {
+		merged, err := mergeCoverProfiles(profiles)
+		if err != nil {
+			log.Errorf("Error merging the cover profiles of the jobs: %v", err)
+			complete.Err = errors.Join(complete.Err, fmt.Errorf("merge cover profiles: %w", err))
+		}
+		complete.CoverProfile = merged
+	}
+	for _, timing := range timings {
+		if timing == nil {
+			continue
+		}
+		if complete.Binaries == nil {
+			complete.Binaries = &BinaryTiming{}
+		}
+		complete.Binaries.add(timing)
+	}
+	send(complete)
+}
+
+// runJob tests the package of job i and returns its cover profile, if any, the timing of its
+// test binary if it was precompiled, and its error.
+// A job that fails without any output, e.g. because `go` can't be started, gets a failed
+// package event of its own, so that the merged results show it.
+func runJob(config TestRunConfig, i int, packages []string, send func(tea.Msg)) (string, *BinaryTiming, error) {
+	pkg := packages[i]
+	job := config
+	job.Type = PackageTests
+	job.PackagePath = pkg
+	job.Packages = nil
+	job.Jobs = 0
+
+	send(JobStatusMsg{Job: i, Jobs: len(packages), Package: pkg, Status: JobRunning})
+	started := time.Now()
+
+	var complete TestRunCompleteMsg
+	output := false // Only written by Run's own goroutine, like complete
+	Run(job, func(msg tea.Msg) {
+		switch msg := msg.(type) {
+		case TestRunCompleteMsg:
+			complete = msg
+		case TestOutputLineMsg:
+			output = true
+			send(msg)
+		default:
+			send(msg)
+		}
+	})
+
+	status := JobPassed
+	var err error
+	if complete.Err != nil {
+		status = JobFailed
+		err = fmt.Errorf("%s: %w", pkg, complete.Err)
+		if !output {
+			sendFailedPackage(pkg, err, send)
+		}
+	}
+	elapsed := time.Since(started)
+	log.Debugf("Job %d/%d (%s) %s in %s", i+1, len(packages), pkg, status, elapsed)
+	send(JobStatusMsg{Job: i, Jobs: len(packages), Package: pkg, Status: status, Err: err, Elapsed: elapsed})
+	return complete.CoverProfile, complete.Binaries, err
+}
+
+// sendFailedPackage sends the `go test -json` events of a package that failed with err.
+func sendFailedPackage(pkg string, err error, send func(tea.Msg)) {
+	sendPackageEvents(pkg, "fail", err.Error(), send)
+}
+
+// sendPackageEvents sends the output and the final action of a package that had no test run,
+// as `go test -json` does for packages that fail to build or have no tests.
+func sendPackageEvents(pkg, action, output string, send func(tea.Msg)) {
+	type event struct {
+		Action  string
+		Package string
+		Output  string `json:",omitempty"`
+	}
+	for _, e := range []event{
+		{Action: "output", Package: pkg, Output: output + "\n"},
+		{Action: action, Package: pkg},
+	} {
+		line, _ := json.Marshal(e)
+		send(TestOutputLineMsg{Line: string(line)})
+	}
+}
+
+// mergeCoverProfiles writes the cover profiles of the jobs, which are removed, into a single
+// temporary one. Blocks reported by several jobs, as with -coverpkg, are left for the readers
+// of the profile to merge, like `go test` does for the packages of a single process.
+func mergeCoverProfiles(paths []string) (string, error) {
+	merged, err := os.CreateTemp("", "gdd-cover-*.out")
+	if err != nil {
+		return "", err
+	}
+	defer merged.Close()
+
+	w := bufio.NewWriter(merged)
+	mode := false
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Warnf("Skipping the cover profile %s: %v", path, err)
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line == "" || strings.HasPrefix(line, "mode: ") && mode {
+				continue
+			}
+			mode = mode || strings.HasPrefix(line, "mode: ")
+			w.WriteString(line + "\n")
+		}
+		if err := os.Remove(path); err != nil {
+			log.Warnf("Could not remove the cover profile %s: %v", path, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return merged.Name(), nil
+}
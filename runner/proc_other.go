@@ -0,0 +1,13 @@
+//go:build !unix
+
+package runner
+
+import "os/exec"
+
+// startInGroup does nothing where process groups aren't available.
+func startInGroup(cmd *exec.Cmd) {}
+
+// killGroup kills cmd. The test binaries it started may outlive it.
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -2,10 +2,15 @@ package runner
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
@@ -23,6 +28,12 @@ type TestOutputLineMsg struct {
 // The rawCombinedOutput can be useful for debugging if JSON parsing fails or if there's non-JSON output.
 type TestRunCompleteMsg struct {
 	Err error
+	// CoverProfile is the path of the cover profile written by the run, if coverage was enabled.
+	// The file is owned by the caller, who is responsible for removing it.
+	CoverProfile string
+	// Binaries splits the duration of a precompiled run between building its test binaries and
+	// running them. Nil if the run wasn't precompiled.
+	Binaries *BinaryTiming
 	// RawCombinedOutput string // Could be useful for debugging, but can be very large.
 }
 
@@ -36,6 +47,12 @@ const (
 	PackageTests
 	// AllTests runs all tests in the project (`./...`).
 	AllTests
+	// ChangedTests runs the tests of the packages listed in Packages, usually
+	// the packages affected by the current git changes.
+	ChangedTests
+	// SelectedTests runs the test functions listed in TestNames within their package,
+	// e.g. the tests of one file.
+	SelectedTests
 )
 
 func (ttt TestTargetType) String() string {
@@ -46,11 +63,44 @@ func (ttt TestTargetType) String() string {
 		return "package_test"
 	case AllTests:
 		return "all_tests"
+	case ChangedTests:
+		return "changed_tests"
+	case SelectedTests:
+		return "selected_tests"
 	default:
 		return "unknown"
 	}
 }
 
+// RunPattern returns the -run pattern matching exactly the given test or subtest.
+// `go test` splits the pattern on slashes and matches each level of the name separately.
+func RunPattern(testName string) string {
+	parts := strings.Split(testName, "/")
+	for i, part := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(part) + "$"
+	}
+	return strings.Join(parts, "/")
+}
+
+// EnvNames returns the names of "KEY=value" environment variables, for logs that must not show
+// their values.
+func EnvNames(env []string) []string {
+	names := make([]string, len(env))
+	for i, kv := range env {
+		names[i], _, _ = strings.Cut(kv, "=")
+	}
+	return names
+}
+
+// selectedPattern returns the -run pattern matching exactly the given test functions.
+func selectedPattern(testNames []string) string {
+	names := make([]string, len(testNames))
+	for i, name := range testNames {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	return fmt.Sprintf("^(%s)$", strings.Join(names, "|"))
+}
+
 // TestRunConfig holds the configuration for a test run.
 type TestRunConfig struct {
 	Type TestTargetType
@@ -58,9 +108,55 @@ type TestRunConfig struct {
 	// For AllTests, this is ignored as \"./...\" is used.
 	PackagePath string
 	// TestName is the specific function name, e.g., \"TestMyFunction\" (only used if Type is SingleTest).
+	// It may name a subtest, e.g. \"TestMyFunction/empty_input\".
 	TestName string
+	// TestNames are the test functions to run (only used if Type is SelectedTests).
+	TestNames []string
+	// Packages are the package paths to test (only used if Type is ChangedTests).
+	Packages []string
+	// BaseRef is the git ref the changes were computed against (only used for display if Type is ChangedTests).
+	BaseRef string
 	// WorkingDir is the directory from which `go test` should be executed. Usually the project root.
 	WorkingDir string
+	// Race enables the race detector (`-race`) for the run.
+	Race bool
+	// Coverage enables coverage collection (`-coverprofile`) into a temporary file,
+	// whose path is reported in TestRunCompleteMsg.CoverProfile.
+	Coverage bool
+	// CoverPkg is passed as `-coverpkg` when Coverage is enabled (e.g. "./..."). Empty means the tested packages only.
+	CoverPkg string
+	// CoverMode is passed as `-covermode` when Coverage is enabled ("set", "count" or "atomic").
+	CoverMode string
+	// Count is passed as `-count`; values below 1 mean 1, which also disables test caching.
+	Count int
+	// Shuffle randomizes the execution order of tests and benchmarks (`-shuffle=on`).
+	Shuffle bool
+	// CPU is passed as `-cpu` if set, e.g. "1,2,4" to run every test once per GOMAXPROCS value.
+	CPU string
+	// Flags are extra `go test` flags, e.g. "-short" or "-timeout=5m", passed before the packages.
+	Flags []string
+	// Env are extra environment variables of the run, as "KEY=value" pairs.
+	// They override the variables of the same name in gdd's environment. As they may hold
+	// secrets, only their names are logged (see EnvNames) and they are not stored with runs.
+	Env []string `json:"-"`
+	// Tags are build tags, passed as `-tags`, e.g. "integration".
+	Tags []string
+	// Profile is the name of the profile the flags, environment and tags come from, if any.
+	// It is only used for display.
+	Profile string
+	// Precompiled runs a single package with its test binary, built with `go test -c` into a cache
+	// under WorkingDir and reused by the runs that follow until the code or build settings change.
+	// Runs of several packages use it when split into jobs.
+	Precompiled bool
+	// Stop, if not nil, kills the run's processes when closed, including a test binary that hangs.
+	// The run then completes with the error of the killed command.
+	Stop <-chan struct{} `json:"-"`
+	// Jobs is the number of `go test` processes run at once when the run covers several packages
+	// (AllTests and ChangedTests), each testing one of them. Below 2, one process tests them all.
+	Jobs int
+	// SlowTestThreshold, if non-zero, makes the runner send a SlowTestMsg for every test
+	// that runs longer than this duration. The test is not interrupted.
+	SlowTestThreshold time.Duration
 }
 
 // StreamMsg is an initial message sent by a command that will stream subsequent messages.
@@ -80,135 +176,286 @@ func ExecuteTestsCmd(config TestRunConfig) tea.Cmd {
 
 		go func() {
 			defer close(msgChan) // Ensure channel is closed when goroutine finishes
+			Run(config, func(msg tea.Msg) { msgChan <- msg })
+		}()
 
-			var finalArgs []string
-
-			// Base arguments for `go test`
-			// -json: Output in JSON format.
-			// -v: Verbose output, ensures all test events (including pass) are in the JSON stream.
-			// -count=1: Disable test caching to ensure tests are always re-run.
-			// -short: (Optional) if you want to run tests in short mode.
-			baseArgs := []string{"test", "-json", "-v", "-count=1"}
-
-			switch config.Type {
-			case SingleTest:
-				if config.PackagePath == "" || config.TestName == "" {
-					err := fmt.Errorf("ExecuteTestsCmd: SingleTest requires a valid PackagePath and TestName")
-					log.Error(err.Error())
-					msgChan <- TestRunCompleteMsg{Err: err}
-					return
-				}
-				// Format: go test [baseArgs] <package_path> -run ^TestName$
-				// Ensure TestName is properly escaped for regex if it contains special characters.
-				// For simplicity, assuming TestName is a valid Go function name without regex metachars.
-				finalArgs = append(baseArgs, config.PackagePath, "-run", fmt.Sprintf("^%s$", config.TestName))
-			case PackageTests:
-				if config.PackagePath == "" {
-					err := fmt.Errorf("ExecuteTestsCmd: PackageTests requires a valid PackagePath")
-					log.Error(err.Error())
-					msgChan <- TestRunCompleteMsg{Err: err}
-					return
-				}
-				// Format: go test [baseArgs] <package_path>
-				finalArgs = append(baseArgs, config.PackagePath)
-			case AllTests:
-				// Format: go test [baseArgs] ./...
-				finalArgs = append(baseArgs, "./...")
-			default:
-				err := fmt.Errorf("ExecuteTestsCmd: unknown test target type: %d", config.Type)
-				log.Error(err.Error())
-				msgChan <- TestRunCompleteMsg{Err: err}
-				return
-			}
+		// Send the StreamMsg first, so the main Update loop knows which channel to listen on.
+		return StreamMsg{Stream: msgChan}
+	}
+}
 
-			log.Infof("Executing test command: go %s (in %s)", strings.Join(finalArgs, " "), config.WorkingDir)
+// Run executes `go test -json` for the given config and blocks until it finishes.
+// Every message produced by the run (TestOutputLineMsg, SlowTestMsg, JobStatusMsg and, last,
+// TestRunCompleteMsg) is passed to send. The watchdog and the jobs of a run split by package send
+// from their own goroutines, so send must be safe for concurrent use.
+func Run(config TestRunConfig, send func(tea.Msg)) {
+	if packages := jobPackages(config); packages != nil {
+		runJobs(config, packages, send)
+		return
+	}
+	if config.Precompiled && precompilable(config) {
+		runPrecompiled(config, send)
+		return
+	}
 
-			cmd := exec.Command("go", finalArgs...)
-			if config.WorkingDir != "" {
-				cmd.Dir = config.WorkingDir
-			} else {
-				cmd.Dir = "." // Default to current directory if not specified
-				log.Warn("ExecuteTestsCmd: WorkingDir not specified, defaulting to '.'")
-			}
+	var finalArgs []string
 
-			// Get stdout and stderr pipes
-			stdoutPipe, err := cmd.StdoutPipe()
-			if err != nil {
-				log.Errorf("Error creating stdout pipe: %v", err)
-				msgChan <- TestRunCompleteMsg{Err: fmt.Errorf("stdout pipe: %w", err)}
-				return
-			}
+	// Base arguments for `go test`
+	// -json: Output in JSON format.
+	// -v: Verbose output, ensures all test events (including pass) are in the JSON stream.
+	// -count=1: Disable test caching to ensure tests are always re-run.
+	// -short: (Optional) if you want to run tests in short mode.
+	count := config.Count
+	if count < 1 {
+		count = 1
+	}
+	baseArgs := []string{"test", "-json", "-v", fmt.Sprintf("-count=%d", count)}
+	if config.Race {
+		baseArgs = append(baseArgs, "-race")
+	}
+	if config.Shuffle {
+		baseArgs = append(baseArgs, "-shuffle=on")
+	}
+	if config.CPU != "" {
+		baseArgs = append(baseArgs, "-cpu="+config.CPU)
+	}
+	if len(config.Tags) > 0 {
+		baseArgs = append(baseArgs, "-tags="+strings.Join(config.Tags, ","))
+	}
+	baseArgs = append(baseArgs, config.Flags...)
 
-			stderrPipe, err := cmd.StderrPipe()
-			if err != nil {
-				log.Errorf("Error creating stderr pipe: %v", err)
-				msgChan <- TestRunCompleteMsg{Err: fmt.Errorf("stderr pipe: %w", err)}
-				return
-			}
+	switch config.Type {
+	case SingleTest:
+		if config.PackagePath == "" || config.TestName == "" {
+			err := fmt.Errorf("ExecuteTestsCmd: SingleTest requires a valid PackagePath and TestName")
+			log.Error(err.Error())
+			send(TestRunCompleteMsg{Err: err})
+			return
+		}
+		// Format: go test [baseArgs] <package_path> -run ^TestName$[/^Subtest$...]
+		finalArgs = append(baseArgs, config.PackagePath, "-run", RunPattern(config.TestName))
+	case SelectedTests:
+		if config.PackagePath == "" || len(config.TestNames) == 0 {
+			err := fmt.Errorf("ExecuteTestsCmd: SelectedTests requires a valid PackagePath and TestNames")
+			log.Error(err.Error())
+			send(TestRunCompleteMsg{Err: err})
+			return
+		}
+		// Format: go test [baseArgs] <package_path> -run ^(TestA|TestB)$
+		finalArgs = append(baseArgs, config.PackagePath, "-run", selectedPattern(config.TestNames))
+	case PackageTests:
+		if config.PackagePath == "" {
+			err := fmt.Errorf("ExecuteTestsCmd: PackageTests requires a valid PackagePath")
+			log.Error(err.Error())
+			send(TestRunCompleteMsg{Err: err})
+			return
+		}
+		// Format: go test [baseArgs] <package_path>
+		finalArgs = append(baseArgs, config.PackagePath)
+	case AllTests:
+		// Format: go test [baseArgs] ./...
+		finalArgs = append(baseArgs, "./...")
+	case ChangedTests:
+		if len(config.Packages) == 0 {
+			err := fmt.Errorf("ExecuteTestsCmd: ChangedTests requires at least one package")
+			log.Error(err.Error())
+			send(TestRunCompleteMsg{Err: err})
+			return
+		}
+		// Format: go test [baseArgs] <package_path>...
+		finalArgs = append(baseArgs, config.Packages...)
+	default:
+		err := fmt.Errorf("ExecuteTestsCmd: unknown test target type: %d", config.Type)
+		log.Error(err.Error())
+		send(TestRunCompleteMsg{Err: err})
+		return
+	}
 
-			if err := cmd.Start(); err != nil {
-				log.Errorf("Error starting command 'go %s': %v", strings.Join(finalArgs, " "), err)
-				msgChan <- TestRunCompleteMsg{Err: fmt.Errorf("start command 'go %s': %w", strings.Join(finalArgs, " "), err)}
-				return
-			}
+	// Coverage flags are appended after the package arguments; `go test` accepts test flags there too.
+	var coverProfile string
+	if config.Coverage {
+		profileFile, err := os.CreateTemp("", "gdd-cover-*.out")
+		if err != nil {
+			log.Errorf("Error creating cover profile file: %v", err)
+			send(TestRunCompleteMsg{Err: fmt.Errorf("create cover profile: %w", err)})
+			return
+		}
+		profileFile.Close()
+		coverProfile = profileFile.Name()
 
-			// Goroutine to capture and log stderr without mixing with JSON on stdout
-			// This ensures that build errors or other non-JSON output from go test's stderr
-			// are logged but don't interfere with parsing the JSON from stdout.
-			var stderrOutput strings.Builder
-			stderrDone := make(chan struct{})
-			go func() {
-				defer close(stderrDone)
-				scanner := bufio.NewScanner(stderrPipe)
-				for scanner.Scan() {
-					line := scanner.Text()
-					log.Warnf("[go test stderr] %s", line)
-					stderrOutput.WriteString(line + "\n") // Collect for potential error reporting
-				}
-				if err := scanner.Err(); err != nil {
-					log.Errorf("Error reading stderr: %v", err)
-				}
-			}()
-
-			// Stream stdout (JSON lines)
-			stdoutScanner := bufio.NewScanner(stdoutPipe)
-			for stdoutScanner.Scan() {
-				msgChan <- TestOutputLineMsg{Line: stdoutScanner.Text()}
-			}
+		finalArgs = append(finalArgs, "-coverprofile="+coverProfile)
+		if config.CoverMode != "" {
+			finalArgs = append(finalArgs, "-covermode="+config.CoverMode)
+		}
+		if config.CoverPkg != "" {
+			finalArgs = append(finalArgs, "-coverpkg="+config.CoverPkg)
+		}
+	}
 
-			// Check for errors during stdout scanning (e.g., pipe closed unexpectedly)
-			if err := stdoutScanner.Err(); err != nil {
-				log.Errorf("Error scanning stdout: %v", err)
-				// This error might indicate issues reading output. The cmd.Wait() error below
-				// will likely also reflect a problem.
-			}
+	log.Infof("Executing test command: go %s (in %s)", strings.Join(finalArgs, " "), config.WorkingDir)
+
+	cmd := exec.Command("go", finalArgs...)
+	if config.WorkingDir != "" {
+		cmd.Dir = config.WorkingDir
+	} else {
+		cmd.Dir = "." // Default to current directory if not specified
+		log.Warn("ExecuteTestsCmd: WorkingDir not specified, defaulting to '.'")
+	}
+	if len(config.Env) > 0 {
+		log.Debugf("Extra environment of the test command: %s", strings.Join(EnvNames(config.Env), " "))
+		cmd.Env = append(os.Environ(), config.Env...)
+	}
+
+	waitErr, err := streamCommand(cmd, config, send)
+	if err != nil {
+		send(TestRunCompleteMsg{Err: err})
+		return
+	}
+
+	// cmd.Wait() error is important. It's non-nil if tests fail or if there's a build error.
+	// This is *expected* if tests fail. The JSON output (parsed by the `parser` package)
+	// will detail individual test statuses.
+	// A non-nil waitErr when no JSON was produced, or if stdoutScanner.Err() occurred,
+	// might indicate a more severe problem (e.g., compilation failed completely).
+	if waitErr != nil {
+		log.Infof("`go test` command finished with error: %v (This is expected if tests failed or build issues occurred)", waitErr)
+		// If stderr had content and waitErr is present, it's likely a build error or similar.
+		// The JSON parser will handle empty/malformed JSON.
+		// The waitErr itself is the primary signal of overall success/failure of the `go test` process.
+	} else {
+		log.Info("`go test` command finished successfully (exit code 0).")
+	}
+
+	send(TestRunCompleteMsg{Err: waitErr, CoverProfile: coverProfile})
+}
 
-			// Wait for stderr goroutine to finish processing all stderr output
-			<-stderrDone
-
-			// Wait for the command to complete
-			waitErr := cmd.Wait()
-
-			// cmd.Wait() error is important. It's non-nil if tests fail or if there's a build error.
-			// This is *expected* if tests fail. The JSON output (parsed by the `parser` package)
-			// will detail individual test statuses.
-			// A non-nil waitErr when no JSON was produced, or if stdoutScanner.Err() occurred,
-			// might indicate a more severe problem (e.g., compilation failed completely).
-			if waitErr != nil {
-				log.Infof("`go test` command finished with error: %v (This is expected if tests failed or build issues occurred)", waitErr)
-				// If stderr had content and waitErr is present, it's likely a build error or similar.
-				// The JSON parser will handle empty/malformed JSON.
-				// The waitErr itself is the primary signal of overall success/failure of the `go test` process.
-			} else {
-				log.Info("`go test` command finished successfully (exit code 0).")
+// killOnStop kills cmd, started in its own process group with startInGroup, and the processes it
+// started when stop is closed. The returned function must be called once cmd exited. A nil stop
+// is never closed.
+func killOnStop(cmd *exec.Cmd, stop <-chan struct{}) (exited func()) {
+	if stop == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-stop:
+			log.Infof("Stopping command '%s'", strings.Join(cmd.Args, " "))
+			if err := killGroup(cmd); err != nil {
+				log.Warnf("Could not kill command '%s': %v", strings.Join(cmd.Args, " "), err)
 			}
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// streamCommand runs cmd, a `go test -json` or an equivalent, passing each line of its standard
+// output to send as a TestOutputLineMsg and warning about slow tests as configured. It returns
+// the error of the command's exit, or err if it could not be started. Closing config.Stop kills
+// the command and the processes it started.
+func streamCommand(cmd *exec.Cmd, config TestRunConfig, send func(tea.Msg)) (waitErr, err error) {
+	// Get stdout and stderr pipes
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Errorf("Error creating stdout pipe: %v", err)
+		return nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		log.Errorf("Error creating stderr pipe: %v", err)
+		return nil, fmt.Errorf("stderr pipe: %w", err)
+	}
 
-			msgChan <- TestRunCompleteMsg{Err: waitErr}
+	if config.Stop != nil {
+		startInGroup(cmd)
+	}
+	if err := cmd.Start(); err != nil {
+		log.Errorf("Error starting command '%s': %v", strings.Join(cmd.Args, " "), err)
+		return nil, fmt.Errorf("start command '%s': %w", strings.Join(cmd.Args, " "), err)
+	}
+	defer killOnStop(cmd, config.Stop)()
+
+	// Goroutine to capture and log stderr without mixing with JSON on stdout
+	// This ensures that build errors or other non-JSON output from go test's stderr
+	// are logged but don't interfere with parsing the JSON from stdout.
+	var stderrOutput strings.Builder
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		scanner := bufio.NewScanner(stderrPipe)
+		for scanner.Scan() {
+			line := scanner.Text()
+			log.Warnf("[go test stderr] %s", line)
+			stderrOutput.WriteString(line + "\n") // Collect for potential error reporting
+		}
+		if err := scanner.Err(); err != nil {
+			log.Errorf("Error reading stderr: %v", err)
+		}
+	}()
+
+	// Start the soft watchdog that warns about individual long-running tests.
+	var wd *watchdog
+	stopWatchdog := make(chan struct{})
+	var watchdogDone sync.WaitGroup
+	if config.SlowTestThreshold > 0 {
+		wd = newWatchdog(config.SlowTestThreshold)
+		watchdogDone.Add(1)
+		go func() {
+			defer watchdogDone.Done()
+			wd.run(send, stopWatchdog)
 		}()
+	}
 
-		// Send the StreamMsg first, so the main Update loop knows which channel to listen on.
-		return StreamMsg{Stream: msgChan}
+	// Stream stdout (JSON lines)
+	stdoutScanner := bufio.NewScanner(stdoutPipe)
+	for stdoutScanner.Scan() {
+		line := stdoutScanner.Text()
+		if wd != nil {
+			wd.observe(line)
+		}
+		send(TestOutputLineMsg{Line: line})
 	}
+
+	// The watchdog must stop sending before the channel is closed.
+	close(stopWatchdog)
+	watchdogDone.Wait()
+
+	// Check for errors during stdout scanning (e.g., pipe closed unexpectedly)
+	if err := stdoutScanner.Err(); err != nil {
+		log.Errorf("Error scanning stdout: %v", err)
+		// This error might indicate issues reading output. The cmd.Wait() error below
+		// will likely also reflect a problem.
+	}
+
+	// Wait for stderr goroutine to finish processing all stderr output
+	<-stderrDone
+
+	// Wait for the command to complete
+	return cmd.Wait(), nil
+}
+
+// RunSync executes `go test -json` for the given config and blocks until it finishes.
+// It returns the raw JSON output and the completion message. Slow test warnings are dropped.
+// It is meant for background work that does not stream into the TUI (e.g. building indexes).
+func RunSync(config TestRunConfig) ([]byte, TestRunCompleteMsg) {
+	var output bytes.Buffer
+	var complete TestRunCompleteMsg
+	var mu sync.Mutex
+
+	Run(config, func(msg tea.Msg) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch msg := msg.(type) {
+		case TestOutputLineMsg:
+			output.WriteString(msg.Line + "\n")
+		case TestRunCompleteMsg:
+			complete = msg
+		}
+	})
+
+	return output.Bytes(), complete
 }
 
 // WaitForStreamMsgCmd returns a `tea.Cmd` that waits for the next message on the given stream.
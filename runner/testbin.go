@@ -0,0 +1,419 @@
+package runner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// BinaryCacheDir is the directory of the cached test binaries, relative to the working directory.
+const BinaryCacheDir = ".gdd/testbin"
+
+// defaultTestTimeout is the -timeout `go test` passes to test binaries unless told otherwise.
+const defaultTestTimeout = "10m0s"
+
+// testBinaryFlags are the `go test` flags the test binary takes, as "-test." flags, rather
+// than the build. The other flags of TestRunConfig.Flags are build flags.
+var testBinaryFlags = []string{
+	"bench", "benchmem", "benchtime", "blockprofile", "blockprofilerate", "count", "coverprofile",
+	"cpu", "cpuprofile", "failfast", "fullpath", "fuzz", "fuzzminimizetime", "fuzztime", "list",
+	"memprofile", "memprofilerate", "mutexprofile", "mutexprofilefraction", "outputdir", "parallel",
+	"run", "short", "shuffle", "skip", "timeout", "trace", "v",
+}
+
+// BinaryTiming splits the duration of a precompiled run between building its test binaries and
+// running them. Binaries reused from the cache take no time to build.
+type BinaryTiming struct {
+	Compile time.Duration
+	Run     time.Duration
+	Built   int // Binaries built by the run
+	Cached  int // Binaries reused from the cache
+}
+
+// add adds the timing of another job of the same run.
+func (t *BinaryTiming) add(other *BinaryTiming) {
+	t.Compile += other.Compile
+	t.Run += other.Run
+	t.Built += other.Built
+	t.Cached += other.Cached
+}
+
+// testBinary is a test binary of the cache, with what running it like `go test` needs.
+type testBinary struct {
+	path       string
+	ImportPath string `json:"import_path"` // Package of the test events
+	Dir        string `json:"dir"`         // Directory of the package, where `go test` runs the binary
+}
+
+// precompilable reports whether a run tests a single package, with a binary of its own.
+func precompilable(config TestRunConfig) bool {
+	switch config.Type {
+	case SingleTest, SelectedTests, PackageTests:
+		return config.PackagePath != ""
+	}
+	return false
+}
+
+// runPrecompiled runs the tests of a single package with its cached test binary, building it
+// first if the cache has none for the current code and build settings, and converts its output
+// to `go test -json` events with `go tool test2json`.
+func runPrecompiled(config TestRunConfig, send func(tea.Msg)) {
+	buildFlags, binaryFlags := splitTestFlags(config.Flags)
+	timing := &BinaryTiming{}
+
+	started := time.Now()
+	bin, built, err := cachedTestBinary(config, buildFlags, send)
+	timing.Compile = time.Since(started)
+	if built {
+		timing.Built++
+	} else if bin != nil {
+		timing.Cached++
+		timing.Compile = 0 // Looking the binary up is part of running it
+	}
+	if err != nil || bin == nil {
+		send(TestRunCompleteMsg{Err: err, Binaries: timing})
+		return
+	}
+
+	count := max(config.Count, 1)
+	args := []string{"tool", "test2json", "-t", "-p", bin.ImportPath, bin.path,
+		"-test.v=test2json", "-test.paniconexit0", fmt.Sprintf("-test.count=%d", count)}
+	if !slices.ContainsFunc(binaryFlags, func(flag string) bool { return flagName(flag) == "timeout" }) {
+		args = append(args, "-test.timeout="+defaultTestTimeout)
+	}
+	if config.Shuffle {
+		args = append(args, "-test.shuffle=on")
+	}
+	if config.CPU != "" {
+		args = append(args, "-test.cpu="+config.CPU)
+	}
+	for _, flag := range binaryFlags {
+		args = append(args, "-test."+strings.TrimLeft(flag, "-"))
+	}
+	switch config.Type {
+	case SingleTest:
+		args = append(args, "-test.run="+RunPattern(config.TestName))
+	case SelectedTests:
+		args = append(args, "-test.run="+selectedPattern(config.TestNames))
+	}
+
+	var coverProfile string
+	if config.Coverage {
+		profileFile, err := os.CreateTemp("", "gdd-cover-*.out")
+		if err != nil {
+			log.Errorf("Error creating cover profile file: %v", err)
+			send(TestRunCompleteMsg{Err: fmt.Errorf("create cover profile: %w", err), Binaries: timing})
+			return
+		}
+		profileFile.Close()
+		coverProfile = profileFile.Name()
+		args = append(args, "-test.coverprofile="+coverProfile)
+	}
+
+	log.Infof("Executing test binary: go %s (in %s)", strings.Join(args, " "), bin.Dir)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = bin.Dir
+	if len(config.Env) > 0 {
+		cmd.Env = append(os.Environ(), config.Env...)
+	}
+
+	started = time.Now()
+	waitErr, err := streamCommand(cmd, config, send)
+	timing.Run = time.Since(started)
+	if err != nil {
+		send(TestRunCompleteMsg{Err: err, Binaries: timing})
+		return
+	}
+	if waitErr != nil {
+		log.Infof("Test binary of %s finished with error: %v", bin.ImportPath, waitErr)
+	}
+	send(TestRunCompleteMsg{Err: waitErr, CoverProfile: coverProfile, Binaries: timing})
+}
+
+// cachedTestBinary returns the test binary of the run's package from the cache, building it if
+// needed, and whether it was built. Build errors are sent as the output of the failed package.
+// It returns nil and no error for a package without tests, reported as `go test` does.
+func cachedTestBinary(config TestRunConfig, buildFlags []string, send func(tea.Msg)) (*testBinary, bool, error) {
+	workingDir, err := filepath.Abs(config.WorkingDir)
+	if err != nil {
+		return nil, false, err
+	}
+	inputs, err := listBuildInputs(workingDir, config, buildFlags)
+	if err != nil {
+		return nil, false, err
+	}
+	key, err := binaryKey(workingDir, config, buildFlags, inputs)
+	if err != nil {
+		return nil, false, fmt.Errorf("test binary cache key: %w", err)
+	}
+	cacheDir := filepath.Join(workingDir, BinaryCacheDir)
+	name := binaryName(inputs.target.ImportPath)
+	path := filepath.Join(cacheDir, name+"-"+key+".test")
+	if runtime.GOOS == "windows" {
+		path += ".exe"
+	}
+
+	bin := &testBinary{path: path}
+	if data, err := os.ReadFile(path + ".json"); err == nil && json.Unmarshal(data, bin) == nil {
+		if _, err := os.Stat(path); err == nil {
+			log.Debugf("Reusing cached test binary %s", path)
+			return bin, false, nil
+		}
+	}
+	bin.ImportPath, bin.Dir = inputs.target.ImportPath, inputs.target.Dir
+
+	// Only the binary of the latest code and settings is kept for each package.
+	stale, _ := filepath.Glob(filepath.Join(cacheDir, name+"-"+strings.Repeat("?", len(key))+".test*"))
+	for _, file := range stale {
+		os.Remove(file)
+	}
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return nil, false, err
+	}
+
+	args := []string{"test", "-c", "-o", path}
+	if config.Race {
+		args = append(args, "-race")
+	}
+	if config.Coverage {
+		args = append(args, "-cover")
+		if config.CoverMode != "" {
+			args = append(args, "-covermode="+config.CoverMode)
+		}
+		if config.CoverPkg != "" {
+			args = append(args, "-coverpkg="+config.CoverPkg)
+		}
+	}
+	if len(config.Tags) > 0 {
+		args = append(args, "-tags="+strings.Join(config.Tags, ","))
+	}
+	args = append(append(args, buildFlags...), config.PackagePath)
+
+	log.Infof("Building test binary: go %s (in %s)", strings.Join(args, " "), workingDir)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = workingDir
+	if len(config.Env) > 0 {
+		cmd.Env = append(os.Environ(), config.Env...)
+	}
+	// Built like the tests are run, so that stopping the run doesn't wait for the build.
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if config.Stop != nil {
+		startInGroup(cmd)
+	}
+	err = cmd.Start()
+	if err == nil {
+		exited := killOnStop(cmd, config.Stop)
+		err = cmd.Wait()
+		exited()
+	}
+	select {
+	case <-config.Stop:
+		log.Infof("Building the test binary of %s was stopped", bin.ImportPath)
+		os.Remove(path) // May be partly written
+		return nil, true, fmt.Errorf("build test binary of %s: stopped", bin.ImportPath)
+	default:
+	}
+	if err != nil {
+		log.Infof("Building the test binary of %s failed: %v", bin.ImportPath, err)
+		sendPackageEvents(bin.ImportPath, "fail", strings.TrimSpace(output.String())+"\nFAIL\t"+bin.ImportPath+" [build failed]", send)
+		return nil, true, fmt.Errorf("build test binary of %s: %w", bin.ImportPath, err)
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		// `go test -c` writes no binary for a package without test files.
+		sendPackageEvents(bin.ImportPath, "skip", fmt.Sprintf("?   \t%s\t[no test files]", bin.ImportPath), send)
+		return nil, true, nil
+	}
+
+	data, err := json.Marshal(bin)
+	if err == nil {
+		err = os.WriteFile(path+".json", data, 0o644)
+	}
+	if err != nil {
+		log.Warnf("Could not record the cached test binary %s, it will be built again: %v", path, err)
+	}
+	return bin, true, nil
+}
+
+// binaryEnv are the `go env` variables a test binary depends on, besides its sources.
+var binaryEnv = []string{
+	"GOVERSION", "GOROOT", "GOTOOLCHAIN", "GOFLAGS", "GOOS", "GOARCH", "GOEXPERIMENT", "GOWORK",
+	"GO386", "GOAMD64", "GOARM", "GOARM64", "GOMIPS", "GOMIPS64", "GOPPC64", "GORISCV64", "GOWASM",
+	"CGO_ENABLED", "CC", "CXX", "CGO_CFLAGS", "CGO_CPPFLAGS", "CGO_CXXFLAGS", "CGO_FFLAGS", "CGO_LDFLAGS",
+	"PKG_CONFIG",
+}
+
+// listedPackage is a package of `go list -json`, with the files it is built from.
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	ForTest    string
+	DepOnly    bool
+	Standard   bool
+	Module     *struct{ GoMod string }
+
+	GoFiles, CgoFiles, CFiles, CXXFiles, HFiles, MFiles, FFiles, SFiles, SysoFiles []string
+	EmbedFiles, TestGoFiles, XTestGoFiles, TestEmbedFiles, XTestEmbedFiles         []string
+}
+
+// buildInputs is what the test binary of a package is built from.
+type buildInputs struct {
+	target listedPackage     // The package under test
+	files  []string          // Absolute paths of the files of the package and its dependencies
+	env    map[string]string // Values of binaryEnv
+}
+
+// listBuildInputs lists the files the test binary of the run's package is built from, with
+// `go list -deps -test`, and the `go env` settings it depends on. The standard library is
+// left to GOVERSION and GOROOT.
+func listBuildInputs(workingDir string, config TestRunConfig, buildFlags []string) (*buildInputs, error) {
+	fields := "ImportPath,Dir,ForTest,DepOnly,Standard,Module,GoFiles,CgoFiles,CFiles,CXXFiles,HFiles,MFiles,FFiles," +
+		"SFiles,SysoFiles,EmbedFiles,TestGoFiles,XTestGoFiles,TestEmbedFiles,XTestEmbedFiles"
+	args := []string{"list", "-e", "-deps", "-test", "-json=" + fields}
+	if config.Race {
+		args = append(args, "-race")
+	}
+	if len(config.Tags) > 0 {
+		args = append(args, "-tags="+strings.Join(config.Tags, ","))
+	}
+	out, err := goOutput(workingDir, config, append(append(args, buildFlags...), config.PackagePath)...)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := &buildInputs{}
+	found := false
+	modules := make(map[string]bool)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var pkg listedPackage
+		if err := dec.Decode(&pkg); err != nil {
+			return nil, fmt.Errorf("go list %s: %w", config.PackagePath, err)
+		}
+		if pkg.Standard {
+			continue
+		}
+		if pkg.ForTest == "" && strings.HasSuffix(pkg.ImportPath, ".test") {
+			continue // The generated main package of the test binary
+		}
+		if !pkg.DepOnly && pkg.ForTest == "" && !found {
+			inputs.target, found = pkg, true
+		}
+		for _, files := range [][]string{pkg.GoFiles, pkg.CgoFiles, pkg.CFiles, pkg.CXXFiles, pkg.HFiles, pkg.MFiles,
+			pkg.FFiles, pkg.SFiles, pkg.SysoFiles, pkg.EmbedFiles, pkg.TestGoFiles, pkg.XTestGoFiles,
+			pkg.TestEmbedFiles, pkg.XTestEmbedFiles} {
+			for _, file := range files {
+				inputs.files = append(inputs.files, filepath.Join(pkg.Dir, file))
+			}
+		}
+		if pkg.Module != nil && pkg.Module.GoMod != "" && !modules[pkg.Module.GoMod] {
+			modules[pkg.Module.GoMod] = true
+			goSum := strings.TrimSuffix(pkg.Module.GoMod, ".mod") + ".sum"
+			inputs.files = append(inputs.files, pkg.Module.GoMod, goSum)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("go list %s: package not found", config.PackagePath)
+	}
+
+	out, err = goOutput(workingDir, config, append([]string{"env", "-json"}, binaryEnv...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(out, &inputs.env); err != nil {
+		return nil, fmt.Errorf("go env: %w", err)
+	}
+	if work := inputs.env["GOWORK"]; work != "" && work != "off" {
+		inputs.files = append(inputs.files, work, work+".sum")
+	}
+	return inputs, nil
+}
+
+// goOutput runs a go command with the environment of the run and returns its standard output.
+func goOutput(workingDir string, config TestRunConfig, args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = workingDir
+	if len(config.Env) > 0 {
+		cmd.Env = append(os.Environ(), config.Env...)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("go %s: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("go %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// binaryKey returns the cache key of the test binary of the run's package: a hash of what the
+// binary is built from, i.e. its build inputs, as of their size and modification time, and the
+// build settings. Missing files, such as a module without go.sum, are hashed as such.
+func binaryKey(workingDir string, config TestRunConfig, buildFlags []string, inputs *buildInputs) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\n", workingDir, config.PackagePath)
+	for _, name := range binaryEnv {
+		fmt.Fprintf(h, "%s=%s\n", name, inputs.env[name])
+	}
+	fmt.Fprintf(h, "race=%t cover=%t %s %s\n", config.Race, config.Coverage, config.CoverMode, config.CoverPkg)
+	for _, values := range [][]string{config.Tags, buildFlags, config.Env} {
+		fmt.Fprintf(h, "%s\n", strings.Join(values, "\x00"))
+	}
+
+	files := slices.Clone(inputs.files)
+	slices.Sort(files)
+	for _, file := range slices.Compact(files) {
+		info, err := os.Stat(file)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			fmt.Fprintf(h, "%s\x00-\n", file)
+		case err != nil:
+			return "", err
+		default:
+			fmt.Fprintf(h, "%s\x00%d\x00%d\n", file, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// binaryName returns the name of the cached binaries of a package: the last element of its
+// import path and a hash of the whole path, e.g. "parser-1a2b3c4d" for "gdd/parser".
+func binaryName(importPath string) string {
+	sum := sha256.Sum256([]byte(importPath))
+	base := importPath[strings.LastIndex(importPath, "/")+1:]
+	base = strings.NewReplacer(".", "_", "\\", "_").Replace(base)
+	return base + "-" + hex.EncodeToString(sum[:4])
+}
+
+// splitTestFlags splits `go test` flags into build flags, passed to `go test -c`, and those
+// the test binary takes.
+func splitTestFlags(flags []string) (build, binary []string) {
+	for _, flag := range flags {
+		if slices.Contains(testBinaryFlags, flagName(flag)) {
+			binary = append(binary, flag)
+		} else {
+			build = append(build, flag)
+		}
+	}
+	return build, binary
+}
+
+// flagName returns the name of a flag such as "-timeout=5m".
+func flagName(flag string) string {
+	name, _, _ := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+	return name
+}
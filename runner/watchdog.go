@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// SlowTestMsg warns that a single test has been running for longer than
+// TestRunConfig.SlowTestThreshold. It is sent at most once per test and run.
+type SlowTestMsg struct {
+	Package string
+	Test    string
+	Elapsed time.Duration // How long the test had been running when the warning was sent
+}
+
+// watchdogCheckInterval is how often the watchdog looks for tests exceeding the threshold.
+const watchdogCheckInterval = time.Second
+
+// watchdog tracks the start time of every running test from the `go test -json` stream
+// and emits a SlowTestMsg when one of them exceeds the threshold. It is a "soft" watchdog:
+// it only warns, it never kills the test process.
+type watchdog struct {
+	threshold time.Duration
+
+	mu      sync.Mutex
+	started map[string]time.Time // Key: "package\x00test"
+	warned  map[string]bool
+}
+
+func newWatchdog(threshold time.Duration) *watchdog {
+	return &watchdog{
+		threshold: threshold,
+		started:   make(map[string]time.Time),
+		warned:    make(map[string]bool),
+	}
+}
+
+// observe updates the set of running tests from a single JSON output line.
+func (w *watchdog) observe(line string) {
+	var event struct {
+		Action  string
+		Package string
+		Test    string
+	}
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Test == "" {
+		return // Not a test event (or not JSON at all); nothing to track.
+	}
+
+	key := event.Package + "\x00" + event.Test
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch event.Action {
+	case "run":
+		w.started[key] = time.Now()
+	case "pass", "fail", "skip":
+		delete(w.started, key)
+	}
+}
+
+// run checks the running tests periodically until stop is closed,
+// passing a SlowTestMsg to send for each test that crosses the threshold.
+func (w *watchdog) run(send func(tea.Msg), stop <-chan struct{}) {
+	ticker := time.NewTicker(watchdogCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			for _, msg := range w.slowTests(now) {
+				log.Warnf("Watchdog: test %s/%s has been running for %s", msg.Package, msg.Test, msg.Elapsed.Round(time.Second))
+				send(msg)
+			}
+		}
+	}
+}
+
+// slowTests returns warnings for tests that crossed the threshold and were not reported yet.
+func (w *watchdog) slowTests(now time.Time) []SlowTestMsg {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var slow []SlowTestMsg
+	for key, start := range w.started {
+		elapsed := now.Sub(start)
+		if elapsed < w.threshold || w.warned[key] {
+			continue
+		}
+		w.warned[key] = true
+		pkg, test, _ := strings.Cut(key, "\x00")
+		slow = append(slow, SlowTestMsg{Package: pkg, Test: test, Elapsed: elapsed})
+	}
+	return slow
+}
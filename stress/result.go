@@ -0,0 +1,231 @@
+package stress
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gdd/parser"
+)
+
+// clusterSimilarity is the minimum Jaccard similarity between the words of two failure
+// messages for them to be grouped in the same cluster.
+const clusterSimilarity = 0.7
+
+// Verdict summarizes how a test behaved across a stress run.
+type Verdict string
+
+const (
+	VerdictStable  Verdict = "stable"  // Passed (or was skipped) every time
+	VerdictFlaky   Verdict = "flaky"   // Both passed and failed
+	VerdictFailing Verdict = "failing" // Failed every time
+)
+
+// FailureCluster groups failures of a test with similar messages.
+type FailureCluster struct {
+	Message    []string // Output of the first failure of the cluster
+	Count      int
+	Iterations []int // Iterations in which the cluster occurred (may repeat with -count)
+
+	words map[string]bool // Normalized words of Message, used to compare new failures
+}
+
+// TestStats aggregates every execution of a test during a stress run.
+type TestStats struct {
+	Package   string
+	Name      string
+	Passes    int
+	Failures  int
+	Skips     int
+	Durations []time.Duration // Durations of passing and failing executions, in execution order
+	Clusters  []*FailureCluster
+}
+
+// Executions returns how many times the test ran.
+func (s *TestStats) Executions() int {
+	return s.Passes + s.Failures + s.Skips
+}
+
+// PassRate returns the percentage of non-skipped executions that passed.
+func (s *TestStats) PassRate() float64 {
+	if s.Passes+s.Failures == 0 {
+		return 100
+	}
+	return float64(s.Passes) / float64(s.Passes+s.Failures) * 100
+}
+
+// Verdict tells whether the test is stable, flaky or consistently failing.
+func (s *TestStats) Verdict() Verdict {
+	switch {
+	case s.Failures == 0:
+		return VerdictStable
+	case s.Passes == 0:
+		return VerdictFailing
+	default:
+		return VerdictFlaky
+	}
+}
+
+// Distribution summarizes the durations of the test's executions.
+type Distribution struct {
+	Min, Median, P90, Max time.Duration
+	Buckets               []int // Executions per equal-width duration range between Min and Max
+}
+
+// DurationDistribution computes the duration distribution of the test with the given number of buckets.
+func (s *TestStats) DurationDistribution(buckets int) Distribution {
+	if len(s.Durations) == 0 {
+		return Distribution{}
+	}
+	sorted := append([]time.Duration(nil), s.Durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	d := Distribution{
+		Min:     sorted[0],
+		Median:  sorted[len(sorted)/2],
+		P90:     sorted[min(len(sorted)-1, len(sorted)*9/10)],
+		Max:     sorted[len(sorted)-1],
+		Buckets: make([]int, buckets),
+	}
+	width := d.Max - d.Min
+	for _, dur := range sorted {
+		i := 0
+		if width > 0 {
+			i = int(int64(dur-d.Min) * int64(buckets) / int64(width+1))
+		}
+		d.Buckets[i]++
+	}
+	return d
+}
+
+// Result aggregates the iterations of a stress run. It is built incrementally with Add,
+// so that it can be shown while the stress run is in progress.
+type Result struct {
+	Config     Config
+	Iterations int
+	Elapsed    time.Duration
+	Stopped    StopReason // Empty while the stress run is in progress
+	Err        error      // Error of the last iteration, if `go test` failed without running tests
+	ErrOutput  []string   // Output of that iteration
+
+	tests map[string]*TestStats
+	order []string
+}
+
+// NewResult creates an empty result for a stress run.
+func NewResult(cfg Config) *Result {
+	return &Result{Config: cfg, tests: make(map[string]*TestStats)}
+}
+
+// Add merges an iteration into the result.
+func (r *Result) Add(it Iteration) {
+	r.Iterations = it.Index
+	r.Elapsed = it.Elapsed
+	if it.Err != nil {
+		r.Err = it.Err
+		r.ErrOutput = it.Output
+	}
+
+	for _, o := range it.Outcomes {
+		key := o.Package + "\x00" + o.Test
+		stats, ok := r.tests[key]
+		if !ok {
+			stats = &TestStats{Package: o.Package, Name: o.Test}
+			r.tests[key] = stats
+			r.order = append(r.order, key)
+		}
+
+		switch o.Status {
+		case parser.StatusPass:
+			stats.Passes++
+			stats.Durations = append(stats.Durations, o.Duration)
+		case parser.StatusFail:
+			stats.Failures++
+			if o.Duration > 0 {
+				stats.Durations = append(stats.Durations, o.Duration)
+			}
+			stats.addFailure(it.Index, o.Output)
+		default:
+			stats.Skips++
+		}
+	}
+}
+
+// Failures returns the total number of failed executions.
+func (r *Result) Failures() int {
+	n := 0
+	for _, s := range r.tests {
+		n += s.Failures
+	}
+	return n
+}
+
+// Tests returns the statistics of every test, flaky and failing tests first, then by lowest pass rate.
+func (r *Result) Tests() []*TestStats {
+	tests := make([]*TestStats, 0, len(r.order))
+	for _, key := range r.order {
+		tests = append(tests, r.tests[key])
+	}
+	rank := map[Verdict]int{VerdictFlaky: 0, VerdictFailing: 1, VerdictStable: 2}
+	sort.SliceStable(tests, func(i, j int) bool {
+		if rank[tests[i].Verdict()] != rank[tests[j].Verdict()] {
+			return rank[tests[i].Verdict()] < rank[tests[j].Verdict()]
+		}
+		return tests[i].PassRate() < tests[j].PassRate()
+	})
+	return tests
+}
+
+// addFailure records a failure in the cluster with the most similar message, or in a new cluster.
+func (s *TestStats) addFailure(iteration int, output []string) {
+	words := normalizedWords(output)
+	var best *FailureCluster
+	bestScore := 0.0
+	for _, c := range s.Clusters {
+		if score := jaccard(words, c.words); score >= clusterSimilarity && score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	if best == nil {
+		best = &FailureCluster{Message: output, words: words}
+		s.Clusters = append(s.Clusters, best)
+	}
+	best.Count++
+	best.Iterations = append(best.Iterations, iteration)
+	sort.SliceStable(s.Clusters, func(i, j int) bool { return s.Clusters[i].Count > s.Clusters[j].Count })
+}
+
+var (
+	hexRe    = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	numberRe = regexp.MustCompile(`\d+(\.\d+)?`)
+)
+
+// normalizedWords returns the set of words of a failure message, with addresses and numbers
+// (durations, goroutine IDs, line numbers of values, ...) replaced by placeholders so that
+// failures differing only in such details compare as equal.
+func normalizedWords(lines []string) map[string]bool {
+	words := make(map[string]bool)
+	for _, line := range lines {
+		line = hexRe.ReplaceAllString(line, "0x?")
+		line = numberRe.ReplaceAllString(line, "#")
+		for _, w := range strings.Fields(line) {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// jaccard returns the Jaccard similarity of two word sets (1 when both are empty).
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	intersection := 0
+	for w := range a {
+		if b[w] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
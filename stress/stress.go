@@ -0,0 +1,163 @@
+package stress
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gdd/parser"
+	"gdd/runner"
+
+	"github.com/charmbracelet/log"
+)
+
+// Config describes a stress run: the same tests run over and over until one of the limits is hit.
+type Config struct {
+	// Base is the run to repeat, usually a single test or a package.
+	Base runner.TestRunConfig
+	// Runs is the number of `go test` invocations. Zero means no limit (a budget or failure limit is then required).
+	Runs int
+	// MaxFailures stops the stress run once this many test failures were seen. Zero means no limit.
+	MaxFailures int
+	// Budget stops the stress run once this much time was spent. Zero means no limit.
+	// The iteration in progress when the budget runs out is completed.
+	Budget time.Duration
+}
+
+// StopReason tells why a stress run ended.
+type StopReason string
+
+const (
+	StoppedRuns      StopReason = "completed all runs"
+	StoppedFailures  StopReason = "reached the failure limit"
+	StoppedBudget    StopReason = "time budget spent"
+	StoppedCancelled StopReason = "cancelled"
+	StoppedError     StopReason = "`go test` failed without running tests"
+)
+
+// Outcome is the result of one execution of one test.
+type Outcome struct {
+	Package  string
+	Test     string
+	Status   parser.TestStatus
+	Duration time.Duration
+	Output   []string // Output of this execution only, without the === RUN / --- PASS framing
+}
+
+// Iteration is the result of one `go test` invocation. With `-count` or `-cpu`, a test
+// can have several outcomes in a single iteration.
+type Iteration struct {
+	Index    int // 1-based
+	Outcomes []Outcome
+	Elapsed  time.Duration // Time spent in the stress run so far, including this iteration
+	Err      error         // Error running `go test` itself, if no test ran
+	Output   []string      // Output not attributed to any test (e.g. build errors)
+}
+
+// Failures returns the number of failing outcomes in the iteration.
+func (it Iteration) Failures() int {
+	n := 0
+	for _, o := range it.Outcomes {
+		if o.Status == parser.StatusFail {
+			n++
+		}
+	}
+	return n
+}
+
+// Run executes the stress run, calling send after every iteration. It returns once a limit is hit,
+// `go test` fails without running any test, or stop is closed, which kills the running iteration.
+func Run(cfg Config, send func(Iteration), stop <-chan struct{}) StopReason {
+	start := time.Now()
+	failures := 0
+	log.Infof("Stress: starting %s (runs: %d, max failures: %d, budget: %s, count: %d, shuffle: %t, cpu: %q).",
+		cfg.Base.Type, cfg.Runs, cfg.MaxFailures, cfg.Budget, cfg.Base.Count, cfg.Base.Shuffle, cfg.Base.CPU)
+
+	for i := 1; cfg.Runs == 0 || i <= cfg.Runs; i++ {
+		select {
+		case <-stop:
+			return StoppedCancelled
+		default:
+		}
+
+		base := cfg.Base
+		base.Stop = stop
+		output, complete := runner.RunSync(base)
+		select {
+		case <-stop:
+			return StoppedCancelled // The iteration was killed, its results are incomplete
+		default:
+		}
+		it := parseIteration(output)
+		it.Index = i
+		it.Elapsed = time.Since(start)
+		if len(it.Outcomes) == 0 && complete.Err != nil {
+			it.Err = complete.Err
+		}
+		send(it)
+
+		if it.Err != nil {
+			log.Warnf("Stress: iteration %d ran no tests: %v", i, it.Err)
+			return StoppedError
+		}
+		failures += it.Failures()
+		if cfg.MaxFailures > 0 && failures >= cfg.MaxFailures {
+			return StoppedFailures
+		}
+		if cfg.Budget > 0 && time.Since(start) >= cfg.Budget {
+			return StoppedBudget
+		}
+	}
+	return StoppedRuns
+}
+
+// parseIteration splits a `go test -json` stream into one outcome per test execution.
+// parser.Parse merges repeated executions of a test, which is not what a stress run needs.
+func parseIteration(output []byte) Iteration {
+	var it Iteration
+	pending := make(map[string][]string) // Output of running tests, keyed by package and test
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var event parser.TestEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			it.Output = append(it.Output, scanner.Text())
+			continue
+		}
+		if event.Test == "" {
+			if event.Action == "output" {
+				it.Output = append(it.Output, strings.TrimRight(event.Output, "\n"))
+			}
+			continue
+		}
+
+		key := event.Package + "\x00" + event.Test
+		switch event.Action {
+		case "run":
+			pending[key] = nil
+		case "output":
+			if !parser.IsFraming(event.Output) {
+				pending[key] = append(pending[key], strings.TrimRight(event.Output, "\n"))
+			}
+		case "pass", "fail", "skip":
+			it.Outcomes = append(it.Outcomes, Outcome{
+				Package:  event.Package,
+				Test:     event.Test,
+				Status:   parser.TestStatus(strings.ToUpper(event.Action)),
+				Duration: time.Duration(event.Elapsed * float64(time.Second)),
+				Output:   pending[key],
+			})
+			delete(pending, key)
+		}
+	}
+
+	// Tests that never finished were interrupted, e.g. by a panic in another test or a timeout.
+	for key, lines := range pending {
+		pkg, test, _ := strings.Cut(key, "\x00")
+		it.Outcomes = append(it.Outcomes, Outcome{Package: pkg, Test: test, Status: parser.StatusFail, Output: append(lines, it.Output...)})
+	}
+	return it
+}
@@ -0,0 +1,45 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// CoverageKeyMap defines keybindings for the coverage viewer.
+// The mode tags tell the key registry which screen of the viewer an action belongs to.
+type CoverageKeyMap struct {
+	OpenFile      key.Binding `category:"view" mode:"picker"`
+	FilePicker    key.Binding `category:"view" mode:"viewer"`
+	NextUncovered key.Binding `category:"navigation" mode:"viewer"`
+	PrevUncovered key.Binding `category:"navigation" mode:"viewer"`
+	CoveringTests key.Binding `category:"view" mode:"viewer"`
+	Back          key.Binding `category:"general"`
+	// Viewport keys are handled by the viewport model itself (up, down, pgup, pgdn, etc.)
+}
+
+// DefaultCoverageKeyMap returns a new CoverageKeyMap with default keybindings.
+func DefaultCoverageKeyMap() CoverageKeyMap {
+	return CoverageKeyMap{
+		OpenFile: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open file"),
+		),
+		FilePicker: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "files"),
+		),
+		NextUncovered: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next uncovered"),
+		),
+		PrevUncovered: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "prev uncovered"),
+		),
+		CoveringTests: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "covering tests"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc", "q", "b"),
+			key.WithHelp("esc/q/b", "back"),
+		),
+	}
+}
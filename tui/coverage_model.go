@@ -0,0 +1,446 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gdd/coverage"
+	"gdd/impact"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// coverageViewMode selects what the coverage viewer is currently showing.
+type coverageViewMode int
+
+const (
+	coverageFilePicker coverageViewMode = iota // Choosing a file, sorted by lowest coverage
+	coverageSourceView                         // Showing a file's source with highlighted blocks
+)
+
+// coverageFileItem is a list.Item for a profiled source file in the file picker.
+type coverageFileItem struct {
+	profile *coverage.Profile
+	relPath string
+	covered int64
+	total   int64
+}
+
+// Title returns the file path relative to the module root.
+func (fi coverageFileItem) Title() string { return fi.relPath }
+
+// Description returns the file's statement coverage.
+func (fi coverageFileItem) Description() string {
+	return fmt.Sprintf("%.1f%% (%d/%d statements)", coverage.Percent(fi.covered, fi.total), fi.covered, fi.total)
+}
+
+// FilterValue returns the string to filter on.
+func (fi coverageFileItem) FilterValue() string { return fi.relPath }
+
+// backFromCoverageMsg signals to leave the coverage viewer.
+type backFromCoverageMsg struct{}
+
+// Line states used when highlighting source code.
+const (
+	lineStateNone      = iota // Not part of any coverage block (comments, declarations, ...)
+	lineStateCovered          // Inside a block that ran at least once
+	lineStateUncovered        // Inside a block that never ran
+)
+
+// CoverageModel shows the source of profiled files with covered and uncovered blocks highlighted.
+type CoverageModel struct {
+	picker   list.Model
+	viewport viewport.Model
+	keys     *CoverageKeyMap // A pointer, as the picker's help reads it too
+	styles   *AppStyles
+	logger   *log.Logger
+
+	width  int
+	height int
+
+	mode    coverageViewMode
+	report  *coverage.Report
+	current *coverageFileItem
+	source  []string // Lines of the current file
+	title   string   // Describes what is being shown, e.g. "Coverage of TestFoo"
+
+	uncovered []int // 0-based line numbers where uncovered blocks start
+	cursor    int   // Index into uncovered of the highlighted block, -1 if none
+
+	impactIndex *impact.Index // Per-test coverage, used to answer "which tests cover this file"
+	showTests   bool          // Whether the viewport shows the covering tests instead of the source
+
+	buildImpactKey *key.Binding // Test list key that builds the impact index, named when there is none
+}
+
+// NewCoverageModel creates a new instance of the CoverageModel.
+func NewCoverageModel(delegate *list.DefaultDelegate, logger *log.Logger, styles *AppStyles) CoverageModel {
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.Styles.Title = styles.ListHeader
+	l.Styles.FilterPrompt = styles.ListFilterPrompt
+	l.Styles.FilterCursor = styles.ListFilterCursor
+	l.Styles.PaginationStyle = styles.ListPagination
+	l.Styles.HelpStyle = styles.Help
+	releaseListKeys(&l)
+
+	keys := DefaultCoverageKeyMap()
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.OpenFile, keys.Back}
+	}
+
+	vp := viewport.New(0, 0)
+	vp.Style = styles.ReportViewport
+
+	return CoverageModel{
+		picker:   l,
+		viewport: vp,
+		keys:     &keys,
+		styles:   styles,
+		logger:   logger,
+		cursor:   -1,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m CoverageModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the CoverageModel.
+func (m CoverageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.mode == coverageFilePicker {
+			if m.picker.FilterState() == list.Filtering {
+				break
+			}
+			switch {
+			case key.Matches(msg, m.keys.OpenFile):
+				if item, ok := m.picker.SelectedItem().(coverageFileItem); ok {
+					m.openFile(item)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Back):
+				m.logger.Debug("CoverageModel: Leaving coverage viewer.")
+				return m, func() tea.Msg { return backFromCoverageMsg{} }
+			}
+			break
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.NextUncovered):
+			m.jumpUncovered(1)
+			return m, nil
+		case key.Matches(msg, m.keys.PrevUncovered):
+			m.jumpUncovered(-1)
+			return m, nil
+		case key.Matches(msg, m.keys.CoveringTests):
+			m.showTests = !m.showTests
+			m.render()
+			m.viewport.GotoTop()
+			return m, nil
+		case key.Matches(msg, m.keys.FilePicker), key.Matches(msg, m.keys.Back):
+			m.mode = coverageFilePicker
+			m.showTests = false
+			return m, nil
+		}
+	}
+
+	if m.mode == coverageFilePicker {
+		m.picker, cmd = m.picker.Update(msg)
+	} else {
+		m.viewport, cmd = m.viewport.Update(msg)
+	}
+	return m, cmd
+}
+
+// View renders the CoverageModel.
+func (m CoverageModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return m.styles.Loading.Render("Initializing coverage view...")
+	}
+	if m.mode == coverageFilePicker {
+		return m.picker.View()
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.viewport.View())
+}
+
+// headerView renders the current file name, its coverage and the uncovered block position.
+func (m CoverageModel) headerView() string {
+	if m.current == nil {
+		return ""
+	}
+	position := fmt.Sprintf("%d uncovered blocks", len(m.uncovered))
+	if m.cursor >= 0 {
+		position = fmt.Sprintf("uncovered block %d/%d", m.cursor+1, len(m.uncovered))
+	}
+	header := fmt.Sprintf("%s — %s — %s", m.current.relPath, m.current.Description(), position)
+	return m.styles.ListHeader.Render(limitString(header, max(m.width-2, 0)))
+}
+
+func (m *CoverageModel) setSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.picker.SetSize(width, height)
+	m.viewport.Width = width
+	m.viewport.Height = max(height-1, 0) // One line for the header
+}
+
+// SetReport loads a coverage report into the viewer and shows the file picker.
+// If onlyCovered is true, files without any covered statement are left out,
+// which is what "what does this test cover" wants.
+func (m *CoverageModel) SetReport(report *coverage.Report, title string, onlyCovered bool) tea.Cmd {
+	m.report = report
+	m.title = title
+	m.mode = coverageFilePicker
+	m.current = nil
+	m.source = nil
+
+	var items []coverageFileItem
+	for _, p := range report.Profiles {
+		covered, total := p.Statements()
+		if total == 0 || (onlyCovered && covered == 0) {
+			continue
+		}
+		items = append(items, coverageFileItem{profile: p, relPath: report.RelPath(p.FileName), covered: covered, total: total})
+	}
+
+	// Lowest coverage first: those are the files worth looking at.
+	sort.SliceStable(items, func(i, j int) bool {
+		pi, pj := coverage.Percent(items[i].covered, items[i].total), coverage.Percent(items[j].covered, items[j].total)
+		if pi != pj {
+			return pi < pj
+		}
+		return items[i].relPath < items[j].relPath
+	})
+
+	listItems := make([]list.Item, len(items))
+	for i, item := range items {
+		listItems[i] = item
+	}
+	m.picker.Title = fmt.Sprintf("%s — %.1f%% of statements", title, report.Percent())
+	m.logger.Debugf("CoverageModel: Loaded %d files for %q.", len(items), title)
+	return m.picker.SetItems(listItems)
+}
+
+// openFile reads a profiled file from disk and renders it with coverage highlighting.
+func (m *CoverageModel) openFile(item coverageFileItem) {
+	m.current = &item
+	m.mode = coverageSourceView
+	m.cursor = -1
+
+	path, err := m.report.SourcePath(item.profile.FileName)
+	if err == nil {
+		var data []byte
+		data, err = os.ReadFile(path)
+		m.source = strings.Split(string(data), "\n")
+	}
+	if err != nil {
+		m.logger.Errorf("CoverageModel: Could not read source of %s: %v", item.profile.FileName, err)
+		m.source = nil
+		m.uncovered = nil
+		m.viewport.SetContent(m.styles.Error.Render("Could not read source: " + err.Error()))
+		return
+	}
+
+	m.uncovered = uncoveredStarts(item.profile)
+	m.showTests = false
+	m.render()
+	m.viewport.GotoTop()
+}
+
+// jumpUncovered moves the highlight to the next (dir > 0) or previous uncovered block, wrapping around.
+func (m *CoverageModel) jumpUncovered(dir int) {
+	if len(m.uncovered) == 0 {
+		return
+	}
+	if m.cursor < 0 {
+		if dir > 0 {
+			m.cursor = 0
+		} else {
+			m.cursor = len(m.uncovered) - 1
+		}
+	} else {
+		m.cursor = (m.cursor + dir + len(m.uncovered)) % len(m.uncovered)
+	}
+	m.render()
+	// Keep a few lines of context above the block.
+	m.viewport.SetYOffset(max(m.uncovered[m.cursor]-3, 0))
+}
+
+// render highlights the current file and puts it into the viewport.
+func (m *CoverageModel) render() {
+	if m.current == nil || m.source == nil {
+		return
+	}
+	if m.showTests {
+		m.viewport.SetContent(m.coveringTestsView())
+		return
+	}
+	profile := m.current.profile
+	states, counts := lineCoverage(m.source, profile)
+	showCounts := profile.Mode == "count" || profile.Mode == "atomic"
+
+	current := -1
+	if m.cursor >= 0 {
+		current = m.uncovered[m.cursor]
+	}
+
+	var b strings.Builder
+	for i, line := range m.source {
+		marker := " "
+		if i == current {
+			marker = m.styles.CoverageCurrent.Render("▶")
+		}
+		count := ""
+		if showCounts && counts[i] >= 0 {
+			count = fmt.Sprintf("%d", counts[i])
+		}
+		gutter := m.styles.CoverageGutter.Render(fmt.Sprintf("%4d %6s │", i+1, count))
+		b.WriteString(marker + gutter + " " + m.highlightLine(line, states[i]) + "\n")
+	}
+	m.viewport.SetContent(b.String())
+}
+
+// coveringTestsView lists, for every function of the current file, the tests that execute it
+// according to the impact index.
+func (m *CoverageModel) coveringTestsView() string {
+	if m.impactIndex == nil {
+		hint := "No test impact index available."
+		if m.buildImpactKey != nil {
+			hint += fmt.Sprintf(" Press %s in the test list to build it.", m.buildImpactKey.Help().Key)
+		}
+		return m.styles.Help.Render(hint)
+	}
+
+	funcs, err := m.impactIndex.TestsByFunc(m.current.relPath)
+	if err != nil {
+		return m.styles.Error.Render("Could not list covering tests: " + err.Error())
+	}
+
+	var b strings.Builder
+	all := m.impactIndex.TestsForFile(m.current.relPath)
+	b.WriteString(m.styles.Title.Render(fmt.Sprintf("%d tests cover %s", len(all), m.current.relPath)) + "\n")
+	if stale := m.impactIndex.ChangedFiles(); slices.Contains(stale, m.current.relPath) {
+		b.WriteString(m.styles.StatusTimeout.Render("File changed since it was indexed; results may be outdated.") + "\n")
+	}
+	b.WriteString("\n")
+	for _, fn := range funcs {
+		b.WriteString(fmt.Sprintf("%s %s\n", m.styles.CoverageGutter.Render(fmt.Sprintf("%4d", fn.StartLine)), fn.Name))
+		if len(fn.Tests) == 0 {
+			b.WriteString(m.styles.CoverageUncovered.Render("       not covered by any test") + "\n")
+			continue
+		}
+		for _, t := range fn.Tests {
+			b.WriteString(m.styles.CoverageCovered.Render(fmt.Sprintf("       %s", t.Name)) + m.styles.Help.Render(" ("+t.PackageDir+")") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// SetImpactIndex sets (or clears, with nil) the index used to list covering tests.
+func (m *CoverageModel) SetImpactIndex(idx *impact.Index) {
+	m.impactIndex = idx
+	if m.showTests {
+		m.render()
+	}
+}
+
+// highlightLine renders a line, styling each run of bytes by its coverage state.
+func (m *CoverageModel) highlightLine(line string, states []int) string {
+	var b strings.Builder
+	start := 0
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && states[i] == states[start] {
+			continue
+		}
+		segment := strings.ReplaceAll(line[start:i], "\t", "    ")
+		switch states[start] {
+		case lineStateCovered:
+			b.WriteString(m.styles.CoverageCovered.Render(segment))
+		case lineStateUncovered:
+			b.WriteString(m.styles.CoverageUncovered.Render(segment))
+		default:
+			b.WriteString(segment)
+		}
+		start = i
+	}
+	return b.String()
+}
+
+// lineCoverage computes, for every byte of every line, whether it is covered, uncovered
+// or outside any block, and for every line the highest hit count of the blocks touching it
+// (-1 if no block touches the line).
+func lineCoverage(lines []string, profile *coverage.Profile) ([][]int, []int) {
+	states := make([][]int, len(lines))
+	counts := make([]int, len(lines))
+	for i, line := range lines {
+		states[i] = make([]int, len(line))
+		counts[i] = -1
+	}
+
+	for _, block := range profile.Blocks {
+		state := lineStateUncovered
+		if block.Count > 0 {
+			state = lineStateCovered
+		}
+		for ln := block.StartLine; ln <= block.EndLine && ln-1 < len(lines); ln++ {
+			idx := ln - 1
+			from, to := 0, len(lines[idx])
+			if ln == block.StartLine {
+				from = min(max(block.StartCol-1, 0), to)
+			}
+			if ln == block.EndLine {
+				to = min(max(block.EndCol-1, from), to)
+			}
+			for col := from; col < to; col++ {
+				states[idx][col] = state
+			}
+			counts[idx] = max(counts[idx], block.Count)
+		}
+	}
+	return states, counts
+}
+
+// uncoveredStarts returns the 0-based start lines of uncovered regions,
+// merging adjacent uncovered blocks so that navigation skips over them as one.
+func uncoveredStarts(profile *coverage.Profile) []int {
+	var starts []int
+	lastEnd := -2
+	for _, block := range profile.Blocks {
+		if block.Count > 0 {
+			lastEnd = -2
+			continue
+		}
+		if block.StartLine > lastEnd+1 {
+			starts = append(starts, block.StartLine-1)
+		}
+		lastEnd = block.EndLine
+	}
+	return starts
+}
+
+// HelpView returns a string containing the help information for the coverage viewer.
+func (m CoverageModel) HelpView() string {
+	if m.mode == coverageFilePicker {
+		return fmt.Sprintf("%s → %s, / → filter, %s → %s",
+			m.keys.OpenFile.Help().Key, m.keys.OpenFile.Help().Desc, m.keys.Back.Help().Key, m.keys.Back.Help().Desc)
+	}
+	return fmt.Sprintf("%s/%s → next/prev uncovered, %s → %s, %s → files, ↑/↓/pgup/pgdn → scroll, %s → back",
+		m.keys.NextUncovered.Help().Key, m.keys.PrevUncovered.Help().Key, m.keys.CoveringTests.Help().Key, m.keys.CoveringTests.Help().Desc,
+		m.keys.FilePicker.Help().Key, m.keys.Back.Help().Key)
+}
@@ -0,0 +1,24 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// HelpKeyMap defines keybindings for the help overlay, in addition to scrolling.
+// The global help key closes it as well, unless the search is being typed.
+type HelpKeyMap struct {
+	Search key.Binding `category:"search"`
+	Close  key.Binding `category:"general"`
+}
+
+// DefaultHelpKeyMap returns a new HelpKeyMap with default keybindings.
+func DefaultHelpKeyMap() HelpKeyMap {
+	return HelpKeyMap{
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search help"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "q"),
+			key.WithHelp("esc/q", "close help"),
+		),
+	}
+}
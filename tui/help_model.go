@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// helpGroup is a titled group of actions listed by the help overlay.
+type helpGroup struct {
+	title   string
+	actions []*keyAction
+}
+
+// closeHelpMsg signals to hide the help overlay.
+type closeHelpMsg struct{}
+
+// HelpModel is the full-screen overlay listing the keybindings that apply in the current view,
+// by category. It is generated from the key registry, so it shows the keys as remapped by the
+// configuration, and can be searched by key, description or category.
+type HelpModel struct {
+	viewport viewport.Model
+	search   textinput.Model
+	keys     HelpKeyMap
+	styles   *AppStyles
+	logger   *log.Logger
+
+	width  int
+	height int
+
+	title  string // Title of the view whose keys are listed
+	groups []helpGroup
+}
+
+// NewHelpModel creates a new instance of the HelpModel.
+func NewHelpModel(logger *log.Logger, styles *AppStyles) HelpModel {
+	vp := viewport.New(0, 0)
+	vp.Style = styles.ReportViewport
+
+	search := textinput.New()
+	search.Prompt = "/"
+	search.PromptStyle = styles.ListFilterPrompt
+	search.Cursor.Style = styles.ListFilterCursor
+	search.Placeholder = "key, action or category"
+
+	return HelpModel{
+		viewport: vp,
+		search:   search,
+		keys:     DefaultHelpKeyMap(),
+		styles:   styles,
+		logger:   logger,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m HelpModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the HelpModel.
+func (m HelpModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if m.search.Focused() {
+			return m, m.updateOnSearchInput(msg)
+		}
+		switch {
+		case msg.String() == "esc" && m.search.Value() != "":
+			m.logger.Debug("HelpModel: Clearing search.")
+			m.search.SetValue("")
+			m.render()
+			return m, nil
+		case key.Matches(msg, m.keys.Search):
+			m.logger.Debug("HelpModel: Focusing search.")
+			cmd = m.search.Focus()
+			m.render()
+			return m, cmd
+		case key.Matches(msg, m.keys.Close):
+			m.logger.Debug("HelpModel: Closing help.")
+			return m, func() tea.Msg { return closeHelpMsg{} }
+		}
+	}
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// updateOnSearchInput handles a key while the search is typed: enter keeps the search,
+// esc drops it, other keys edit it and narrow the list down as they are typed.
+func (m *HelpModel) updateOnSearchInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		m.search.Blur()
+		m.render()
+		return nil
+	case "esc":
+		m.search.Blur()
+		m.search.SetValue("")
+		m.render()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.render()
+	m.viewport.GotoTop()
+	return cmd
+}
+
+// View renders the title, the search line while there is one, and the keybindings.
+func (m HelpModel) View() string {
+	parts := []string{m.styles.ReportTitle.Render("Keybindings: " + m.title)}
+	if m.searching() {
+		parts = append(parts, m.search.View())
+	}
+	parts = append(parts, m.viewport.View())
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+// HelpView returns the keys of the overlay, for the footer.
+func (m HelpModel) HelpView() string {
+	if m.search.Focused() {
+		return "enter → keep search, esc → clear search"
+	}
+	return fmt.Sprintf("↑/↓/pgup/pgdn → scroll, %s → %s, %s → close",
+		m.keys.Search.Help().Key, m.keys.Search.Help().Desc, m.keys.Close.Help().Key)
+}
+
+// SetGroups sets the actions to list, under the title of their view, and starts over
+// without a search.
+func (m *HelpModel) SetGroups(title string, groups []helpGroup) {
+	m.title = title
+	m.groups = groups
+	m.search.Blur()
+	m.search.SetValue("")
+	m.render()
+	m.viewport.GotoTop()
+}
+
+// searching reports whether the search line is shown.
+func (m HelpModel) searching() bool {
+	return m.search.Focused() || m.search.Value() != ""
+}
+
+func (m *HelpModel) setSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.search.Width = width - len(m.search.Prompt) - 1
+	m.render()
+}
+
+// render lays out the groups matching the search in the viewport.
+func (m *HelpModel) render() {
+	header := lipgloss.Height(m.styles.ReportTitle.Render(m.title))
+	if m.searching() {
+		header++
+	}
+	frameWidth, frameHeight := m.viewport.Style.GetFrameSize()
+	m.viewport.Width = max(m.width-frameWidth, 1)
+	m.viewport.Height = max(m.height-header-frameHeight, 1)
+
+	query := strings.ToLower(m.search.Value())
+	var groups []helpGroup
+	keyWidth := 0
+	for _, g := range m.groups {
+		matching := helpGroup{title: g.title}
+		for _, a := range g.actions {
+			help := a.binding.Help()
+			text := strings.ToLower(help.Key + "\n" + help.Desc + "\n" + g.title)
+			if query == "" || strings.Contains(text, query) {
+				matching.actions = append(matching.actions, a)
+				keyWidth = max(keyWidth, lipgloss.Width(help.Key))
+			}
+		}
+		if len(matching.actions) > 0 {
+			groups = append(groups, matching)
+		}
+	}
+	if len(groups) == 0 {
+		m.viewport.SetContent(m.styles.Help.Render(fmt.Sprintf("No keybindings match %q.", m.search.Value())))
+		return
+	}
+
+	var lines []string
+	for i, g := range groups {
+		title := m.styles.HelpGroup
+		if i == 0 {
+			title = title.UnsetMarginTop()
+		}
+		lines = append(lines, title.Render(g.title))
+		for _, a := range g.actions {
+			help := a.binding.Help()
+			keys := m.styles.HelpKey.Render(help.Key + strings.Repeat(" ", keyWidth-lipgloss.Width(help.Key)))
+			lines = append(lines, "  "+keys+"  "+help.Desc)
+		}
+	}
+	m.viewport.SetContent(lipgloss.NewStyle().MaxWidth(m.viewport.Width).Render(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,33 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// HistoryKeyMap defines keybindings for the run history view.
+type HistoryKeyMap struct {
+	OpenRun key.Binding `category:"view"`
+	Mark    key.Binding `category:"general"`
+	Compare key.Binding `category:"view"`
+	Back    key.Binding `category:"general"`
+}
+
+// DefaultHistoryKeyMap returns a new HistoryKeyMap with default keybindings.
+func DefaultHistoryKeyMap() HistoryKeyMap {
+	return HistoryKeyMap{
+		OpenRun: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open report"),
+		),
+		Mark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mark for comparison"),
+		),
+		Compare: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "diff with marked"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc", "q", "b"),
+			key.WithHelp("esc/q/b", "back"),
+		),
+	}
+}
@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"gdd/history"
+	"gdd/parser"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// historyItem is a list.Item for a stored run.
+type historyItem struct {
+	entry  *history.Entry
+	icon   string
+	marked bool // Marked as one side of a comparison
+}
+
+// Title returns the run's start time and scope.
+func (hi historyItem) Title() string {
+	title := fmt.Sprintf("%s %s — %s", hi.icon, hi.entry.StartedAt.Format("2006-01-02 15:04:05"), describeRunScope(hi.entry.Config))
+	if hi.marked {
+		title += " [marked]"
+	}
+	return title
+}
+
+// Description returns the run's counts and duration.
+func (hi historyItem) Description() string {
+	return fmt.Sprintf("%d passed, %d failed, %d skipped in %d packages — %s",
+		hi.entry.Passed, hi.entry.Failed, hi.entry.Skipped, hi.entry.Packages, hi.entry.Duration.Round(time.Millisecond))
+}
+
+// FilterValue returns the string to filter on.
+func (hi historyItem) FilterValue() string {
+	return fmt.Sprintf("%s %s %s", hi.entry.StartedAt.Format("2006-01-02 15:04:05"), describeRunScope(hi.entry.Config), hi.entry.Status)
+}
+
+// openHistoryViewMsg signals an intent to browse the run history.
+type openHistoryViewMsg struct{}
+
+// historyLoadedMsg carries the stored runs, newest first.
+type historyLoadedMsg struct {
+	entries []*history.Entry
+	err     error
+}
+
+// openHistoryRunMsg signals an intent to reopen the report of a stored run.
+type openHistoryRunMsg struct{ id string }
+
+// compareRunsMsg signals an intent to compare two stored runs.
+type compareRunsMsg struct{ firstID, secondID string }
+
+// backFromHistoryMsg signals to leave the history view.
+type backFromHistoryMsg struct{}
+
+// HistoryModel lists past runs and reopens their reports.
+type HistoryModel struct {
+	list   list.Model
+	keys   *HistoryKeyMap // Also read by the list's help, hence a pointer
+	styles *AppStyles
+	logger *log.Logger
+
+	markedID string // Run marked for comparison, "" if none
+}
+
+// NewHistoryModel creates a new instance of the HistoryModel.
+func NewHistoryModel(delegate *list.DefaultDelegate, logger *log.Logger, styles *AppStyles) HistoryModel {
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.Title = "Run History"
+	l.Styles.Title = styles.ListHeader
+	l.Styles.FilterPrompt = styles.ListFilterPrompt
+	l.Styles.FilterCursor = styles.ListFilterCursor
+	l.Styles.PaginationStyle = styles.ListPagination
+	l.Styles.HelpStyle = styles.Help
+	releaseListKeys(&l)
+
+	keys := DefaultHistoryKeyMap()
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.OpenRun, keys.Mark, keys.Compare, keys.Back}
+	}
+
+	return HistoryModel{
+		list:   l,
+		keys:   &keys,
+		styles: styles,
+		logger: logger,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m HistoryModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the HistoryModel.
+func (m HistoryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		switch {
+		case key.Matches(msg, m.keys.OpenRun):
+			if item, ok := m.list.SelectedItem().(historyItem); ok {
+				m.logger.Debugf("HistoryModel: Opening run %s.", item.entry.ID)
+				return m, func() tea.Msg { return openHistoryRunMsg{id: item.entry.ID} }
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Mark):
+			if item, ok := m.list.SelectedItem().(historyItem); ok {
+				if m.markedID == item.entry.ID {
+					m.markedID = ""
+				} else {
+					m.markedID = item.entry.ID
+				}
+				return m, m.refreshMarks()
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Compare):
+			item, ok := m.list.SelectedItem().(historyItem)
+			if !ok || m.markedID == "" || m.markedID == item.entry.ID {
+				return m, func() tea.Msg {
+					return errorMsg{err: fmt.Errorf("mark a run with '%s', then select another run to compare it with", m.keys.Mark.Help().Key)}
+				}
+			}
+			m.logger.Debugf("HistoryModel: Comparing runs %s and %s.", m.markedID, item.entry.ID)
+			first, second := m.markedID, item.entry.ID
+			return m, func() tea.Msg { return compareRunsMsg{firstID: first, secondID: second} }
+		case key.Matches(msg, m.keys.Back):
+			m.logger.Debug("HistoryModel: Leaving history view.")
+			return m, func() tea.Msg { return backFromHistoryMsg{} }
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the list of past runs.
+func (m HistoryModel) View() string {
+	return m.list.View()
+}
+
+func (m *HistoryModel) setSize(width, height int) {
+	m.list.SetSize(width, height)
+}
+
+// SetEntries replaces the listed runs.
+func (m *HistoryModel) SetEntries(entries []*history.Entry) tea.Cmd {
+	items := make([]list.Item, len(entries))
+	for i, entry := range entries {
+		icon := m.styles.StatusPass.Render(m.styles.PassIcon)
+		if entry.Status == parser.StatusFail {
+			icon = m.styles.StatusFail.Render(m.styles.FailIcon)
+		}
+		items[i] = historyItem{entry: entry, icon: icon, marked: entry.ID == m.markedID}
+	}
+	m.list.Title = fmt.Sprintf("Run History (%d runs)", len(entries))
+	return m.list.SetItems(items)
+}
+
+// refreshMarks updates the marker shown on each run after the marked run changed.
+func (m *HistoryModel) refreshMarks() tea.Cmd {
+	items := m.list.Items()
+	for i, it := range items {
+		if item, ok := it.(historyItem); ok {
+			item.marked = item.entry.ID == m.markedID
+			items[i] = item
+		}
+	}
+	return m.list.SetItems(items)
+}
+
+// HelpView returns a string with help for the history view's keybindings.
+func (m HistoryModel) HelpView() string {
+	return fmt.Sprintf("%s → %s, %s → %s, %s → %s, / → filter, %s → %s",
+		m.keys.OpenRun.Help().Key, m.keys.OpenRun.Help().Desc, m.keys.Mark.Help().Key, m.keys.Mark.Help().Desc,
+		m.keys.Compare.Help().Key, m.keys.Compare.Help().Desc, m.keys.Back.Help().Key, m.keys.Back.Help().Desc)
+}
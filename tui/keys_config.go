@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gdd/config"
+)
+
+// keyPresets are sets of keybindings applied before those of the configuration, selected by
+// its key_preset setting.
+var keyPresets = map[string]map[string]map[string]config.KeyList{
+	"default": {},
+	"vim": {
+		"browser": {"quit": {"q", ": q"}},
+		// A key that breaks a chord is handled on its own, so the second "g" of "g g" reaches
+		// the list, which moves to the top on "g", as in vim.
+		"list": {
+			"run_changed":       {"g c"},
+			"run_all_tests":     {"g a"},
+			"run_package_tests": {"g p"},
+		},
+		"tree": {
+			"toggle":       {" ", "z a"},
+			"expand_all":   {"z R"},
+			"collapse_all": {"z M"},
+		},
+		"report": {
+			"back_to_list": {"esc", "q", "h"},
+			"top":          {"home", "g g"},
+			"next_failure": {"] c"},
+			"prev_failure": {"[ c"},
+			"outline_fold": {" ", "z a"},
+		},
+		"output": {
+			"top":  {"home", "g g"},
+			"back": {"esc", "q", "h"},
+		},
+		"coverage": {"back": {"esc", "q", "h"}},
+		"history":  {"back": {"esc", "q", "h"}},
+	},
+}
+
+// configureKeys applies the key preset and then the keybindings of the configuration to the
+// registry, and checks the result for conflicts. It returns every problem found.
+func configureKeys(r *keyRegistry, cfg config.Config) []error {
+	var errs []error
+	preset := cfg.KeyPreset
+	if preset == "" {
+		preset = "default"
+	}
+	if remaps, ok := keyPresets[preset]; ok {
+		errs = append(errs, remapKeys(r, remaps)...)
+	} else {
+		errs = append(errs, fmt.Errorf("key_preset: unknown preset %q (one of %s)", preset, strings.Join(slices.Sorted(maps.Keys(keyPresets)), ", ")))
+	}
+	errs = append(errs, remapKeys(r, cfg.Keys)...)
+	return append(errs, r.conflicts()...)
+}
+
+// remapKeys applies keybindings, by view and action, to the registry's actions. The help of
+// a remapped action shows its new keys. It returns a problem for every unknown view or action
+// and every empty key, which are left alone.
+func remapKeys(r *keyRegistry, remaps map[string]map[string]config.KeyList) []error {
+	var errs []error
+	views := r.views()
+	for _, view := range slices.Sorted(maps.Keys(remaps)) {
+		if !slices.Contains(views, view) {
+			errs = append(errs, fmt.Errorf("keys: unknown view %q (one of %s)", view, strings.Join(views, ", ")))
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(remaps[view])) {
+			action := r.lookup(view, name)
+			if action == nil {
+				errs = append(errs, fmt.Errorf("keys.%s: unknown action %q", view, name))
+				continue
+			}
+			keys := remaps[view][name]
+			if len(keys) == 0 || slices.Contains(keys, "") {
+				errs = append(errs, fmt.Errorf("keys.%s.%s: empty key", view, name))
+				continue
+			}
+
+			bound := make([]string, len(keys))
+			help := make([]string, len(keys))
+			for i, k := range keys {
+				bound[i], help[i] = k, k
+				if k == "space" || k == " " {
+					bound[i], help[i] = " ", "space" // bubbletea names the space key " "
+				}
+			}
+			action.binding.SetKeys(bound...)
+			action.binding.SetHelp(strings.Join(help, "/"), action.binding.Help().Desc)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,441 @@
+package tui
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyAction is an action of a view, triggered by the keys of its binding.
+type keyAction struct {
+	view     string       // View handling the action, e.g. "report"
+	name     string       // Name of the action in snake case, as in the config, e.g. "next_failure"
+	category string       // Category the help lists the action under, one of keyCategories
+	mode     string       // Mode of the view the action is limited to, e.g. "picker"; empty if none
+	layer    string       // Layer of the view the action belongs to, e.g. "outline"; empty if none
+	binding  *key.Binding // The binding in the keymap of the view's model
+}
+
+// keyRegistry holds the actions of every view. The bindings are those of the models' keymaps,
+// so that remapping an action changes the keys its model matches.
+//
+// The `category` tag of a keymap's field groups the action in the help overlay.
+// A view can have modes and layers, set by the `mode` and `layer` tags of the keymap's fields.
+// An action with a mode only applies in that mode of the view (e.g. the file picker of the
+// coverage viewer). An action of a layer applies while the layer is shown, in addition to the
+// view's other actions, and takes precedence over them (e.g. the outline of the report).
+type keyRegistry struct {
+	actions []*keyAction
+	titles  map[string]string // Titles of the views, by name
+}
+
+// keyView is a view whose keymap is part of the registry.
+type keyView struct {
+	name   string
+	title  string // Shown in the help overlay
+	keymap any    // Pointer to the keymap struct
+}
+
+// keyViews returns the views whose actions can be remapped, in the order they are shown.
+// Their actions are named after the fields of their keymaps in snake case, e.g.
+// ListKeyMap.RunAllTests is "run_all_tests" of the "list" view.
+func keyViews(m *MainModel) []keyView {
+	return []keyView{
+		{"global", "Everywhere", &m.globalKeys},
+		{"browser", "Test browser", &m.browserKeys},
+		{"split", "Split view", &m.splitKeys},
+		{"list", "Test list", m.listModel.keys},
+		{"tree", "Test tree", &m.treeModel.keys},
+		{"report", "Report", &m.reportModel.keys},
+		{"output", "Test output", m.testOutputModel.keys},
+		{"coverage", "Coverage", m.coverageModel.keys},
+		{"history", "Run history", m.historyModel.keys},
+		{"profiles", "Run profiles", m.profileModel.keys},
+		{"stress", "Stress test", &m.stressModel.keys},
+		{"queue", "Run queue", m.queueModel.keys},
+		{"help", "Help", &m.helpModel.keys},
+	}
+}
+
+// keyCategories are the categories of actions, in the order the help overlay lists them.
+var keyCategories = []struct{ name, title string }{
+	{"run", "Running tests"},
+	{"navigation", "Navigation"},
+	{"search", "Search and filter"},
+	{"view", "Views and layout"},
+	{"options", "Run options"},
+	{"general", "General"},
+}
+
+// sharedKeyViews are the views whose actions also apply in other views, besides "global".
+var sharedKeyViews = map[string][]string{
+	"list": {"browser", "split"},
+	"tree": {"browser", "split"},
+}
+
+// newKeyRegistry registers the actions of the keymaps of m's views.
+func newKeyRegistry(m *MainModel) *keyRegistry {
+	r := &keyRegistry{titles: make(map[string]string)}
+	for _, v := range keyViews(m) {
+		r.titles[v.name] = v.title
+		keymap := reflect.ValueOf(v.keymap).Elem()
+		for i := 0; i < keymap.NumField(); i++ {
+			binding, ok := keymap.Field(i).Addr().Interface().(*key.Binding)
+			if !ok {
+				continue
+			}
+			field := keymap.Type().Field(i)
+			r.actions = append(r.actions, &keyAction{
+				view:     v.name,
+				name:     snakeCase(field.Name),
+				category: field.Tag.Get("category"),
+				mode:     field.Tag.Get("mode"),
+				layer:    field.Tag.Get("layer"),
+				binding:  binding,
+			})
+		}
+	}
+	return r
+}
+
+// snakeCase converts a Go field name to snake case, e.g. "RunAllTests" to "run_all_tests".
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// views returns the names of the views, in registration order.
+func (r *keyRegistry) views() []string {
+	var views []string
+	for _, a := range r.actions {
+		if !slices.Contains(views, a.view) {
+			views = append(views, a.view)
+		}
+	}
+	return views
+}
+
+// lookup returns the action of a view, or nil if there is none by that name.
+func (r *keyRegistry) lookup(view, name string) *keyAction {
+	for _, a := range r.actions {
+		if a.view == view && a.name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// active returns the actions that apply in a view in the given mode or layer (empty if
+// none): the view's own, those of the views it shares keys with and the global ones.
+func (r *keyRegistry) active(view, state string) []*keyAction {
+	views := append([]string{view}, sharedKeyViews[view]...)
+	views = append(views, "global")
+
+	var actions []*keyAction
+	for _, a := range r.actions {
+		if !slices.Contains(views, a.view) || !a.binding.Enabled() {
+			continue
+		}
+		if (a.mode == "" || a.mode == state) && (a.layer == "" || a.layer == state) {
+			actions = append(actions, a)
+		}
+	}
+	return actions
+}
+
+// groups returns the actions that apply in a view in the given mode or layer, grouped by
+// category. Builtin are the keys of the view's bubbles components, such as the cursor keys of
+// a list, which aren't remappable; the keys the view's actions take over are left out of them.
+func (r *keyRegistry) groups(view, state string, builtin []*keyAction) []helpGroup {
+	actions := r.active(view, state)
+	bound := make(map[string]bool)
+	for _, a := range actions {
+		for _, k := range a.binding.Keys() {
+			bound[k] = true
+		}
+	}
+	for _, b := range builtin {
+		if !b.binding.Enabled() {
+			continue
+		}
+		keys := slices.DeleteFunc(slices.Clone(b.binding.Keys()), func(k string) bool { return bound[k] })
+		switch {
+		case len(keys) == 0:
+			continue
+		case len(keys) < len(b.binding.Keys()):
+			// The help of the binding names the keys its own way, e.g. "↑/k", so it is rebuilt.
+			binding := key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(keys, "/"), b.binding.Help().Desc))
+			b = &keyAction{category: b.category, binding: &binding}
+		}
+		actions = append(actions, b)
+	}
+
+	var groups []helpGroup
+	for _, c := range keyCategories {
+		g := helpGroup{title: c.title}
+		for _, a := range actions {
+			if a.category == c.name || a.category == "" && c.name == "general" {
+				g.actions = append(g.actions, a)
+			}
+		}
+		if len(g.actions) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// builtinKeys returns the keys of the bubbles components of the current view, for the help.
+func builtinKeys(m *MainModel) []*keyAction {
+	var lm *list.KeyMap
+	var vm *viewport.KeyMap
+	switch m.state {
+	case stateTestList:
+		lm = &m.listModel.list.KeyMap
+	case stateHistoryView:
+		lm = &m.historyModel.list.KeyMap
+	case stateProfileView:
+		lm = &m.profileModel.list.KeyMap
+	case stateTestOutputView:
+		if m.testOutputModel.mode == testOutputPicker {
+			lm = &m.testOutputModel.picker.KeyMap
+		} else {
+			vm = &m.testOutputModel.viewport.KeyMap
+		}
+	case stateCoverageView:
+		if m.coverageModel.mode == coverageFilePicker {
+			lm = &m.coverageModel.picker.KeyMap
+		} else {
+			vm = &m.coverageModel.viewport.KeyMap
+		}
+	case stateReportView:
+		vm = &m.reportModel.viewport.KeyMap
+	case stateStressView:
+		if m.stressModel.mode == stressResults {
+			vm = &m.stressModel.viewport.KeyMap
+		}
+	}
+	if m.showQueue {
+		lm, vm = &m.queueModel.list.KeyMap, nil
+	}
+
+	var keys []*keyAction
+	add := func(category string, bindings ...*key.Binding) {
+		for _, b := range bindings {
+			keys = append(keys, &keyAction{category: category, binding: b})
+		}
+	}
+	if lm != nil {
+		add("navigation", &lm.CursorUp, &lm.CursorDown, &lm.PrevPage, &lm.NextPage, &lm.GoToStart, &lm.GoToEnd)
+		add("search", &lm.Filter, &lm.ClearFilter)
+	}
+	if vm != nil {
+		add("navigation", &vm.Up, &vm.Down, &vm.PageUp, &vm.PageDown, &vm.HalfPageUp, &vm.HalfPageDown)
+	}
+	return keys
+}
+
+// conflicts returns a problem for every key bound to two actions that apply at the same time,
+// and every key that is also the start of a chord (e.g. "g" and "g g"), which can't both work.
+// The actions of a layer may shadow those of the view below it.
+func (r *keyRegistry) conflicts() []error {
+	var errs []error
+	reported := make(map[string]bool) // Scopes overlap, so the same conflict can be found twice
+	for _, view := range r.views() {
+		states := []string{""}
+		for _, a := range r.actions {
+			if a.view != view {
+				continue
+			}
+			for _, state := range []string{a.mode, a.layer} {
+				if state != "" && !slices.Contains(states, state) {
+					states = append(states, state)
+				}
+			}
+		}
+
+		for _, state := range states {
+			scope := r.active(view, state)
+			if r.isLayer(view, state) {
+				scope = slices.DeleteFunc(scope, func(a *keyAction) bool { return a.view != "global" && a.layer != state })
+			}
+			for _, err := range scopeConflicts(view, scope) {
+				if !reported[err.Error()] {
+					reported[err.Error()] = true
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// isLayer reports whether state is a layer of view.
+func (r *keyRegistry) isLayer(view, state string) bool {
+	return state != "" && slices.ContainsFunc(r.actions, func(a *keyAction) bool { return a.view == view && a.layer == state })
+}
+
+// scopeConflicts returns the conflicts between actions that apply at the same time in view.
+func scopeConflicts(view string, actions []*keyAction) []error {
+	var errs []error
+	bound := make(map[string]*keyAction)
+	for _, a := range actions {
+		for _, k := range a.binding.Keys() {
+			k = strings.Join(chordParts(k), " ")
+			if other, ok := bound[k]; ok && other != a {
+				errs = append(errs, fmt.Errorf("keys.%s: %q is bound to both %s and %s", view, k, other.qualifiedName(view), a.qualifiedName(view)))
+				continue
+			}
+			bound[k] = a
+		}
+	}
+	for k, a := range bound {
+		parts := chordParts(k)
+		for n := 1; n < len(parts); n++ {
+			prefix := strings.Join(parts[:n], " ")
+			if other, ok := bound[prefix]; ok {
+				errs = append(errs, fmt.Errorf("keys.%s: %q of %s is also the start of %q of %s", view, prefix, other.qualifiedName(view), k, a.qualifiedName(view)))
+			}
+		}
+	}
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errs
+}
+
+// qualifiedName returns the name of the action, prefixed by its view if it isn't view.
+func (a *keyAction) qualifiedName(view string) string {
+	if a.view == view {
+		return a.name
+	}
+	return a.view + "." + a.name
+}
+
+// --- Chords ---
+
+// chordParts splits the keys of a binding into the keys of its chord, e.g. "g g" into "g" and "g".
+// The space key is written "space" in chords; on its own it is " ", as bubbletea names it.
+func chordParts(keys string) []string {
+	if keys == " " {
+		return []string{"space"}
+	}
+	return strings.Fields(keys)
+}
+
+// chordPart returns the name of a key press in a chord.
+func chordPart(msg tea.KeyMsg) string {
+	if s := msg.String(); s != " " {
+		return s
+	}
+	return "space"
+}
+
+// chordMsg returns the key message of a completed chord. Its String() is the chord as written
+// in the bindings, e.g. "g g", so that the models match it with key.Matches like any other key.
+func chordMsg(chord string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(chord)}
+}
+
+// isChordMsg reports whether msg is a chord sent by chordMsg rather than a key press.
+func isChordMsg(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyRunes && !msg.Paste && len(strings.Fields(string(msg.Runes))) > 1
+}
+
+// matchChord reports whether a sequence of keys, e.g. "g g", is a chord of one of the actions
+// and whether it is the start of a longer one.
+func matchChord(actions []*keyAction, seq string) (complete, prefix bool) {
+	for _, a := range actions {
+		for _, k := range a.binding.Keys() {
+			chord := strings.Join(chordParts(k), " ")
+			complete = complete || chord == seq
+			prefix = prefix || strings.HasPrefix(chord, seq+" ")
+		}
+	}
+	return complete, prefix
+}
+
+// keyContext returns the view whose actions apply in the current state, and its mode or layer.
+func keyContext(m *MainModel) (view, state string) {
+	if m.showQueue {
+		return "queue", ""
+	}
+	switch m.state {
+	case stateTestList:
+		return "list", ""
+	case stateTreeView:
+		return "tree", ""
+	case stateReportView:
+		if m.reportModel.outlineFocus {
+			return "report", "outline"
+		}
+		return "report", ""
+	case stateTestOutputView:
+		if m.testOutputModel.mode == testOutputPicker {
+			return "output", "picker"
+		}
+		return "output", "viewer"
+	case stateCoverageView:
+		if m.coverageModel.mode == coverageFilePicker {
+			return "coverage", "picker"
+		}
+		return "coverage", "viewer"
+	case stateHistoryView:
+		return "history", ""
+	case stateProfileView:
+		return "profiles", ""
+	case stateStressView:
+		return "stress", ""
+	}
+	return "", ""
+}
+
+// typing reports whether keys go to a text input of the current view, such as a filter,
+// so that they must not trigger global actions.
+func typing(m *MainModel) bool {
+	if m.showHelp {
+		return m.helpModel.search.Focused()
+	}
+	if m.showQueue {
+		return m.queueModel.list.FilterState() == list.Filtering
+	}
+	switch m.state {
+	case stateTestList:
+		return m.listModel.list.FilterState() == list.Filtering
+	case stateTreeView:
+		return m.treeModel.Filtering()
+	case stateReportView:
+		return m.reportModel.search.Focused()
+	case stateTestOutputView:
+		return m.testOutputModel.mode == testOutputPicker && m.testOutputModel.picker.FilterState() == list.Filtering
+	case stateCoverageView:
+		return m.coverageModel.mode == coverageFilePicker && m.coverageModel.picker.FilterState() == list.Filtering
+	case stateHistoryView:
+		return m.historyModel.list.FilterState() == list.Filtering
+	case stateProfileView:
+		return m.profileModel.list.FilterState() == list.Filtering
+	}
+	return false
+}
+
+// releaseListKeys disables the keys with which a bubbles list quits the program or shows its
+// full help, which MainModel handles with the remappable global and browser actions instead.
+func releaseListKeys(l *list.Model) {
+	l.DisableQuitKeybindings()
+	l.KeyMap.ShowFullHelp.Unbind() // The list enables these again as it updates, unbinding sticks
+	l.KeyMap.CloseFullHelp.Unbind()
+}
@@ -11,8 +11,8 @@ import (
 // It embeds charmbracelet/bubbles/list.Model and adds application-specific logic.
 type ListModel struct {
 	list   list.Model
-	keys   ListKeyMap
-	styles *AppStyles // Styles passed from MainModel
+	keys   *ListKeyMap // Shared with the list's help, so that keys remapped by the configuration show there too
+	styles *AppStyles  // Styles passed from MainModel
 	logger *log.Logger
 
 	width  int
@@ -30,6 +30,7 @@ func NewListModel(delegate *list.DefaultDelegate, logger *log.Logger, styles *Ap
 	l.Styles.FilterCursor = styles.ListFilterCursor
 	l.Styles.PaginationStyle = styles.ListPagination
 	l.Styles.HelpStyle = styles.Help // For the list's built-in help
+	releaseListKeys(&l)
 
 	// Define additional keybindings for list actions shown in help
 	keys := DefaultListKeyMap()
@@ -38,6 +39,19 @@ func NewListModel(delegate *list.DefaultDelegate, logger *log.Logger, styles *Ap
 			keys.RunSelectedTest,
 			keys.RunPackageTests,
 			keys.RunAllTests,
+			keys.RunChanged,
+			keys.ToggleRace,
+			keys.ToggleCoverage,
+			keys.CoverSelected,
+			keys.FilterImpacted,
+			keys.TreeView,
+			keys.StatusFilter,
+			keys.SortOrder,
+			keys.ToggleWatch,
+			keys.Profile,
+			keys.History,
+			keys.StressTest,
+			keys.Quarantine,
 		}
 	}
 	// l.SetShowHelp(true) // By default, list shows its help. MainModel can control this.
@@ -47,7 +61,7 @@ func NewListModel(delegate *list.DefaultDelegate, logger *log.Logger, styles *Ap
 
 	return ListModel{
 		list:   l,
-		keys:   keys,
+		keys:   &keys,
 		styles: styles,
 		logger: logger,
 	}
@@ -97,6 +111,61 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.RunAllTests):
 			m.logger.Debug("ListModel: 'Run All Tests' key pressed.")
 			return m, func() tea.Msg { return triggerRunAllTestsMsg{} }
+		case key.Matches(msg, m.keys.RunChanged):
+			m.logger.Debug("ListModel: 'Run Changed' key pressed.")
+			return m, func() tea.Msg { return triggerRunChangedTestsMsg{} }
+		case key.Matches(msg, m.keys.ToggleRace):
+			m.logger.Debug("ListModel: 'Toggle Race' key pressed.")
+			return m, func() tea.Msg { return toggleRaceMsg{} }
+		case key.Matches(msg, m.keys.ToggleCoverage):
+			m.logger.Debug("ListModel: 'Cycle Coverage' key pressed.")
+			return m, func() tea.Msg { return cycleCoverageMsg{} }
+		case key.Matches(msg, m.keys.Precompile):
+			m.logger.Debug("ListModel: 'Toggle Precompile' key pressed.")
+			return m, func() tea.Msg { return togglePrecompileMsg{} }
+		case key.Matches(msg, m.keys.BuildImpact):
+			m.logger.Debug("ListModel: 'Update Impact Index' key pressed.")
+			return m, func() tea.Msg { return triggerImpactUpdateMsg{} }
+		case key.Matches(msg, m.keys.FilterImpacted):
+			m.logger.Debug("ListModel: 'Impacted Tests' key pressed.")
+			return m, func() tea.Msg { return toggleImpactFilterMsg{} }
+		case key.Matches(msg, m.keys.TreeView):
+			m.logger.Debug("ListModel: 'Tree View' key pressed.")
+			return m, func() tea.Msg { return toggleTreeViewMsg{} }
+		case key.Matches(msg, m.keys.StatusFilter):
+			m.logger.Debug("ListModel: 'Filter By Status' key pressed.")
+			return m, func() tea.Msg { return cycleStatusFilterMsg{} }
+		case key.Matches(msg, m.keys.SortOrder):
+			m.logger.Debug("ListModel: 'Cycle Sort Order' key pressed.")
+			return m, func() tea.Msg { return cycleSortOrderMsg{} }
+		case key.Matches(msg, m.keys.ToggleWatch):
+			m.logger.Debug("ListModel: 'Cycle Watch Mode' key pressed.")
+			return m, func() tea.Msg { return cycleWatchMsg{} }
+		case key.Matches(msg, m.keys.Profile):
+			m.logger.Debug("ListModel: 'Switch Profile' key pressed.")
+			return m, func() tea.Msg { return openProfilePickerMsg{} }
+		case key.Matches(msg, m.keys.History):
+			m.logger.Debug("ListModel: 'Run History' key pressed.")
+			return m, func() tea.Msg { return openHistoryViewMsg{} }
+		case key.Matches(msg, m.keys.StressTest), key.Matches(msg, m.keys.StressPackage):
+			m.logger.Debug("ListModel: 'Stress' key pressed.")
+			if m.list.SelectedItem() != nil {
+				wholePackage := key.Matches(msg, m.keys.StressPackage)
+				return m, func() tea.Msg { return triggerStressMsg{wholePackage: wholePackage} }
+			}
+		case key.Matches(msg, m.keys.Quarantine):
+			m.logger.Debug("ListModel: 'Toggle Quarantine' key pressed.")
+			if m.list.SelectedItem() != nil {
+				return m, func() tea.Msg { return toggleQuarantineMsg{} }
+			}
+		case key.Matches(msg, m.keys.EditQuarantine):
+			m.logger.Debug("ListModel: 'Edit Quarantine File' key pressed.")
+			return m, func() tea.Msg { return editQuarantineMsg{} }
+		case key.Matches(msg, m.keys.CoverSelected):
+			m.logger.Debug("ListModel: 'View Test Coverage' key pressed.")
+			if m.list.SelectedItem() != nil {
+				return m, func() tea.Msg { return triggerCoverSelectedTestMsg{} }
+			}
 		}
 	}
 
@@ -7,10 +7,26 @@ import (
 // ListKeyMap defines keybindings specifically for the list view actions.
 // These are in addition to the default list navigation and filtering keys.
 type ListKeyMap struct {
-	RunSelectedTest key.Binding
-	RunPackageTests key.Binding
-	RunAllTests     key.Binding
-	// Help            key.Binding // Potentially for a context-sensitive help view
+	RunSelectedTest key.Binding `category:"run"`
+	RunPackageTests key.Binding `category:"run"`
+	RunAllTests     key.Binding `category:"run"`
+	RunChanged      key.Binding `category:"run"`
+	ToggleRace      key.Binding `category:"options"`
+	ToggleCoverage  key.Binding `category:"options"`
+	Precompile      key.Binding `category:"options"`
+	CoverSelected   key.Binding `category:"run"`
+	BuildImpact     key.Binding `category:"run"`
+	FilterImpacted  key.Binding `category:"search"`
+	ToggleWatch     key.Binding `category:"options"`
+	Profile         key.Binding `category:"options"`
+	History         key.Binding `category:"view"`
+	StressTest      key.Binding `category:"run"`
+	StressPackage   key.Binding `category:"run"`
+	StatusFilter    key.Binding `category:"search"`
+	SortOrder       key.Binding `category:"view"`
+	TreeView        key.Binding `category:"view"`
+	Quarantine      key.Binding `category:"general"`
+	EditQuarantine  key.Binding `category:"general"`
 }
 
 // DefaultListKeyMap returns a new ListKeyMap with default keybindings.
@@ -28,5 +44,115 @@ func DefaultListKeyMap() ListKeyMap {
 			key.WithKeys("a"),
 			key.WithHelp("a", "run all"),
 		),
+		RunChanged: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "run changed"),
+		),
+		ToggleRace: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "toggle -race"),
+		),
+		ToggleCoverage: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "cycle coverage"),
+		),
+		Precompile: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "toggle cached test binaries"),
+		),
+		CoverSelected: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "view test coverage"),
+		),
+		BuildImpact: key.NewBinding(
+			key.WithKeys("I"),
+			key.WithHelp("I", "update impact index"),
+		),
+		FilterImpacted: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "impacted tests"),
+		),
+		ToggleWatch: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "cycle watch mode"),
+		),
+		Profile: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "switch profile"),
+		),
+		History: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "run history"),
+		),
+		StressTest: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "stress test"),
+		),
+		StressPackage: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "stress package"),
+		),
+		StatusFilter: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "filter by status"),
+		),
+		SortOrder: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "cycle sort order"),
+		),
+		TreeView: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "tree view"),
+		),
+		Quarantine: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "toggle quarantine"),
+		),
+		EditQuarantine: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "edit quarantine file"),
+		),
+	}
+}
+
+// GlobalKeyMap defines keybindings handled by MainModel in every view, unless text is being typed
+// (Quit always applies).
+type GlobalKeyMap struct {
+	Quit  key.Binding `category:"general"`
+	Help  key.Binding `category:"general"`
+	Queue key.Binding `category:"run"`
+}
+
+// DefaultGlobalKeyMap returns a new GlobalKeyMap with default keybindings.
+func DefaultGlobalKeyMap() GlobalKeyMap {
+	return GlobalKeyMap{
+		Quit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
+		Help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "help"),
+		),
+		Queue: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "run queue"),
+		),
+	}
+}
+
+// BrowserKeyMap defines keybindings handled by MainModel in the test list and the tree,
+// while they aren't being filtered.
+type BrowserKeyMap struct {
+	Quit key.Binding `category:"general"`
+}
+
+// DefaultBrowserKeyMap returns a new BrowserKeyMap with default keybindings.
+func DefaultBrowserKeyMap() BrowserKeyMap {
+	return BrowserKeyMap{
+		Quit: key.NewBinding(
+			key.WithKeys("q"),
+			key.WithHelp("q", "quit"),
+		),
 	}
 }
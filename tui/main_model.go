@@ -4,10 +4,20 @@ import (
 	"bytes"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"gdd/changes"
+	"gdd/config"
+	"gdd/coverage"
 	"gdd/finder"
+	"gdd/history"
+	"gdd/impact"
 	"gdd/parser"
+	"gdd/quarantine"
 	"gdd/runner"
+	"gdd/watch"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -21,29 +31,79 @@ import (
 type appState int
 
 const (
-	stateInitializing appState = iota // Initial state, discovering tests
-	stateTestList                     // Displaying the list of tests
-	stateRunningTests                 // Tests are currently being executed
-	stateReportView                   // Displaying the test results report
-	stateError                        // Displaying a fatal error
+	stateInitializing   appState = iota // Initial state, discovering tests
+	stateTestList                       // Displaying the list of tests
+	stateTreeView                       // Displaying the tests as a tree
+	stateRunningTests                   // Tests are currently being executed
+	stateReportView                     // Displaying the test results report
+	stateCoverageView                   // Displaying source files with coverage highlighting
+	stateTestOutputView                 // Browsing the output of the tests of a run
+	stateHistoryView                    // Browsing past runs
+	stateStressView                     // Setting up or watching a stress run
+	stateProfileView                    // Picking the profile of the next runs
+	stateError                          // Displaying a fatal error
 )
 
 // TestItem is a list.Item implementation for discovered Go tests.
 type TestItem struct {
 	finder.TestInfo // Embed TestInfo from the finder package
+
+	Last        *history.LastResult // Most recent outcome, nil if the test never ran
+	Flakiness   *history.Flakiness  // Score from the run history, nil if the test never ran
+	Quarantined bool                // Listed in the quarantine file
+
+	statusIcon string // Icon of the last status, set with Last
+	// Badges of the theme, set with Flakiness and Quarantined
+	flakyBadge, quarantinedBadge string
 }
 
-// Title returns the function name for the list item.
-func (ti TestItem) Title() string { return ti.Name }
+// Title returns the function name for the list item, followed by its badges.
+func (ti TestItem) Title() string {
+	title := ti.Name
+	if ti.statusIcon != "" {
+		title = ti.statusIcon + " " + title
+	}
+	if ti.Flakiness != nil && ti.Flakiness.Flaky() {
+		title += fmt.Sprintf("  %s %.0f%%", ti.flakyBadge, ti.Flakiness.Score*100)
+	}
+	if ti.Quarantined {
+		title += "  " + ti.quarantinedBadge
+	}
+	return title
+}
 
-// Description returns the package name and directory for the list item.
+// Description returns the package name and directory for the list item, followed by
+// the duration of the last run of the test and how long ago it ran.
 func (ti TestItem) Description() string {
-	return fmt.Sprintf("Pkg: %s (%s)", ti.PackageName, ti.PackageDir)
+	desc := fmt.Sprintf("Pkg: %s (%s)", ti.PackageName, ti.PackageDir)
+	if ti.Last != nil {
+		desc += fmt.Sprintf(" — %s, %s", ti.Last.Duration.Round(time.Millisecond), formatAge(time.Since(ti.Last.RunAt)))
+	}
+	return desc
 }
 
-// FilterValue returns the string to filter on.
+// FilterValue returns the string to filter on. It includes the last status, so that
+// e.g. filtering on "FAIL" finds the failing tests.
 func (ti TestItem) FilterValue() string {
-	return fmt.Sprintf("%s %s %s", ti.Name, ti.PackageName, ti.PackageDir)
+	value := fmt.Sprintf("%s %s %s", ti.Name, ti.PackageName, ti.PackageDir)
+	if ti.Last != nil {
+		value += " " + string(ti.Last.Status)
+	}
+	return value
+}
+
+// formatAge formats how long ago something happened, e.g. "5m ago".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
 }
 
 // --- Messages ---
@@ -65,11 +125,258 @@ type triggerRunPackageTestsMsg struct{}
 // triggerRunSelectedTestMsg signals an intent to run the single selected test function.
 type triggerRunSelectedTestMsg struct{}
 
+// triggerRunChangedTestsMsg signals an intent to run the tests of the packages affected
+// by the current git changes. The affected packages are resolved in the background first.
+type triggerRunChangedTestsMsg struct{}
+
+// changesResolvedMsg carries the packages affected by the current git changes.
+type changesResolvedMsg struct {
+	result *changes.Result
+	err    error
+}
+
+// triggerCoverSelectedTestMsg signals an intent to run the selected test with coverage
+// of the whole module and show what it covers in the coverage viewer.
+type triggerCoverSelectedTestMsg struct{}
+
+// openCoverageViewMsg signals an intent to open the coverage viewer for the last run.
+type openCoverageViewMsg struct{}
+
+// triggerImpactUpdateMsg signals an intent to build or update the test impact index.
+type triggerImpactUpdateMsg struct{}
+
+// runStartedMsg carries the stream of a run started by startRun, and the fingerprint of the
+// sources (see watch.Fingerprint) when it started, empty if unknown.
+type runStartedMsg struct {
+	stream     <-chan tea.Msg
+	sourceHash string
+}
+
+// impactIndexLoadedMsg carries an index loaded from disk at startup.
+type impactIndexLoadedMsg struct{ index *impact.Index }
+
+// impactIndexUpdatedMsg is sent when a background index update finishes.
+type impactIndexUpdatedMsg struct {
+	index  *impact.Index
+	result impact.UpdateResult
+	err    error
+}
+
+// toggleImpactFilterMsg signals an intent to show only the tests impacted by changes since the index was built.
+type toggleImpactFilterMsg struct{}
+
+// cycleWatchMsg signals an intent to switch to the next watch mode.
+type cycleWatchMsg struct{}
+
+// watchStartedMsg carries a watcher created in the background for watch generation gen.
+type watchStartedMsg struct {
+	gen     int
+	watcher *watch.Watcher
+	err     error
+}
+
+// watchPollMsg carries the files changed since the last poll of watch generation gen.
+// Files is empty while nothing changed or a burst of saves is still settling.
+type watchPollMsg struct {
+	gen   int
+	files []string
+	err   error
+}
+
+// watchRunMsg starts a test run triggered by file changes. Config is the run to repeat
+// in watchLastRun mode; otherwise packages lists the packages to test.
+type watchRunMsg struct {
+	files    []string
+	config   *runner.TestRunConfig
+	packages []string
+	err      error
+}
+
+// testsRefreshedMsg carries the result of rediscovering tests after file changes.
+type testsRefreshedMsg struct {
+	items []list.Item
+}
+
+// watchMode selects whether and what to rerun when files of the module change.
+type watchMode int
+
+const (
+	watchOff             watchMode = iota // Files are not watched
+	watchLastRun                          // Rerun the last run's scope
+	watchChangedPackages                  // Run the packages containing the changed files
+	watchDependents                       // Run the changed packages and their reverse dependencies
+)
+
+func (w watchMode) String() string {
+	switch w {
+	case watchLastRun:
+		return "rerun last run"
+	case watchChangedPackages:
+		return "changed packages"
+	case watchDependents:
+		return "changed packages and dependents"
+	default:
+		return "off"
+	}
+}
+
+// toggleRaceMsg signals an intent to enable or disable the race detector for subsequent runs.
+type toggleRaceMsg struct{}
+
+// togglePrecompileMsg signals an intent to run subsequent tests with cached test binaries, or
+// to build them with every run again.
+type togglePrecompileMsg struct{}
+
+// cycleCoverageMsg signals an intent to switch to the next coverage collection mode.
+type cycleCoverageMsg struct{}
+
+// coverageScope selects whether and how coverage is collected for test runs.
+type coverageScope int
+
+const (
+	coverageOff    coverageScope = iota // No coverage collection
+	coverageTested                      // Coverage of the tested packages only
+	coverageModule                      // Coverage of every package in the module (-coverpkg=./...)
+)
+
+func (c coverageScope) String() string {
+	switch c {
+	case coverageTested:
+		return "tested packages"
+	case coverageModule:
+		return "whole module (-coverpkg=./...)"
+	default:
+		return "off"
+	}
+}
+
 // displayReportMsg is an internal message to trigger showing the report.
 // It carries the parsed results and the original run configuration.
 type displayReportMsg struct {
 	parsedResults []*parser.PackageResult
 	runConfig     runner.TestRunConfig
+	coverage      *coverage.Report     // Nil unless the run collected coverage
+	binaries      *runner.BinaryTiming // Nil unless the run used precompiled test binaries
+	historyEntry  *history.Entry       // Set when reopening a stored run instead of showing a new one
+	coverageErr   error                // Set when the coverage of the run could not be loaded
+}
+
+// displayDiffMsg carries the comparison of two stored runs, base being the older one.
+type displayDiffMsg struct {
+	diff *history.RunDiff
+	base *history.Entry
+	head *history.Entry
+}
+
+// runSavedMsg is sent when a finished run has been stored in the history.
+type runSavedMsg struct{ err error }
+
+// flakinessLoadedMsg carries the flakiness scores computed from the run history.
+type flakinessLoadedMsg struct {
+	scores map[string]*history.Flakiness
+	err    error
+}
+
+// lastResultsLoadedMsg carries the most recent outcome of every test found in the run history.
+type lastResultsLoadedMsg struct {
+	results map[string]*history.LastResult
+	err     error
+}
+
+// cycleStatusFilterMsg signals an intent to switch to the next status filter of the list.
+type cycleStatusFilterMsg struct{}
+
+// cycleSortOrderMsg signals an intent to switch to the next sort order of the list.
+type cycleSortOrderMsg struct{}
+
+// statusFilter selects which tests the list shows, by their last status.
+type statusFilter int
+
+const (
+	filterAll     statusFilter = iota // Every test
+	filterFailing                     // Tests whose last run failed or timed out
+	filterPassing                     // Tests whose last run passed
+	filterSkipped                     // Tests whose last run was skipped
+	filterNotRun                      // Tests without a known result
+)
+
+func (f statusFilter) String() string {
+	switch f {
+	case filterFailing:
+		return "failing"
+	case filterPassing:
+		return "passing"
+	case filterSkipped:
+		return "skipped"
+	case filterNotRun:
+		return "not run"
+	default:
+		return "all"
+	}
+}
+
+// matches reports whether a test with the given last result passes the filter.
+func (f statusFilter) matches(last *history.LastResult) bool {
+	switch f {
+	case filterFailing:
+		return last != nil && (last.Status == parser.StatusFail || last.Status == parser.StatusTimeout)
+	case filterPassing:
+		return last != nil && last.Status == parser.StatusPass
+	case filterSkipped:
+		return last != nil && last.Status == parser.StatusSkip
+	case filterNotRun:
+		return last == nil
+	default:
+		return true
+	}
+}
+
+// listSortOrder selects the order of the tests in the list.
+type listSortOrder int
+
+const (
+	sortByName     listSortOrder = iota // Discovery order: by package, then file position
+	sortByStatus                        // Failing first, then passing, skipped and not run
+	sortByDuration                      // Slowest first
+	sortByRecent                        // Most recently run first
+)
+
+func (o listSortOrder) String() string {
+	switch o {
+	case sortByStatus:
+		return "status"
+	case sortByDuration:
+		return "duration"
+	case sortByRecent:
+		return "last run"
+	default:
+		return "name"
+	}
+}
+
+// quarantineLoadedMsg carries the quarantine list read from disk.
+type quarantineLoadedMsg struct {
+	list *quarantine.List
+	err  error
+}
+
+// toggleQuarantineMsg signals an intent to quarantine the selected test, or release it.
+type toggleQuarantineMsg struct{}
+
+// quarantineToggledMsg carries the quarantine list saved after a test was quarantined or released.
+type quarantineToggledMsg struct {
+	list        *quarantine.List
+	test, pkg   string
+	quarantined bool // Whether the test is now quarantined
+	err         error
+}
+
+// editQuarantineMsg signals an intent to edit the quarantine file in $EDITOR.
+type editQuarantineMsg struct{}
+
+// execQuarantineEditorMsg carries the quarantine file, once it exists, to open in $EDITOR.
+type execQuarantineEditorMsg struct {
+	path string
 }
 
 // backToListMsg signals to return from the report view to the test list view.
@@ -86,11 +393,20 @@ type MainModel struct {
 	state    appState
 	fatalErr error
 
-	listModel   ListModel
-	reportModel ReportModel
-	spinner     spinner.Model
-	styles      *AppStyles
-	logger      *log.Logger
+	listModel       ListModel
+	reportModel     ReportModel
+	coverageModel   CoverageModel
+	testOutputModel TestOutputModel
+	historyModel    HistoryModel
+	stressModel     StressModel
+	profileModel    ProfileModel
+	treeModel       TreeModel
+	outputModel     OutputModel
+	helpModel       HelpModel
+	queueModel      QueueModel
+	spinner         spinner.Model
+	styles          *AppStyles
+	logger          *log.Logger
 
 	width  int
 	height int
@@ -100,14 +416,123 @@ type MainModel struct {
 	accumulatedJSONOutput bytes.Buffer          // Stores JSON lines from `go test -json`
 	testOutputChan        <-chan tea.Msg        // Channel for messages from test runner goroutine
 	statusMessage         string                // General status message for footer
+	raceEnabled           bool                  // Whether runs are started with `-race`
+	precompile            bool                  // Whether runs reuse test binaries cached by package
+	slowTests             []slowTestWarning     // Tests flagged by the runner's watchdog during the current run
+	jobs                  []runner.JobStatusMsg // Latest status of each job of the current run, if split by package
+	coverageScope         coverageScope         // Coverage collection mode for subsequent runs
+	coverProfile          string                // Cover profile of the last run, removed when a new one replaces it
+	lastCoverage          *coverage.Report      // Coverage of the last run, if collected
+	coverageReturnState   appState              // State to return to when leaving the coverage viewer
+	openCoverageAfterRun  bool                  // Show the coverage viewer instead of the report when the run completes
+
+	// Test impact index (per-test coverage)
+	impactIndex    *impact.Index // Nil until loaded from disk or built
+	impactUpdating bool          // Whether a background index update is in progress
+	impactFilter   bool          // Whether the list only shows tests impacted by changes
+	allItems       []list.Item   // Every discovered test, kept while the list is filtered
+
+	browseState appState // Test browser to return to from other views: the flat list or the tree
+
+	// Split view: the test browser on the left, the output of the selection on the right
+	splitView  bool                 // Whether the split view is shown
+	splitFocus paneFocus            // Pane receiving the keys
+	splitRatio int                  // Width of the test browser, in percent of the window
+	splitKeys  SplitKeyMap          // Keys handled while the split view is shown
+	liveTests  map[string]*liveTest // Tests of the run streaming in the split view, keyed by history.TestKey
+
+	// Keybindings
+	globalKeys  GlobalKeyMap  // Keys handled in every view
+	browserKeys BrowserKeyMap // Keys handled in the test list and the tree
+	keys        *keyRegistry  // Every action of every view, with the keys of the configuration
+	pendingKeys []string      // Keys of the chord being typed, e.g. ["g"] on the way to "g g"
+	showHelp    bool          // Whether the help overlay is shown
+	showQueue   bool          // Whether the run queue panel is shown
+	runQueue    runQueue      // Runs waiting for the run in progress
+
+	config       config.Config // Settings from the config files and flags
+	configErrors []error       // Problems with the settings, shown once the tests are listed
+	profile      string        // Profile of the config used by the next runs, "" for none
+
+	baseRef    string // Git ref that changes are computed against for the "changed" scope
+	modulePath string // Module path, to map the tests in the list to the import paths in results
+
+	// Last known results and how the list shows them
+	lastResults  map[string]*history.LastResult // Most recent outcome of each test, keyed by history.TestKey
+	statusFilter statusFilter                   // Which tests the list shows, by last status
+	sortOrder    listSortOrder                  // Order of the tests in the list
+
+	// Flaky tests
+	flakiness  map[string]*history.Flakiness // Scores from the run history, keyed by history.TestKey
+	quarantine *quarantine.List              // Known-flaky tests whose failures don't fail a run
+
+	// Run history
+	history           *history.Store // Stores every completed run
+	runStartedAt      time.Time      // When the current or last run started
+	runSourceHash     string         // Fingerprint of the sources when the current or last run started
+	reportReturnState appState       // State to return to when leaving the report
+
+	// Stress runs
+	stressStream <-chan tea.Msg // Messages of the running stress run, nil if none
+	stressStop   chan struct{}  // Closed to cancel the running stress run
+
+	// Watch mode
+	watchMode  watchMode      // What to rerun when files change
+	watcher    *watch.Watcher // Nil while watch mode is off
+	watchGen   int            // Incremented whenever watching starts or stops, to drop polls of a stopped watcher
+	runInPlace bool           // A run is streaming while the current view stays on screen (watch and queued runs, runs from the split view)
+}
+
+// Options configures the TUI at startup.
+type Options struct {
+	// BaseRef is the git ref that the "changed" run scope diffs against.
+	// Empty means changes.DefaultBaseRef (uncommitted changes).
+	BaseRef string
+	// Config holds the settings loaded by config.Load. Nil means config.Default().
+	Config *config.Config
+	// ConfigErrors are the problems config.Load found, to be shown to the user.
+	ConfigErrors []error
 }
 
+// slowTestWarning is a test the runner's watchdog reported as exceeding the slow-test threshold.
+type slowTestWarning struct {
+	pkg     string
+	test    string
+	started time.Time // Derived from the reported elapsed time, used to keep the live duration ticking
+}
+
+// paneFocus is the pane of the split view that receives the keys.
+type paneFocus int
+
+const (
+	focusBrowser paneFocus = iota // The test list or tree
+	focusOutput                   // The output pane
+)
+
+// Bounds of the width of the test browser in the split view, in percent of the window.
+const (
+	defaultSplitRatio = 40
+	minSplitRatio     = 20
+	maxSplitRatio     = 80
+	splitRatioStep    = 5
+)
+
+// defaultSlowTestThreshold is how long a single test may run before the live view warns about it.
+const defaultSlowTestThreshold = 30 * time.Second
+
 // NewMainModel creates the initial model for the Bubble Tea program.
-func NewMainModel() (*MainModel, error) {
+func NewMainModel(opts Options) (*MainModel, error) {
 	globalLogger := log.Default()
 	globalLogger.Debug("MainModel: Initializing...")
 
-	styles := DefaultStyles()
+	cfg := config.Default()
+	if opts.Config != nil {
+		cfg = *opts.Config
+	}
+
+	theme, themeErrs := loadTheme(cfg)
+	globalLogger.Debugf("MainModel: Using theme %q", theme.Name)
+	styles := NewStyles(theme)
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
@@ -123,15 +548,58 @@ func NewMainModel() (*MainModel, error) {
 
 	lm := NewListModel(&delegate, globalLogger, styles)
 	rm := NewReportModel(globalLogger, styles)
+	rm.exportDir = cfg.Export.Dir
+	cm := NewCoverageModel(&delegate, globalLogger, styles)
+	cm.buildImpactKey = &lm.keys.BuildImpact // Remapped in place by the key registry
+	hm := NewHistoryModel(&delegate, globalLogger, styles)
+	sm := NewStressModel(globalLogger, styles)
 
 	m := &MainModel{
-		state:         stateInitializing,
-		spinner:       s,
-		listModel:     lm,
-		reportModel:   rm,
-		styles:        styles,
-		logger:        globalLogger,
-		statusMessage: "Initializing...",
+		state:           stateInitializing,
+		spinner:         s,
+		listModel:       lm,
+		reportModel:     rm,
+		coverageModel:   cm,
+		testOutputModel: NewTestOutputModel(&delegate, globalLogger, styles),
+		historyModel:    hm,
+		stressModel:     sm,
+		profileModel:    NewProfileModel(&delegate, globalLogger, styles),
+		treeModel:       NewTreeModel(globalLogger, styles),
+		outputModel:     NewOutputModel(globalLogger, styles),
+		helpModel:       NewHelpModel(globalLogger, styles),
+		queueModel:      NewQueueModel(&delegate, globalLogger, styles),
+		globalKeys:      DefaultGlobalKeyMap(),
+		browserKeys:     DefaultBrowserKeyMap(),
+		browseState:     stateTestList,
+		splitRatio:      defaultSplitRatio,
+		splitKeys:       DefaultSplitKeyMap(),
+		history:         history.Open(".", cfg.History.Retention()),
+		styles:          styles,
+		logger:          globalLogger,
+		statusMessage:   "Initializing...",
+		baseRef:         opts.BaseRef,
+		config:          cfg,
+		profile:         cfg.Profile,
+		precompile:      cfg.Test.Precompile,
+	}
+
+	m.keys = newKeyRegistry(m)
+	m.configErrors = slices.Concat(opts.ConfigErrors, themeErrs, configureKeys(m.keys, cfg))
+	for _, err := range m.configErrors {
+		globalLogger.Warnf("MainModel: Config: %v", err)
+	}
+	listHelpKeys := m.listModel.list.AdditionalShortHelpKeys
+	m.listModel.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return append(listHelpKeys(), m.splitKeys.ToggleSplit)
+	}
+
+	if m.baseRef == "" {
+		m.baseRef = changes.DefaultBaseRef
+	}
+	if modulePath, err := finder.ModulePath("."); err != nil {
+		globalLogger.Warnf("MainModel: Could not read module path, flaky test badges are disabled: %v", err)
+	} else {
+		m.modulePath = modulePath
 	}
 	return m, nil
 }
@@ -157,20 +625,45 @@ func (m *MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		updateOnResize(m, msg)
 		return m, nil
 	case tea.KeyMsg:
-		if key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))) {
-			m.logger.Info("MainModel: Ctrl+C pressed, quitting.")
-			return m, tea.Quit
-		}
-		if msg.String() == "q" {
-			if m.state == stateTestList && m.listModel.list.FilterState() != list.Filtering {
-				m.logger.Info("MainModel: 'q' pressed in TestList, quitting.")
-				return m, tea.Quit
-			}
+		if key.Matches(msg, m.globalKeys.Quit) {
+			m.logger.Infof("MainModel: %q pressed, quitting.", msg.String())
+			return m, updateOnQuit(m)
 		}
 		if m.state == stateError && msg.String() != "" {
 			m.logger.Info("MainModel: Key pressed in Error state, quitting.")
-			return m, tea.Quit
+			return m, updateOnQuit(m)
 		}
+		if m.showHelp {
+			return m, updateOnHelpKeys(m, msg)
+		}
+		if !typing(m) {
+			if chordCmd, handled := updateOnChord(m, msg); handled {
+				return m, chordCmd
+			}
+			if key.Matches(msg, m.globalKeys.Help) {
+				updateOnToggleHelp(m)
+				return m, nil
+			}
+			if key.Matches(msg, m.globalKeys.Queue) {
+				return m, updateOnToggleQueue(m)
+			}
+		}
+		if m.showQueue {
+			return m, updateOnQueueKeys(m, msg)
+		}
+		if !typing(m) {
+			browsing := m.state == stateTestList || m.state == stateTreeView
+			if browsing && key.Matches(msg, m.browserKeys.Quit) {
+				m.logger.Infof("MainModel: %q pressed in the test browser, quitting.", msg.String())
+				return m, updateOnQuit(m)
+			}
+		}
+		if splitCmd, handled := updateOnSplitKeys(m, msg); handled {
+			return m, splitCmd
+		}
+	case closeHelpMsg:
+		updateOnToggleHelp(m)
+		return m, nil
 	case spinner.TickMsg:
 		if m.state == stateInitializing || m.state == stateRunningTests {
 			m.spinner, cmd = m.spinner.Update(msg)
@@ -179,14 +672,48 @@ func (m *MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case testsFoundMsg:
 		m.logger.Infof("MainModel: testsFoundMsg received with %d items.", len(msg.items))
 		m.state = stateTestList
-		m.statusMessage = "Select a test or action (a: all, p: package, enter: selected). Press '/' to filter."
+		m.statusMessage = fmt.Sprintf("%s Press %s to filter, %s for help.", browserHint(m), m.listModel.list.KeyMap.Filter.Help().Key, m.globalKeys.Help.Help().Key)
 		if len(msg.items) == 0 {
-			m.statusMessage = "No tests found. Press 'q' to quit or Ctrl+C."
+			m.statusMessage = fmt.Sprintf("No tests found. Press %s to quit.", m.browserKeys.Quit.Help().Key)
 		}
-		cmd = m.listModel.SetItems(msg.items)
-		cmds = append(cmds, cmd)
+		if len(m.configErrors) > 0 {
+			// Shown once, in place of the usual hint; every problem is in the log as well.
+			m.statusMessage = fmt.Sprintf("Config error: %v", m.configErrors[0])
+			if len(m.configErrors) > 1 {
+				m.statusMessage += fmt.Sprintf(" (and %d more problems, see the log)", len(m.configErrors)-1)
+			}
+		}
+		m.allItems = decorateItems(m, msg.items)
+		m.impactFilter = false
+		cmd = applyListView(m)
+		cmds = append(cmds, cmd, loadImpactIndexCmd(), loadQuarantineCmd(), computeFlakinessCmd(m.history), loadLastResultsCmd(m.history))
 
 		return m, tea.Batch(cmds...)
+	case impactIndexLoadedMsg:
+		// The index is only updated on request: an update runs every stale test with coverage,
+		// and re-baselines the files changed since, which the impacted tests filter relies on.
+		m.logger.Infof("MainModel: Impact index loaded with %d tests, %d files changed since.", len(msg.index.Tests), len(msg.index.ChangedFiles()))
+		m.impactIndex = msg.index
+		m.coverageModel.SetImpactIndex(msg.index)
+
+		return m, nil
+	case triggerImpactUpdateMsg:
+		return m, updateOnImpactUpdate(m)
+	case impactIndexUpdatedMsg:
+		m.impactUpdating = false
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Impact index update failed: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Error: impact index update failed: %v", msg.err)
+			return m, nil
+		}
+		m.impactIndex = msg.index
+		m.coverageModel.SetImpactIndex(msg.index)
+		m.statusMessage = fmt.Sprintf("Impact index updated: %d tests run, %d up to date, %d removed, %d failed.",
+			msg.result.Ran, msg.result.Reused, msg.result.Removed, msg.result.Failed)
+
+		return m, nil
+	case toggleImpactFilterMsg:
+		return m, updateOnImpactFilter(m)
 	case testsLoadFailedMsg:
 		m.logger.Errorf("MainModel: testsLoadFailedMsg: %v", msg.err)
 		m.fatalErr = msg.err
@@ -194,43 +721,430 @@ func (m *MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.statusMessage = fmt.Sprintf("Error: %v. Press any key to quit.", msg.err)
 
 		return m, nil
-	case triggerRunAllTestsMsg, triggerRunPackageTestsMsg, triggerRunSelectedTestMsg:
-		runCmd, err := updateOnRunTests(m, msg, cmd)
+	case triggerRunAllTestsMsg, triggerRunPackageTestsMsg, triggerRunSelectedTestMsg, triggerCoverSelectedTestMsg, runTreeNodeMsg:
+		runCmd, err := updateOnRunTests(m, msg)
+		if err != nil {
+			return m, nil
+		}
+
+		return m, runCmd
+	case triggerRunChangedTestsMsg:
+		m.logger.Infof("MainModel: Resolving packages affected by changes against %s.", m.baseRef)
+		m.statusMessage = fmt.Sprintf("Finding packages affected by changes against %s...", m.baseRef)
+
+		return m, resolveChangesCmd(m.baseRef)
+	case changesResolvedMsg:
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not resolve changed packages: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Error: could not determine changed packages: %v", msg.err)
+			return m, nil
+		}
+		if len(msg.result.Affected) == 0 {
+			m.statusMessage = fmt.Sprintf("No packages affected by changes against %s (%d files changed).", msg.result.BaseRef, len(msg.result.Files))
+			return m, nil
+		}
+		runCmd, err := updateOnRunTests(m, msg)
 		if err != nil {
 			return m, nil
 		}
-		cmds = append(cmds, runCmd, m.spinner.Tick)
+
+		return m, runCmd
+	case cycleWatchMsg:
+		return m, updateOnCycleWatch(m)
+	case watchStartedMsg:
+		if msg.gen != m.watchGen {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not start watching: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Error: could not start watch mode: %v", msg.err)
+			m.watchMode = watchOff
+			return m, nil
+		}
+		m.watcher = msg.watcher
+
+		return m, pollWatchCmd(m.watcher, m.watchGen)
+	case watchPollMsg:
+		if msg.gen != m.watchGen || m.watcher == nil {
+			return m, nil // Poll of a watcher that was stopped
+		}
+		if msg.err != nil {
+			m.logger.Warnf("MainModel: Watch poll failed: %v", msg.err)
+		}
+		cmds = append(cmds, pollWatchCmd(m.watcher, m.watchGen))
+		if len(msg.files) > 0 {
+			cmds = append(cmds, refreshTestsCmd(m.config.Finder.ExcludeDirs), updateOnWatchChanges(m, msg.files))
+		}
 
 		return m, tea.Batch(cmds...)
-	case runner.StreamMsg:
-		streamMessage := msg
-		m.logger.Debug("MainModel: Received StreamMsg from runner.")
-		m.testOutputChan = streamMessage.Stream
+	case watchRunMsg:
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not determine packages for changed files: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Watch: could not determine packages to test: %v", msg.err)
+			return m, nil
+		}
+		if msg.config == nil && len(msg.packages) == 0 {
+			m.statusMessage = fmt.Sprintf("Watch: %d files changed, no tests affected.", len(msg.files))
+			return m, nil
+		}
+		runCmd, err := updateOnRunTests(m, msg)
+		if err != nil {
+			return m, nil
+		}
+
+		return m, runCmd
+	case testsRefreshedMsg:
+		return m, updateOnTestsRefreshed(m, msg.items)
+	case flakinessLoadedMsg:
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not compute flakiness from history: %v", msg.err)
+			return m, nil
+		}
+		m.flakiness = msg.scores
+
+		return m, refreshBadges(m)
+	case quarantineLoadedMsg:
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not load quarantine file: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+			return m, nil
+		}
+		m.quarantine = msg.list
+		m.reportModel.SetQuarantine(msg.list)
+
+		return m, refreshBadges(m)
+	case lastResultsLoadedMsg:
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not load last results from history: %v", msg.err)
+			return m, nil
+		}
+		// Results of runs completed since the history was read are newer.
+		for key, last := range m.lastResults {
+			if prev, ok := msg.results[key]; !ok || last.RunAt.After(prev.RunAt) {
+				msg.results[key] = last
+			}
+		}
+		m.lastResults = msg.results
+
+		return m, refreshBadges(m)
+	case cycleStatusFilterMsg:
+		m.statusFilter = (m.statusFilter + 1) % (filterNotRun + 1)
+		m.logger.Infof("MainModel: Status filter set to %s", m.statusFilter)
+		cmd = applyListView(m)
+		m.statusMessage = fmt.Sprintf("Showing %s tests (%d). Press %s for the next filter.", m.statusFilter, len(m.listModel.list.Items()), m.listModel.keys.StatusFilter.Help().Key)
+
+		return m, cmd
+	case cycleSortOrderMsg:
+		m.sortOrder = (m.sortOrder + 1) % (sortByRecent + 1)
+		m.logger.Infof("MainModel: Sort order set to %s", m.sortOrder)
+		m.statusMessage = fmt.Sprintf("Sorting tests by %s.", m.sortOrder)
+
+		return m, applyListView(m)
+	case toggleTreeViewMsg:
+		if m.browseState == stateTreeView {
+			m.logger.Info("MainModel: Switching to the flat test list.")
+			m.browseState = stateTestList
+		} else {
+			m.logger.Info("MainModel: Switching to the test tree.")
+			m.browseState = stateTreeView
+		}
+		returnToBrowser(m)
+
+		return m, nil
+	case toggleQuarantineMsg:
+		return m, updateOnToggleQuarantine(m)
+	case quarantineToggledMsg:
+		return m, updateOnQuarantineToggled(m, msg)
+	case editQuarantineMsg:
+		return m, editQuarantineCmd()
+	case execQuarantineEditorMsg:
+		return m, execQuarantineEditorCmd(msg.path)
+	case toggleRaceMsg:
+		m.raceEnabled = !m.raceEnabled
+		m.logger.Infof("MainModel: Race detector toggled. Enabled: %t", m.raceEnabled)
+		if m.raceEnabled {
+			m.statusMessage = "Race detector enabled: tests will run with -race."
+		} else {
+			m.statusMessage = "Race detector disabled."
+		}
+
+		return m, nil
+	case togglePrecompileMsg:
+		m.precompile = !m.precompile
+		m.logger.Infof("MainModel: Test binary cache toggled. Enabled: %t", m.precompile)
+		if m.precompile {
+			m.statusMessage = fmt.Sprintf("Test binaries are cached in %s and reused until the code changes.", runner.BinaryCacheDir)
+		} else {
+			m.statusMessage = "Test binaries are built with every run."
+		}
+
+		return m, nil
+	case cycleCoverageMsg:
+		m.coverageScope = (m.coverageScope + 1) % (coverageModule + 1)
+		m.logger.Infof("MainModel: Coverage collection set to %s", m.coverageScope)
+		m.statusMessage = fmt.Sprintf("Coverage: %s.", m.coverageScope)
+
+		return m, nil
+	case runSavedMsg:
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not save run to history: %v", msg.err)
+			return m, nil
+		}
+		// Flakiness is computed from the stored runs, now including this one.
+		return m, computeFlakinessCmd(m.history)
+	case runStartedMsg:
+		m.logger.Debug("MainModel: Run started.")
+		m.runSourceHash = msg.sourceHash
+		m.testOutputChan = msg.stream
 		cmds = append(cmds, runner.WaitForStreamMsgCmd(m.testOutputChan))
 	case runner.TestOutputLineMsg:
 		m.logger.Debugf("MainModel: Received TestOutputLineMsg.")
 		m.accumulatedJSONOutput.WriteString(msg.Line + "\n")
+		if m.splitView && m.runInPlace {
+			updateOnLiveEvent(m, msg.Line)
+		}
+		if m.testOutputChan != nil {
+			cmds = append(cmds, runner.WaitForStreamMsgCmd(m.testOutputChan))
+		}
+	case runner.SlowTestMsg:
+		m.logger.Warnf("MainModel: Slow test reported: %s/%s (%s)", msg.Package, msg.Test, msg.Elapsed)
+		m.slowTests = append(m.slowTests, slowTestWarning{
+			pkg:     msg.Package,
+			test:    msg.Test,
+			started: time.Now().Add(-msg.Elapsed),
+		})
+		if m.testOutputChan != nil {
+			cmds = append(cmds, runner.WaitForStreamMsgCmd(m.testOutputChan))
+		}
+	case runner.JobStatusMsg:
+		updateOnJobStatus(m, msg)
 		if m.testOutputChan != nil {
 			cmds = append(cmds, runner.WaitForStreamMsgCmd(m.testOutputChan))
 		}
 	case runner.TestRunCompleteMsg:
 		cmds = append(cmds, updateOnTestsComplete(m, msg))
 	case displayReportMsg:
+		// Started once this update settled the view, so that a queued run keeps it on screen.
+		cmds = append(cmds, func() tea.Msg { return startQueuedRunMsg{} })
+
+		if m.splitView && msg.historyEntry == nil && !m.openCoverageAfterRun && (m.state == stateTestList || m.state == stateTreeView) {
+			// The output pane already shows the results of the selection; stay in the split view.
+			m.logger.Info("MainModel: displayReportMsg received in split view. Staying in the test browser.")
+			m.lastCoverage = msg.coverage
+			m.statusMessage = fmt.Sprintf("Run complete: %s. Open the full report from the run history (%s).", summarizeResults(msg.parsedResults), m.listModel.keys.History.Help().Key)
+			if msg.coverageErr != nil {
+				m.statusMessage = fmt.Sprintf("Error: could not load coverage: %v", msg.coverageErr)
+			}
+			return m, tea.Batch(cmds...)
+		}
+
 		m.logger.Info("MainModel: displayReportMsg received. Transitioning to ReportView.")
 		m.state = stateReportView
-		cmd = m.reportModel.SetContent(msg.parsedResults, msg.runConfig) // reportModel is value type
+		cmd = m.reportModel.SetContent(msg.parsedResults, msg.runConfig, msg.coverage, msg.binaries) // reportModel is value type
 		m.statusMessage = m.reportModel.HelpView()
+		m.reportReturnState = m.browseState
+		if msg.historyEntry != nil {
+			m.reportReturnState = stateHistoryView
+			m.statusMessage = fmt.Sprintf("Run of %s. %s", msg.historyEntry.StartedAt.Format("2006-01-02 15:04:05"), m.statusMessage)
+		} else if m.watchMode != watchOff {
+			m.statusMessage = fmt.Sprintf("Watching (%s), updated %s. %s", m.watchMode, time.Now().Format("15:04:05"), m.statusMessage)
+		}
+		m.lastCoverage = msg.coverage
 		cmds = append(cmds, cmd)
 
+		if m.openCoverageAfterRun {
+			m.openCoverageAfterRun = false
+			if msg.coverage != nil {
+				m.logger.Info("MainModel: Showing what the test covers in the coverage viewer.")
+				m.state = stateCoverageView
+				m.coverageReturnState = stateReportView
+				cmds = append(cmds, m.coverageModel.SetReport(msg.coverage, fmt.Sprintf("Covered by %s", msg.runConfig.TestName), true))
+				m.statusMessage = m.coverageModel.HelpView()
+			}
+		}
+		if msg.coverageErr != nil {
+			m.statusMessage = fmt.Sprintf("Error: could not load coverage: %v", msg.coverageErr)
+		}
+
 		return m, tea.Batch(cmds...)
+	case openCoverageViewMsg:
+		if m.lastCoverage == nil {
+			m.statusMessage = fmt.Sprintf("No coverage was collected for this run. Press %s in the test list to enable coverage.", m.listModel.keys.ToggleCoverage.Help().Key)
+			return m, nil
+		}
+		m.logger.Info("MainModel: openCoverageViewMsg received. Transitioning to CoverageView.")
+		m.coverageReturnState = m.state
+		m.state = stateCoverageView
+		cmd = m.coverageModel.SetReport(m.lastCoverage, "Coverage", false)
+		m.statusMessage = m.coverageModel.HelpView()
+
+		return m, cmd
+	case backFromCoverageMsg:
+		m.logger.Info("MainModel: backFromCoverageMsg received.")
+		m.state = m.coverageReturnState
+		if m.state == stateReportView {
+			m.statusMessage = m.reportModel.HelpView()
+		} else {
+			m.statusMessage = browserHint(m)
+		}
+
+		return m, nil
+	case openTestOutputMsg:
+		m.logger.Info("MainModel: openTestOutputMsg received. Transitioning to TestOutputView.")
+		m.state = stateTestOutputView
+		cmd = m.testOutputModel.SetResults(m.reportModel.results, "Test Output — "+m.reportModel.testRunScope, msg.pkg, msg.test)
+		m.statusMessage = m.testOutputModel.HelpView()
+
+		return m, cmd
+	case backFromTestOutputMsg:
+		m.logger.Info("MainModel: backFromTestOutputMsg received. Returning to ReportView.")
+		m.state = stateReportView
+		m.statusMessage = m.reportModel.HelpView()
+
+		return m, nil
 	case backToListMsg:
-		m.logger.Info("MainModel: backToListMsg received. Transitioning to TestList state.")
-		m.state = stateTestList
 		m.reportModel.Reset()
+		if m.reportReturnState == stateHistoryView {
+			m.logger.Info("MainModel: backToListMsg received. Returning to HistoryView.")
+			m.state = stateHistoryView
+			m.statusMessage = m.historyModel.HelpView()
+			return m, nil
+		}
+		m.logger.Info("MainModel: backToListMsg received. Transitioning to the test browser.")
 		m.listModel.list.FilterInput.SetValue("")
-		m.statusMessage = "Select a test or action (a: all, p: package, enter: selected)."
+		returnToBrowser(m)
+
+		return m, tea.Batch(cmds...)
+	case openHistoryViewMsg:
+		return m, loadHistoryCmd(m.history)
+	case historyLoadedMsg:
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not load run history: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Error: could not load run history: %v", msg.err)
+			return m, nil
+		}
+		if len(msg.entries) == 0 {
+			m.statusMessage = "No runs in history yet."
+			return m, nil
+		}
+		m.logger.Infof("MainModel: Showing %d runs from history.", len(msg.entries))
+		m.state = stateHistoryView
+		m.statusMessage = m.historyModel.HelpView()
+
+		return m, m.historyModel.SetEntries(msg.entries)
+	case openHistoryRunMsg:
+		m.statusMessage = "Loading run..."
+		return m, openHistoryRunCmd(m.history, msg.id)
+	case triggerStressMsg:
+		return m, updateOnStressSetup(m, msg)
+	case startStressMsg:
+		return m, updateOnStressStart(m, msg.config)
+	case stressStreamMsg:
+		m.stressStream = msg.stream
+		return m, runner.WaitForStreamMsgCmd(m.stressStream)
+	case stressIterationMsg:
+		m.stressModel.AddIteration(msg.iteration)
+		if m.stressStream != nil {
+			cmds = append(cmds, runner.WaitForStreamMsgCmd(m.stressStream))
+		}
 
 		return m, tea.Batch(cmds...)
+	case stressDoneMsg:
+		m.logger.Infof("MainModel: Stress run ended: %s", msg.reason)
+		m.stressStream = nil
+		m.stressStop = nil
+		m.stressModel.Finish(msg.reason)
+		if m.state == stateStressView {
+			m.statusMessage = m.stressModel.HelpView()
+		}
+
+		return m, func() tea.Msg { return startQueuedRunMsg{} }
+	case cancelStressMsg:
+		if m.stressStop != nil {
+			close(m.stressStop)
+			m.stressStop = nil
+		}
+		m.statusMessage = "Stopping the stress run..."
+
+		return m, nil
+	case backFromStressMsg:
+		m.logger.Info("MainModel: backFromStressMsg received. Transitioning to the test browser.")
+		returnToBrowser(m)
+
+		return m, nil
+	case compareRunsMsg:
+		m.statusMessage = "Comparing runs..."
+		return m, compareRunsCmd(m.history, msg.firstID, msg.secondID)
+	case displayDiffMsg:
+		m.logger.Infof("MainModel: Showing comparison of runs %s and %s.", msg.base.ID, msg.head.ID)
+		m.state = stateReportView
+		m.reportReturnState = stateHistoryView
+		m.lastCoverage = nil
+		m.reportModel.SetDiffContent(msg.diff, msg.base, msg.head)
+		m.statusMessage = m.reportModel.HelpView()
+
+		return m, nil
+	case openProfilePickerMsg:
+		if len(m.config.Profiles) == 0 {
+			m.statusMessage = "No profiles defined. Add them to the \"profiles\" of the config file."
+			return m, nil
+		}
+		m.logger.Infof("MainModel: Showing %d profiles.", len(m.config.Profiles))
+		m.state = stateProfileView
+		m.statusMessage = m.profileModel.HelpView()
+
+		return m, m.profileModel.SetProfiles(m.config.Profiles, m.profile)
+	case selectProfileMsg:
+		m.logger.Infof("MainModel: Using profile %q for the next runs.", msg.name)
+		m.profile = msg.name
+		returnToBrowser(m)
+		m.statusMessage = "Runs use no profile."
+		if m.profile != "" {
+			m.statusMessage = fmt.Sprintf("Runs use the %q profile.", m.profile)
+		}
+
+		return m, nil
+	case backFromProfilesMsg:
+		m.logger.Info("MainModel: backFromProfilesMsg received. Transitioning to the test browser.")
+		returnToBrowser(m)
+
+		return m, nil
+	case startQueuedRunMsg:
+		return m, updateOnStartQueuedRun(m)
+	case moveQueuedRunMsg:
+		m.runQueue.move(msg.id, msg.delta)
+
+		return m, m.queueModel.SetRuns(m.runQueue.runs, msg.id)
+	case cancelQueuedRunMsg:
+		if i := m.runQueue.index(msg.id); i >= 0 {
+			m.logger.Infof("MainModel: Cancelling queued run %q.", describeRunScope(m.runQueue.runs[i].config))
+			m.runQueue.remove(func(run testRun) bool { return run.id == msg.id })
+		}
+
+		return m, m.queueModel.SetRuns(m.runQueue.runs, -1)
+	case cancelQueueMsg:
+		n := m.runQueue.remove(func(testRun) bool { return true })
+		m.logger.Infof("MainModel: Cancelled %d queued runs.", n)
+
+		return m, m.queueModel.SetRuns(m.runQueue.runs, -1)
+	case closeQueueMsg:
+		updateOnToggleQueue(m)
+		return m, nil
+	case backFromHistoryMsg:
+		m.logger.Info("MainModel: backFromHistoryMsg received. Transitioning to the test browser.")
+		returnToBrowser(m)
+
+		return m, nil
+	case reportExportedMsg:
+		if msg.err != nil {
+			m.logger.Errorf("MainModel: Could not export the report: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Error: could not export the report: %v", msg.err)
+			return m, nil
+		}
+		m.logger.Infof("MainModel: Report exported to %s.", msg.path)
+		m.statusMessage = fmt.Sprintf("Report exported to %s.", msg.path)
+
+		return m, nil
 	case errorMsg:
 		m.logger.Errorf("MainModel: Generic errorMsg received: %v", msg.err)
 		m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
@@ -260,7 +1174,9 @@ func (m *MainModel) View() string {
 		spin := m.spinner.View() + " Discovering Go tests, please wait..."
 		mainContentView = loadingStyle.Render(spin)
 	case stateTestList:
-		mainContentView = m.listModel.View()
+		mainContentView = m.withOutputPane(m.listModel.View())
+	case stateTreeView:
+		mainContentView = m.withOutputPane(m.treeModel.View())
 	case stateRunningTests:
 		loadingStyle := m.styles.Loading.Width(m.width).Height(m.height - lipgloss.Height(m.footerView())).Align(lipgloss.Center)
 
@@ -273,6 +1189,10 @@ func (m *MainModel) View() string {
 				runDesc = fmt.Sprintf("package %s", filepath.Base(m.currentTestRunConfig.PackagePath))
 			case runner.SingleTest:
 				runDesc = fmt.Sprintf("test %s", m.currentTestRunConfig.TestName)
+			case runner.ChangedTests:
+				runDesc = fmt.Sprintf("%d packages affected by changes", len(m.currentTestRunConfig.Packages))
+			case runner.SelectedTests:
+				runDesc = fmt.Sprintf("%d tests in package %s", len(m.currentTestRunConfig.TestNames), filepath.Base(m.currentTestRunConfig.PackagePath))
 			default:
 				runDesc = "tests"
 			}
@@ -281,22 +1201,130 @@ func (m *MainModel) View() string {
 		}
 
 		content := fmt.Sprintf("%s Running %s...\n\n(Ctrl+C to attempt to quit)", m.spinner.View(), runDesc)
+		if n := len(m.runQueue.runs); n > 0 {
+			content += fmt.Sprintf("\n%d runs queued, %s to manage them", n, m.globalKeys.Queue.Help().Key)
+		}
+		if len(m.jobs) > 0 {
+			content += "\n\n" + m.jobsView(m.height-lipgloss.Height(m.footerView())-lipgloss.Height(content)-len(m.slowTests)-4)
+		}
+		if len(m.slowTests) > 0 {
+			content += "\n\n" + m.slowTestsView()
+		}
 		mainContentView = loadingStyle.Render(content)
 	case stateReportView:
 		mainContentView = m.reportModel.View()
+	case stateCoverageView:
+		mainContentView = m.coverageModel.View()
+	case stateTestOutputView:
+		mainContentView = m.testOutputModel.View()
+	case stateHistoryView:
+		mainContentView = m.historyModel.View()
+	case stateStressView:
+		mainContentView = m.stressModel.View()
+	case stateProfileView:
+		mainContentView = m.profileModel.View()
 	default:
 		mainContentView = m.styles.Error.Render("Unknown application state. This is a bug.")
 	}
 
+	if m.showQueue {
+		mainContentView = m.queueModel.View() // Full screen, over the view of the run in progress
+	}
+	if m.showHelp {
+		mainContentView = m.helpModel.View() // Full screen, in place of the view it describes
+	}
+
 	return lipgloss.JoinVertical(lipgloss.Left,
 		mainContentView,
 		m.footerView(),
 	)
 }
 
+// withOutputPane places the output pane to the right of the test browser while the split view is shown.
+func (m *MainModel) withOutputPane(browser string) string {
+	if !m.splitView {
+		return browser
+	}
+	height := m.outputModel.height
+	divider := m.styles.SplitDivider
+	if m.splitFocus == focusOutput {
+		divider = m.styles.SplitDividerFocus
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		clipToSize(browser, m.listModel.width, height),
+		divider.Render(strings.TrimSuffix(strings.Repeat("│\n", height), "\n")),
+		m.outputModel.View(),
+	)
+}
+
+// clipToSize cuts a view to the given size and pads it to fill it, so that panes line up.
+func clipToSize(view string, width, height int) string {
+	clipped := lipgloss.NewStyle().MaxWidth(width).MaxHeight(height).Render(view)
+	return lipgloss.NewStyle().Width(width).Height(height).Render(clipped)
+}
+
+// slowTestsView renders the watchdog warnings for the running view.
+func (m *MainModel) slowTestsView() string {
+	lines := []string{m.styles.StatusTimeout.Render(fmt.Sprintf("%s Slow tests (over %s):", m.styles.TimeoutIcon, defaultSlowTestThreshold))}
+	for _, w := range m.slowTests {
+		elapsed := time.Since(w.started).Round(time.Second)
+		lines = append(lines, fmt.Sprintf("  %s (%s) running for %s", w.test, w.pkg, elapsed))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jobsView renders the status of the jobs of a run split by package in at most maxLines lines.
+// When they don't all fit, the running and failed jobs are listed first.
+func (m *MainModel) jobsView(maxLines int) string {
+	maxLines = max(maxLines, 3)
+	counts := make(map[runner.JobStatus]int)
+	for _, job := range m.jobs {
+		counts[job.Status]++
+	}
+	lines := []string{fmt.Sprintf("Packages: %d/%d done, %d running, %d failed",
+		counts[runner.JobPassed]+counts[runner.JobFailed], len(m.jobs), counts[runner.JobRunning], counts[runner.JobFailed])}
+
+	jobs := m.jobs
+	if len(jobs) > maxLines-1 {
+		jobs = nil
+		for _, job := range m.jobs {
+			if job.Status == runner.JobRunning || job.Status == runner.JobFailed {
+				jobs = append(jobs, job)
+			}
+		}
+	}
+	for i, job := range jobs {
+		if i == maxLines-2 && len(jobs) > maxLines-1 {
+			lines = append(lines, fmt.Sprintf("  … and %d more", len(jobs)-i))
+			break
+		}
+		var line string
+		switch job.Status {
+		case runner.JobQueued:
+			line = m.styles.StatusUnknown.Render(fmt.Sprintf("%s %s queued", m.styles.UnknownIcon, job.Package))
+		case runner.JobRunning:
+			line = fmt.Sprintf("%s %s", m.spinner.View(), job.Package)
+		case runner.JobPassed:
+			line = m.styles.StatusPass.Render(fmt.Sprintf("%s %s (%s)", m.styles.PassIcon, job.Package, job.Elapsed.Round(time.Millisecond)))
+		case runner.JobFailed:
+			line = m.styles.StatusFail.Render(fmt.Sprintf("%s %s (%s)", m.styles.FailIcon, job.Package, job.Elapsed.Round(time.Millisecond)))
+		}
+		lines = append(lines, "  "+line)
+	}
+	return lipgloss.NewStyle().Align(lipgloss.Left).Render(strings.Join(lines, "\n"))
+}
+
 // footerView renders the status bar/help line at the bottom.
 func (m *MainModel) footerView() string {
 	helpText := m.statusMessage
+	switch {
+	case len(m.pendingKeys) > 0:
+		helpText = strings.Join(m.pendingKeys, " ") + " …"
+	case m.showHelp:
+		helpText = m.helpModel.HelpView()
+	case m.showQueue:
+		helpText = m.queueModel.HelpView()
+	}
 
 	return m.styles.FooterStatus.Width(m.width).Render(helpText)
 }
@@ -325,6 +1353,20 @@ func routeByState(m *MainModel, msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd
 
 	currentFocusedModelName := "None"
 
+	browsing := m.state == stateTestList || m.state == stateTreeView
+	if _, isKey := msg.(tea.KeyMsg); isKey && browsing && m.splitView && m.splitFocus == focusOutput {
+		updatedModel, childCmd = m.outputModel.Update(msg)
+
+		if um, ok := updatedModel.(OutputModel); ok {
+			m.outputModel = um
+		} else {
+			m.logger.Errorf("MainModel: OutputModel.Update returned unexpected type %T", updatedModel)
+		}
+		m.logger.Debugf("MainModel: Delegated msg %T to OutputModel", msg)
+
+		return m, tea.Batch(append(cmds, childCmd)...)
+	}
+
 	switch m.state {
 	case stateTestList:
 		updatedModel, childCmd = m.listModel.Update(msg)
@@ -336,6 +1378,16 @@ func routeByState(m *MainModel, msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd
 		}
 
 		currentFocusedModelName = "ListModel"
+	case stateTreeView:
+		updatedModel, childCmd = m.treeModel.Update(msg)
+
+		if um, ok := updatedModel.(TreeModel); ok {
+			m.treeModel = um
+		} else {
+			m.logger.Errorf("MainModel: TreeModel.Update returned unexpected type %T", updatedModel)
+		}
+
+		currentFocusedModelName = "TreeModel"
 	case stateReportView:
 		updatedModel, childCmd = m.reportModel.Update(msg)
 
@@ -346,11 +1398,68 @@ func routeByState(m *MainModel, msg tea.Msg, cmds []tea.Cmd) (tea.Model, tea.Cmd
 		}
 
 		currentFocusedModelName = "ReportModel"
+	case stateHistoryView:
+		updatedModel, childCmd = m.historyModel.Update(msg)
+
+		if um, ok := updatedModel.(HistoryModel); ok {
+			m.historyModel = um
+		} else {
+			m.logger.Errorf("MainModel: HistoryModel.Update returned unexpected type %T", updatedModel)
+		}
+
+		currentFocusedModelName = "HistoryModel"
+	case stateProfileView:
+		updatedModel, childCmd = m.profileModel.Update(msg)
+
+		if um, ok := updatedModel.(ProfileModel); ok {
+			m.profileModel = um
+		} else {
+			m.logger.Errorf("MainModel: ProfileModel.Update returned unexpected type %T", updatedModel)
+		}
+
+		currentFocusedModelName = "ProfileModel"
+	case stateStressView:
+		updatedModel, childCmd = m.stressModel.Update(msg)
+
+		if um, ok := updatedModel.(StressModel); ok {
+			m.stressModel = um
+			m.statusMessage = m.stressModel.HelpView() // Help depends on the view's mode
+		} else {
+			m.logger.Errorf("MainModel: StressModel.Update returned unexpected type %T", updatedModel)
+		}
+
+		currentFocusedModelName = "StressModel"
+	case stateCoverageView:
+		updatedModel, childCmd = m.coverageModel.Update(msg)
+
+		if um, ok := updatedModel.(CoverageModel); ok {
+			m.coverageModel = um
+			m.statusMessage = m.coverageModel.HelpView() // Help depends on the viewer's mode
+		} else {
+			m.logger.Errorf("MainModel: CoverageModel.Update returned unexpected type %T", updatedModel)
+		}
+
+		currentFocusedModelName = "CoverageModel"
+	case stateTestOutputView:
+		updatedModel, childCmd = m.testOutputModel.Update(msg)
+
+		if um, ok := updatedModel.(TestOutputModel); ok {
+			m.testOutputModel = um
+			m.statusMessage = m.testOutputModel.HelpView() // Help depends on the viewer's mode
+		} else {
+			m.logger.Errorf("MainModel: TestOutputModel.Update returned unexpected type %T", updatedModel)
+		}
+
+		currentFocusedModelName = "TestOutputModel"
 	case stateInitializing, stateRunningTests, stateError:
 		// No child model input or handled globally/earlier in switch
 		return m, tea.Batch(cmds...) // Batch any commands accumulated so far (e.g. spinner)
 	}
 
+	if _, isKey := msg.(tea.KeyMsg); isKey && browsing && m.splitView {
+		refreshOutputPane(m) // The selection may have moved
+	}
+
 	if childCmd != nil {
 		m.logger.Debugf("MainModel: Delegated msg %T to %s, received cmd", msg, currentFocusedModelName)
 		cmds = append(cmds, childCmd)
@@ -1,13 +1,30 @@
 package tui
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"gdd/changes"
+	"gdd/coverage"
 	"gdd/finder"
+	"gdd/history"
+	"gdd/impact"
 	"gdd/parser"
+	"gdd/quarantine"
 	"gdd/runner"
+	"gdd/stress"
+	"gdd/watch"
+	"maps"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -15,9 +32,9 @@ import (
 )
 
 var (
-	ErrAlreadyRunning error = errors.New("tests already running.")
-	ErrWrongPackage   error = errors.New("could not determine selected package.")
-	ErrWrongTest      error = errors.New("could not determine selected test.")
+	ErrWrongPackage error = errors.New("could not determine selected package.")
+	ErrWrongTest    error = errors.New("could not determine selected test.")
+	ErrNoTestOutput error = errors.New("test output is only available in the report of a run, not in a comparison.")
 )
 
 func updateOnResize(m *MainModel, msg tea.WindowSizeMsg) {
@@ -28,98 +45,297 @@ func updateOnResize(m *MainModel, msg tea.WindowSizeMsg) {
 	footerHeight := lipgloss.Height(m.footerView())
 	viewHeight := m.height - footerHeight
 
-	m.listModel.width = m.width
+	// In the split view the test browser gets its share of the width, the output pane
+	// the rest minus the divider between them.
+	browserWidth := m.width
+	if m.splitView {
+		browserWidth = m.width * m.splitRatio / 100
+		m.outputModel.setSize(max(m.width-browserWidth-1, 1), viewHeight)
+	}
+
+	m.listModel.width = browserWidth
 	m.listModel.height = viewHeight
-	m.listModel.list.SetSize(m.width, viewHeight)
+	m.listModel.list.SetSize(browserWidth, viewHeight)
+
+	m.reportModel.setSize(m.width, viewHeight)
 
-	m.reportModel.width = m.width
-	m.reportModel.height = viewHeight
-	m.reportModel.viewport.Width = m.width
-	m.reportModel.viewport.Height = viewHeight
+	m.coverageModel.setSize(m.width, viewHeight)
+	m.testOutputModel.setSize(m.width, viewHeight)
+	m.historyModel.setSize(m.width, viewHeight)
+	m.profileModel.setSize(m.width, viewHeight)
+	m.treeModel.setSize(browserWidth, viewHeight)
+	m.stressModel.setSize(m.width, viewHeight)
+	m.helpModel.setSize(m.width, viewHeight)
+	m.queueModel.setSize(m.width, viewHeight)
 }
 
 func updateOnInit(m *MainModel) tea.Cmd {
 	return func() tea.Msg {
-		log.Debug("discoverTestsCmd: Starting test discovery...")
-		foundTests, err := finder.FindTests(".")
+		items, err := discoverTests(m.config.Finder.ExcludeDirs)
 		if err != nil {
-			log.Errorf("discoverTestsCmd: Failed to discover tests: %v", err)
 			return testsLoadFailedMsg{err: fmt.Errorf("test discovery failed: %w", err)}
 		}
+		return testsFoundMsg{items: items}
+	}
+}
 
-		if len(foundTests) == 0 {
-			log.Info("discoverTestsCmd: No tests found.")
-		} else {
-			log.Infof("discoverTestsCmd: Discovered %d test functions.", len(foundTests))
-		}
+// discoverTests finds the tests of the module in the working directory as list items,
+// skipping the directories matching excludeDirs.
+func discoverTests(excludeDirs []string) ([]list.Item, error) {
+	log.Debug("discoverTestsCmd: Starting test discovery...")
+	foundTests, err := finder.FindTests(".", excludeDirs...)
+	if err != nil {
+		log.Errorf("discoverTestsCmd: Failed to discover tests: %v", err)
+		return nil, err
+	}
 
-		items := make([]list.Item, len(foundTests))
-		for i, t := range foundTests {
-			items[i] = TestItem{TestInfo: t}
-		}
-		return testsFoundMsg{items: items}
+	if len(foundTests) == 0 {
+		log.Info("discoverTestsCmd: No tests found.")
+	} else {
+		log.Infof("discoverTestsCmd: Discovered %d test functions.", len(foundTests))
+	}
+
+	items := make([]list.Item, len(foundTests))
+	for i, t := range foundTests {
+		items[i] = TestItem{TestInfo: t}
 	}
+	return items, nil
 }
 
-func updateOnRunTests(m *MainModel, msg tea.Msg, cmd tea.Cmd) (tea.Cmd, error) {
-	if m.state == stateRunningTests {
-		m.logger.Warn("MainModel: Received trigger test run message while already running tests. Ignoring.")
-		return nil, ErrAlreadyRunning
+// updateOnRunTests starts the run requested by msg, or queues it while another run is in progress.
+func updateOnRunTests(m *MainModel, msg tea.Msg) (tea.Cmd, error) {
+	run, err := newTestRun(m, msg)
+	if err != nil {
+		return nil, err
 	}
+	if runBusy(m) {
+		updateOnQueueRun(m, run)
+		return nil, nil
+	}
+	m.statusMessage = run.status
+	return startRun(m, run, run.watch), nil
+}
 
+// newTestRun returns the run requested by msg, with the settings and the selection of the moment.
+func newTestRun(m *MainModel, msg tea.Msg) (testRun, error) {
 	var runCfg runner.TestRunConfig
 	runCfg.WorkingDir, _ = os.Getwd()
+	runCfg.Race = m.raceEnabled
+	runCfg.SlowTestThreshold = defaultSlowTestThreshold
+	applyRunOptions(m, &runCfg)
+	if m.coverageScope != coverageOff {
+		runCfg.Coverage = true
+		runCfg.CoverMode = "count" // Hit counts are shown in the coverage viewer
+		if runCfg.Race {
+			runCfg.CoverMode = "atomic" // Required by -race
+		}
+		if m.coverageScope == coverageModule {
+			runCfg.CoverPkg = "./..."
+		}
+	}
 
-	switch msg.(type) {
+	run := testRun{queuedAt: time.Now()}
+	switch msg := msg.(type) {
+	case watchRunMsg:
+		if msg.config != nil {
+			m.logger.Infof("MainModel: Watch: rerunning last run (%s) after %d files changed.", msg.config.Type, len(msg.files))
+			runCfg.Type = msg.config.Type
+			runCfg.PackagePath = msg.config.PackagePath
+			runCfg.TestName = msg.config.TestName
+			runCfg.TestNames = msg.config.TestNames
+			runCfg.Packages = msg.config.Packages
+			runCfg.BaseRef = msg.config.BaseRef
+		} else {
+			m.logger.Infof("MainModel: Watch: running %d packages after %d files changed.", len(msg.packages), len(msg.files))
+			runCfg.Type = runner.ChangedTests
+			runCfg.Packages = msg.packages
+		}
+		run.watch = true
+		run.status = fmt.Sprintf("Watch: %d files changed, rerunning tests...", len(msg.files))
+	case changesResolvedMsg:
+		m.logger.Infof("MainModel: Triggering 'Run Changed' for %d packages (changes against %s).", len(msg.result.Affected), msg.result.BaseRef)
+		runCfg.Type = runner.ChangedTests
+		runCfg.Packages = msg.result.Affected
+		runCfg.BaseRef = msg.result.BaseRef
+		run.status = fmt.Sprintf("Running tests of %d packages affected by %d changed files...", len(msg.result.Affected), len(msg.result.Files))
+	case runTreeNodeMsg:
+		m.logger.Infof("MainModel: Triggering run of tree node: %s", msg.desc)
+		runCfg.Type = msg.config.Type
+		runCfg.PackagePath = msg.config.PackagePath
+		runCfg.TestName = msg.config.TestName
+		runCfg.TestNames = msg.config.TestNames
+		run.status = fmt.Sprintf("Running %s...", msg.desc)
 	case triggerRunAllTestsMsg:
 		m.logger.Info("MainModel: Triggering 'Run All Tests'.")
 		runCfg.Type = runner.AllTests
-		m.currentTestRunConfig = &runner.TestRunConfig{Type: runner.AllTests, PackagePath: "./...", WorkingDir: runCfg.WorkingDir}
-		m.statusMessage = "Running all project tests..."
+		runCfg.PackagePath = "./..."
+		run.status = "Running all project tests..."
 	case triggerRunPackageTestsMsg:
 		selectedItem, ok := m.listModel.SelectedItem().(TestItem)
 
 		if !ok {
 			m.logger.Error("MainModel: Failed to get selected item for 'Run Package Tests'.")
 			m.statusMessage = "Error: Could not determine selected package."
-			return nil, ErrWrongPackage
+			return testRun{}, ErrWrongPackage
 		}
 
 		m.logger.Infof("MainModel: Triggering 'Run Package Tests' for package: %s (dir: ./%s)", selectedItem.PackageName, selectedItem.PackageDir)
 		runCfg.Type = runner.PackageTests
 		runCfg.PackagePath = "./" + selectedItem.PackageDir
-		m.currentTestRunConfig = &runCfg
-		m.statusMessage = fmt.Sprintf("Running tests for package %s...", selectedItem.PackageName)
-	case triggerRunSelectedTestMsg:
+		run.status = fmt.Sprintf("Running tests for package %s...", selectedItem.PackageName)
+	case triggerRunSelectedTestMsg, triggerCoverSelectedTestMsg:
 		selectedItem, ok := m.listModel.SelectedItem().(TestItem)
 
 		if !ok {
 			m.logger.Error("MainModel: Failed to get selected item for 'Run Selected Test'.")
 			m.statusMessage = "Error: Could not determine selected test."
-			return nil, ErrWrongTest
+			return testRun{}, ErrWrongTest
 		}
 
 		m.logger.Infof("MainModel: Triggering 'Run Selected Test': %s in package %s (dir: ./%s)", selectedItem.Name, selectedItem.PackageName, selectedItem.PackageDir)
 		runCfg.Type = runner.SingleTest
 		runCfg.PackagePath = "./" + selectedItem.PackageDir
 		runCfg.TestName = selectedItem.Name
-		m.currentTestRunConfig = &runCfg
-		m.statusMessage = fmt.Sprintf("Running test %s...", selectedItem.Name)
+		run.status = fmt.Sprintf("Running test %s...", selectedItem.Name)
+
+		if _, cover := msg.(triggerCoverSelectedTestMsg); cover {
+			// Cover the whole module so the viewer can show everything the test reaches.
+			runCfg.Coverage = true
+			runCfg.CoverPkg = "./..."
+			runCfg.CoverMode = "count"
+			if runCfg.Race {
+				runCfg.CoverMode = "atomic"
+			}
+			run.cover = true
+			run.status = fmt.Sprintf("Running test %s with coverage...", selectedItem.Name)
+		}
 	}
 
-	m.state = stateRunningTests
+	run.config = runCfg
+	return run, nil
+}
+
+// runBusy reports whether a run or a stress test is in progress, so that new runs must wait.
+func runBusy(m *MainModel) bool {
+	return m.state == stateRunningTests || m.runInPlace || m.stressStream != nil
+}
+
+// startRun starts run. A run that keeps the view, as those of watch mode and of the queue do,
+// streams while the report or the test browser stays on screen; it is updated when the run completes.
+func startRun(m *MainModel, run testRun, keepView bool) tea.Cmd {
+	runCfg := run.config
+	m.currentTestRunConfig = &runCfg
+	m.openCoverageAfterRun = run.cover
+
+	browsing := m.state == stateTestList || m.state == stateTreeView
+	if keepView && (m.state == stateReportView || browsing) || m.splitView && browsing {
+		m.runInPlace = true
+	} else {
+		m.state = stateRunningTests
+	}
 	m.accumulatedJSONOutput.Reset()
 	m.testOutputChan = nil
-	m.logger.Debugf("MainModel: Executing tests with config: %+v", runCfg)
+	m.slowTests = nil
+	m.jobs = nil
+	m.liveTests = nil
+	m.runStartedAt = time.Now()
+	m.runSourceHash = ""
+	logged := runCfg
+	logged.Env = runner.EnvNames(runCfg.Env) // Values may be secrets
+	m.logger.Debugf("MainModel: Executing tests with config: %+v", logged)
 
-	m.logger.Debugf("MainModel: Executing tests with config: %+v", runCfg)
+	return tea.Batch(startTestsCmd(runCfg), m.spinner.Tick)
+}
 
-	return runner.ExecuteTestsCmd(runCfg), nil
+// startTestsCmd fingerprints the sources, which walks the whole module, then starts the run.
+func startTestsCmd(runCfg runner.TestRunConfig) tea.Cmd {
+	return func() tea.Msg {
+		hash, err := watch.Fingerprint(runCfg.WorkingDir)
+		if err != nil {
+			log.Warnf("Could not fingerprint sources, reruns can't be told apart from code changes: %v", err)
+		}
+		stream := runner.ExecuteTestsCmd(runCfg)().(runner.StreamMsg)
+		return runStartedMsg{stream: stream.Stream, sourceHash: hash}
+	}
+}
+
+// updateOnQueueRun queues a run requested while another one is in progress, according to the
+// queue policy of the configuration.
+func updateOnQueueRun(m *MainModel, run testRun) {
+	desc := describeRunScope(run.config)
+	if !m.runQueue.add(run, m.config.Queue.Policy) {
+		m.logger.Infof("MainModel: Run %q coalesced with a queued run.", desc)
+		m.statusMessage = fmt.Sprintf("Already queued: %s (%d runs waiting, %s to manage).", desc, len(m.runQueue.runs), m.globalKeys.Queue.Help().Key)
+	} else {
+		m.logger.Infof("MainModel: Run %q queued (policy %s), %d runs waiting.", desc, m.config.Queue.Policy, len(m.runQueue.runs))
+		m.statusMessage = fmt.Sprintf("Queued: %s (%d runs waiting, %s to manage).", desc, len(m.runQueue.runs), m.globalKeys.Queue.Help().Key)
+	}
+	if m.showQueue {
+		m.queueModel.SetRuns(m.runQueue.runs, -1)
+	}
+}
+
+// updateOnStartQueuedRun starts the first queued run once nothing else runs. It keeps the
+// current view on screen, so that the report of the previous run stays readable.
+func updateOnStartQueuedRun(m *MainModel) tea.Cmd {
+	if runBusy(m) {
+		return nil
+	}
+	run, ok := m.runQueue.pop()
+	if !ok {
+		return nil
+	}
+	m.logger.Infof("MainModel: Starting queued run %q, %d runs still waiting.", describeRunScope(run.config), len(m.runQueue.runs))
+	m.statusMessage = run.status
+	if len(m.runQueue.runs) > 0 {
+		m.statusMessage = fmt.Sprintf("%s (%d more queued)", run.status, len(m.runQueue.runs))
+	}
+	if m.showQueue {
+		m.queueModel.SetRuns(m.runQueue.runs, -1)
+	}
+	return startRun(m, run, true)
+}
+
+// applyRunOptions sets the extra flags, environment variables and build tags of a run from the
+// settings and the active profile, how many packages it tests at once and whether it reuses
+// cached test binaries.
+func applyRunOptions(m *MainModel, cfg *runner.TestRunConfig) {
+	cfg.Flags, cfg.Env, cfg.Tags = m.config.RunOptions(m.profile)
+	cfg.Profile = m.profile
+	cfg.Jobs = m.config.Test.ParallelJobs()
+	cfg.Precompiled = m.precompile
+}
+
+// updateOnJobStatus records the new status of a job of a run split by package. The running
+// view lists the jobs; a run kept in place reports their progress in the status bar instead.
+func updateOnJobStatus(m *MainModel, msg runner.JobStatusMsg) {
+	if len(m.jobs) != msg.Jobs {
+		m.jobs = make([]runner.JobStatusMsg, msg.Jobs)
+	}
+	m.jobs[msg.Job] = msg
+	if msg.Err != nil {
+		m.logger.Infof("MainModel: Job %d/%d failed: %v", msg.Job+1, msg.Jobs, msg.Err)
+	}
+
+	if m.runInPlace {
+		done, failed := 0, 0
+		for _, job := range m.jobs {
+			switch job.Status {
+			case runner.JobPassed:
+				done++
+			case runner.JobFailed:
+				done++
+				failed++
+			}
+		}
+		m.statusMessage = fmt.Sprintf("Testing packages: %d/%d done, %d failed...", done, len(m.jobs), failed)
+	}
 }
 
 func updateOnTestsComplete(m *MainModel, msg runner.TestRunCompleteMsg) tea.Cmd {
 	m.logger.Infof("MainModel: TestRunCompleteMsg received. Error: %v", msg.Err)
 	m.testOutputChan = nil
+	m.runInPlace = false
 
 	var parsedData []*parser.PackageResult
 	var parseErr error
@@ -165,6 +381,16 @@ func updateOnTestsComplete(m *MainModel, msg runner.TestRunCompleteMsg) tea.Cmd
 
 	m.logger.Infof("MainModel: Parsed %d package results.", len(parsedData))
 
+	if msg.CoverProfile != "" {
+		// Keep only the latest profile around; it is needed until the next run replaces it.
+		if m.coverProfile != "" && m.coverProfile != msg.CoverProfile {
+			if err := os.Remove(m.coverProfile); err != nil {
+				m.logger.Warnf("MainModel: Could not remove old cover profile %s: %v", m.coverProfile, err)
+			}
+		}
+		m.coverProfile = msg.CoverProfile
+	}
+
 	if m.currentTestRunConfig == nil {
 		m.logger.Error("MainModel: currentTestRunConfig is nil when trying to display report. Using placeholder.")
 		m.currentTestRunConfig = &runner.TestRunConfig{
@@ -174,10 +400,892 @@ func updateOnTestsComplete(m *MainModel, msg runner.TestRunCompleteMsg) tea.Cmd
 		}
 	}
 
+	if m.lastResults == nil {
+		m.lastResults = make(map[string]*history.LastResult)
+	}
+	history.RecordResults(m.lastResults, parsedData, m.runStartedAt)
+	m.liveTests = nil // The output pane shows the recorded results from now on
+	badgesCmd := refreshBadges(m)
+
+	var saveCmd tea.Cmd
+	if m.accumulatedJSONOutput.Len() > 0 {
+		entry := history.NewEntry(*m.currentTestRunConfig, parsedData, m.runStartedAt, time.Since(m.runStartedAt))
+		entry.SourceHash = m.runSourceHash
+		// The buffer is reset by the next run, which may start before the run is saved.
+		saveCmd = saveRunCmd(m.history, entry, bytes.Clone(m.accumulatedJSONOutput.Bytes()), msg.CoverProfile)
+	}
+
+	return tea.Batch(badgesCmd, saveCmd, displayRunReportCmd(parsedData, *m.currentTestRunConfig, msg.CoverProfile, msg.Binaries))
+}
+
+// displayRunReportCmd loads the coverage of a completed run, if it collected any, in the
+// background and then shows its report. Loading parses every covered source file.
+func displayRunReportCmd(results []*parser.PackageResult, runCfg runner.TestRunConfig, coverProfile string, binaries *runner.BinaryTiming) tea.Cmd {
+	return func() tea.Msg {
+		msg := displayReportMsg{parsedResults: results, runConfig: runCfg, binaries: binaries}
+		if coverProfile != "" {
+			rootDir := runCfg.WorkingDir
+			if rootDir == "" {
+				rootDir = "."
+			}
+			if msg.coverage, msg.coverageErr = coverage.Load(coverProfile, rootDir); msg.coverageErr != nil {
+				log.Errorf("displayRunReportCmd: Failed to load cover profile: %v", msg.coverageErr)
+			}
+		}
+		return msg
+	}
+}
+
+// updateOnQuit cleans up what the session leaves behind, the cover profile of the last run in
+// the temp directory, and quits.
+func updateOnQuit(m *MainModel) tea.Cmd {
+	if m.coverProfile != "" {
+		if err := os.Remove(m.coverProfile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			m.logger.Warnf("MainModel: Could not remove cover profile %s: %v", m.coverProfile, err)
+		}
+		m.coverProfile = ""
+	}
+	return tea.Quit
+}
+
+// returnToBrowser shows the test browser the user last chose: the flat list or the tree.
+func returnToBrowser(m *MainModel) {
+	m.state = m.browseState
+	m.splitFocus = focusBrowser
+	m.outputModel.SetFocused(false)
+	refreshOutputPane(m)
+	if m.state == stateTreeView {
+		m.statusMessage = m.treeModel.HelpView()
+		return
+	}
+	m.statusMessage = browserHint(m)
+}
+
+// browserHint names the keys, as bound, that run tests from the test list.
+func browserHint(m *MainModel) string {
+	keys := m.listModel.keys
+	return fmt.Sprintf("Select a test or action (%s: all, %s: package, %s: selected).",
+		keys.RunAllTests.Help().Key, keys.RunPackageTests.Help().Key, keys.RunSelectedTest.Help().Key)
+}
+
+// updateOnSplitKeys handles the keys of the split view while the test browser is shown and
+// not being filtered. It reports whether the key was handled.
+func updateOnSplitKeys(m *MainModel, msg tea.KeyMsg) (tea.Cmd, bool) {
+	if m.state != stateTestList && m.state != stateTreeView {
+		return nil, false
+	}
+	if m.listModel.list.FilterState() == list.Filtering || m.treeModel.Filtering() {
+		return nil, false
+	}
+
+	switch {
+	case key.Matches(msg, m.splitKeys.ToggleSplit):
+		m.splitView = !m.splitView
+		m.logger.Infof("MainModel: Split view toggled. Enabled: %t", m.splitView)
+		updateOnResize(m, tea.WindowSizeMsg{Width: m.width, Height: m.height})
+		returnToBrowser(m)
+		if m.splitView {
+			m.statusMessage = fmt.Sprintf("Split view: %s → switch pane, %s/%s → resize, %s → close.",
+				m.splitKeys.SwitchFocus.Help().Key, m.splitKeys.Shrink.Help().Key, m.splitKeys.Grow.Help().Key, m.splitKeys.ToggleSplit.Help().Key)
+		}
+		return nil, true
+	case !m.splitView:
+		return nil, false
+	case key.Matches(msg, m.splitKeys.SwitchFocus):
+		if m.splitFocus == focusOutput {
+			returnToBrowser(m)
+			return nil, true
+		}
+		m.logger.Debug("MainModel: Focusing the output pane.")
+		m.splitFocus = focusOutput
+		m.outputModel.SetFocused(true)
+		m.statusMessage = fmt.Sprintf("%s, %s → back to tests, %s/%s → resize", m.outputModel.HelpView(),
+			m.splitKeys.SwitchFocus.Help().Key, m.splitKeys.Shrink.Help().Key, m.splitKeys.Grow.Help().Key)
+		return nil, true
+	case key.Matches(msg, m.splitKeys.Grow), key.Matches(msg, m.splitKeys.Shrink):
+		step := splitRatioStep
+		if key.Matches(msg, m.splitKeys.Shrink) {
+			step = -step
+		}
+		m.splitRatio = max(minSplitRatio, min(maxSplitRatio, m.splitRatio+step))
+		m.logger.Debugf("MainModel: Split ratio set to %d%%", m.splitRatio)
+		updateOnResize(m, tea.WindowSizeMsg{Width: m.width, Height: m.height})
+		return nil, true
+	}
+	return nil, false
+}
+
+// updateOnChord collects the keys of chords such as "g g" while they are typed, and handles a
+// completed chord as a key of its own. It reports whether the key was handled.
+func updateOnChord(m *MainModel, msg tea.KeyMsg) (tea.Cmd, bool) {
+	if isChordMsg(msg) {
+		return nil, false
+	}
+	if len(m.pendingKeys) > 0 && msg.Type == tea.KeyEsc {
+		m.logger.Debugf("MainModel: Chord %q cancelled.", strings.Join(m.pendingKeys, " "))
+		m.pendingKeys = nil
+		return nil, true
+	}
+
+	seq := strings.Join(append(slices.Clone(m.pendingKeys), chordPart(msg)), " ")
+	complete, prefix := matchChord(m.keys.active(keyContext(m)), seq)
+	switch {
+	case prefix:
+		m.logger.Debugf("MainModel: Waiting for the rest of chord %q.", seq)
+		m.pendingKeys = strings.Fields(seq)
+		return nil, true
+	case complete && len(m.pendingKeys) > 0:
+		m.logger.Debugf("MainModel: Chord %q completed.", seq)
+		m.pendingKeys = nil
+		_, cmd := m.Update(chordMsg(seq))
+		return cmd, true
+	case len(m.pendingKeys) > 0:
+		// The key doesn't continue the chord, so the chord is dropped and the key handled on its own.
+		m.logger.Debugf("MainModel: Chord %q broken by %q.", strings.Join(m.pendingKeys, " "), msg.String())
+		m.pendingKeys = nil
+	}
+	return nil, false
+}
+
+// updateOnToggleHelp shows or hides the help overlay. It lists the actions of the current view,
+// so it is filled in every time it is shown.
+func updateOnToggleHelp(m *MainModel) {
+	m.showHelp = !m.showHelp
+	m.pendingKeys = nil
+	m.logger.Debugf("MainModel: Help overlay toggled. Shown: %t", m.showHelp)
+	if m.showHelp {
+		view, state := keyContext(m)
+		title, ok := m.keys.titles[view]
+		if !ok {
+			title = m.keys.titles["global"] // No view of its own, e.g. while tests run
+		}
+		m.helpModel.SetGroups(title, m.keys.groups(view, state, builtinKeys(m)))
+	}
+}
+
+// updateOnToggleQueue shows or hides the run queue panel, over the current view.
+func updateOnToggleQueue(m *MainModel) tea.Cmd {
+	m.showQueue = !m.showQueue
+	m.pendingKeys = nil
+	m.logger.Debugf("MainModel: Run queue toggled. Shown: %t", m.showQueue)
+	if m.showQueue {
+		return m.queueModel.SetRuns(m.runQueue.runs, -1)
+	}
+	return nil
+}
+
+// updateOnQueueKeys passes the keys to the run queue panel while it is shown, rather than to
+// the view below.
+func updateOnQueueKeys(m *MainModel, msg tea.KeyMsg) tea.Cmd {
+	updatedModel, cmd := m.queueModel.Update(msg)
+	if um, ok := updatedModel.(QueueModel); ok {
+		m.queueModel = um
+	} else {
+		m.logger.Errorf("MainModel: QueueModel.Update returned unexpected type %T", updatedModel)
+	}
+	return cmd
+}
+
+// updateOnHelpKeys handles the keys while the help overlay is shown: the help key closes it
+// unless the search is typed, any other key goes to the overlay rather than the view below.
+func updateOnHelpKeys(m *MainModel, msg tea.KeyMsg) tea.Cmd {
+	if !typing(m) && key.Matches(msg, m.globalKeys.Help) {
+		updateOnToggleHelp(m)
+		return nil
+	}
+	updatedModel, cmd := m.helpModel.Update(msg)
+	if um, ok := updatedModel.(HelpModel); ok {
+		m.helpModel = um
+	} else {
+		m.logger.Errorf("MainModel: HelpModel.Update returned unexpected type %T", updatedModel)
+	}
+	return cmd
+}
+
+// updateOnLiveEvent records an event of the run streaming in the split view, so that the output
+// pane can show the output of the selected tests while they run.
+func updateOnLiveEvent(m *MainModel, line string) {
+	var event parser.TestEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Test == "" {
+		return // Not JSON (e.g. a build error), or a package-level event
+	}
+
+	if m.liveTests == nil {
+		m.liveTests = make(map[string]*liveTest)
+	}
+	key := history.TestKey(event.Package, event.Test)
+	lt, ok := m.liveTests[key]
+	if !ok {
+		lt = &liveTest{status: parser.StatusRunning}
+		m.liveTests[key] = lt
+	}
+	switch event.Action {
+	case "output":
+		lt.output = append(lt.output, strings.TrimRight(event.Output, "\n"))
+	case "pass":
+		lt.status = parser.StatusPass
+	case "fail":
+		lt.status = parser.StatusFail
+	case "skip":
+		lt.status = parser.StatusSkip
+	}
+
+	if m.outputModel.Shows(key) {
+		refreshOutputPane(m)
+	}
+}
+
+// refreshOutputPane shows the output of the selected test, or of the tests below the selected
+// node of the tree, in the output pane.
+func refreshOutputPane(m *MainModel) {
+	if !m.splitView {
+		return
+	}
+	m.outputModel.SetTarget(splitTarget(m))
+}
+
+// splitTarget returns what the output pane shows for the selection of the test browser.
+func splitTarget(m *MainModel) outputTarget {
+	if m.browseState == stateTreeView {
+		n := m.treeModel.selected()
+		if n == nil {
+			return outputTarget{}
+		}
+		if n.kind == nodeTest || n.kind == nodeSubtest {
+			return testTarget(m, n.item, n.testName)
+		}
+
+		target := outputTarget{id: n.id, group: true}
+		walkTree(n, func(c *treeNode) {
+			if c.kind != nodeTest {
+				return
+			}
+			key := history.TestKey(testImportPath(m.modulePath, c.item), c.testName)
+			target.tests = append(target.tests, outputTest{key: key, name: c.testName, last: m.lastResults[key], live: m.liveTests[key]})
+		})
+		target.title = fmt.Sprintf("%s (%d tests)", n.name, len(target.tests))
+		return target
+	}
+
+	ti, ok := m.listModel.SelectedItem().(TestItem)
+	if !ok {
+		return outputTarget{}
+	}
+	return testTarget(m, ti, ti.Name)
+}
+
+// testTarget returns a test and its subtests, both the statically known ones and the ones
+// found in results, for the output pane.
+func testTarget(m *MainModel, ti TestItem, name string) outputTarget {
+	pkg := testImportPath(m.modulePath, ti)
+	id := history.TestKey(pkg, name)
+
+	subtests := make(map[string]bool)
+	for _, sub := range ti.Subtests {
+		if strings.HasPrefix(sub, name+"/") {
+			subtests[sub] = true
+		}
+	}
+	for key := range m.lastResults {
+		if strings.HasPrefix(key, id+"/") {
+			subtests[strings.TrimPrefix(key, history.TestKey(pkg, ""))] = true
+		}
+	}
+	for key := range m.liveTests {
+		if strings.HasPrefix(key, id+"/") {
+			subtests[strings.TrimPrefix(key, history.TestKey(pkg, ""))] = true
+		}
+	}
+
+	target := outputTarget{id: id, title: fmt.Sprintf("%s (%s)", name, ti.PackageName)}
+	target.tests = append(target.tests, outputTest{key: id, name: name, last: m.lastResults[id], live: m.liveTests[id]})
+	for _, sub := range slices.Sorted(maps.Keys(subtests)) {
+		key := history.TestKey(pkg, sub)
+		rel := strings.TrimPrefix(sub, name+"/")
+		target.tests = append(target.tests, outputTest{
+			key:   key,
+			name:  rel[strings.LastIndex(rel, "/")+1:],
+			depth: strings.Count(rel, "/") + 1,
+			last:  m.lastResults[key],
+			live:  m.liveTests[key],
+		})
+	}
+	return target
+}
+
+// summarizeResults counts the outcomes of the tests of a run for the status bar.
+func summarizeResults(results []*parser.PackageResult) string {
+	var passed, failed, skipped int
+	for _, pkg := range results {
+		for _, t := range pkg.Tests {
+			switch t.Status {
+			case parser.StatusPass:
+				passed++
+			case parser.StatusFail, parser.StatusTimeout:
+				failed++
+			case parser.StatusSkip:
+				skipped++
+			}
+		}
+	}
+	return fmt.Sprintf("%d passed, %d failed, %d skipped", passed, failed, skipped)
+}
+
+// updateOnCycleWatch switches to the next watch mode, starting or stopping the watcher as needed.
+func updateOnCycleWatch(m *MainModel) tea.Cmd {
+	previous := m.watchMode
+	m.watchMode = (m.watchMode + 1) % (watchDependents + 1)
+	m.logger.Infof("MainModel: Watch mode set to %s", m.watchMode)
+
+	if m.watchMode == watchOff {
+		m.watchGen++
+		m.watcher = nil
+		if n := m.runQueue.remove(func(run testRun) bool { return run.watch }); n > 0 {
+			m.logger.Infof("MainModel: Dropped %d queued watch mode runs.", n)
+		}
+		m.statusMessage = "Watch mode off."
+		return nil
+	}
+
+	m.statusMessage = fmt.Sprintf("Watch mode: %s. Save a file to rerun tests.", m.watchMode)
+	if previous != watchOff {
+		return nil // Already watching, only the rerun policy changed
+	}
+
+	m.watchGen++
+	gen := m.watchGen
+	return func() tea.Msg {
+		w, err := watch.New(".", watch.DefaultDebounce)
+		return watchStartedMsg{gen: gen, watcher: w, err: err}
+	}
+}
+
+// pollWatchCmd polls the watcher for changed files after the watch interval.
+func pollWatchCmd(w *watch.Watcher, gen int) tea.Cmd {
+	return tea.Tick(watch.DefaultInterval, func(time.Time) tea.Msg {
+		files, err := w.Poll()
+		return watchPollMsg{gen: gen, files: files, err: err}
+	})
+}
+
+// updateOnWatchChanges decides what to rerun after files changed, according to the watch mode.
+// Mapping files to packages needs `go list`, so it runs in the background.
+func updateOnWatchChanges(m *MainModel, files []string) tea.Cmd {
+	m.logger.Infof("MainModel: Watch: %d files changed: %v", len(files), files)
+
+	if m.watchMode == watchLastRun && m.currentTestRunConfig != nil {
+		last := *m.currentTestRunConfig
+		return func() tea.Msg { return watchRunMsg{files: files, config: &last} }
+	}
+
+	// Without a previous run there is nothing to repeat, so fall back to the dependents.
+	withDependents := m.watchMode != watchChangedPackages
+	return func() tea.Msg {
+		wd, err := os.Getwd()
+		if err != nil {
+			return watchRunMsg{files: files, err: err}
+		}
+		changed, affected, err := changes.Packages(wd, files)
+		if withDependents {
+			changed = affected
+		}
+		return watchRunMsg{files: files, packages: changed, err: err}
+	}
+}
+
+// refreshTestsCmd rediscovers the tests of the module, so that new test functions appear in the list.
+func refreshTestsCmd(excludeDirs []string) tea.Cmd {
+	return func() tea.Msg {
+		items, err := discoverTests(excludeDirs)
+		if err != nil {
+			return nil // Likely a file in the middle of being edited; the next change refreshes again.
+		}
+		return testsRefreshedMsg{items: items}
+	}
+}
+
+// updateOnTestsRefreshed replaces the discovered tests, keeping the list untouched if nothing changed.
+func updateOnTestsRefreshed(m *MainModel, items []list.Item) tea.Cmd {
+	if sameTests(m.allItems, items) {
+		return nil
+	}
+	m.logger.Infof("MainModel: Test discovery refreshed: %d tests (was %d).", len(items), len(m.allItems))
+	m.allItems = decorateItems(m, items)
+	return applyListView(m)
+}
+
+// computeFlakinessCmd scores the flakiness of every test from the run history in the background.
+func computeFlakinessCmd(store *history.Store) tea.Cmd {
+	return func() tea.Msg {
+		scores, err := store.Flakiness()
+		return flakinessLoadedMsg{scores: scores, err: err}
+	}
+}
+
+// saveRunCmd stores a finished run, its events and its cover profile in the history in the
+// background.
+func saveRunCmd(store *history.Store, entry *history.Entry, events []byte, coverProfile string) tea.Cmd {
 	return func() tea.Msg {
+		return runSavedMsg{err: store.Save(entry, events, coverProfile)}
+	}
+}
+
+// loadLastResultsCmd finds the most recent outcome of every test in the run history in the background.
+func loadLastResultsCmd(store *history.Store) tea.Cmd {
+	return func() tea.Msg {
+		results, err := store.LastResults()
+		return lastResultsLoadedMsg{results: results, err: err}
+	}
+}
+
+// loadQuarantineCmd reads the quarantine file of the module in the background.
+func loadQuarantineCmd() tea.Cmd {
+	return func() tea.Msg {
+		list, err := quarantine.Load(".")
+		return quarantineLoadedMsg{list: list, err: err}
+	}
+}
+
+// testImportPath returns the import path of the package of a discovered test, as it appears in results.
+func testImportPath(modulePath string, ti TestItem) string {
+	if ti.PackageDir == "." || ti.PackageDir == "" {
+		return modulePath
+	}
+	return modulePath + "/" + filepath.ToSlash(ti.PackageDir)
+}
+
+// decorateItems sets the last results and the flakiness and quarantine badges of the test items.
+func decorateItems(m *MainModel, items []list.Item) []list.Item {
+	if m.modulePath == "" {
+		return items
+	}
+	decorated := make([]list.Item, len(items))
+	for i, item := range items {
+		ti, ok := item.(TestItem)
+		if !ok {
+			decorated[i] = item
+			continue
+		}
+		pkg := testImportPath(m.modulePath, ti)
+		ti.Last = m.lastResults[history.TestKey(pkg, ti.Name)]
+		ti.statusIcon = ""
+		if ti.Last != nil {
+			ti.statusIcon = m.styles.StatusIcon(ti.Last.Status)
+		}
+		ti.Flakiness = m.flakiness[history.TestKey(pkg, ti.Name)]
+		ti.Quarantined = m.quarantine.Contains(pkg, ti.Name)
+		ti.flakyBadge, ti.quarantinedBadge = m.styles.FlakyBadge, m.styles.QuarantinedBadge
+		decorated[i] = ti
+	}
+	return decorated
+}
+
+// refreshBadges updates the badges of the tests in place, keeping the list's filter and selection.
+// When the list is filtered or sorted by status, it is rebuilt since the badges affect it.
+func refreshBadges(m *MainModel) tea.Cmd {
+	m.allItems = decorateItems(m, m.allItems)
+	if m.statusFilter != filterAll || m.sortOrder != sortByName {
+		return applyListView(m)
+	}
+
+	refreshTree(m)
+	refreshOutputPane(m)
+	var cmds []tea.Cmd
+	for i, item := range decorateItems(m, m.listModel.list.Items()) {
+		cmds = append(cmds, m.listModel.list.SetItem(i, item))
+	}
+	return tea.Batch(cmds...)
+}
+
+// refreshTree rebuilds the test tree from every discovered test and their last results.
+func refreshTree(m *MainModel) {
+	m.treeModel.SetTests(m.modulePath, m.allItems, func(ti TestItem, name string) *history.LastResult {
+		return m.lastResults[history.TestKey(testImportPath(m.modulePath, ti), name)]
+	})
+}
+
+// updateOnToggleQuarantine quarantines the selected test, or releases it, saving the quarantine file in the background.
+func updateOnToggleQuarantine(m *MainModel) tea.Cmd {
+	selectedItem, ok := m.listModel.SelectedItem().(TestItem)
+	if !ok || m.modulePath == "" {
+		m.statusMessage = "Error: Could not determine selected test."
+		return nil
+	}
+	if m.quarantine == nil {
+		m.statusMessage = "The quarantine file is not loaded yet, try again in a moment."
+		return nil
+	}
+
+	pkg := testImportPath(m.modulePath, selectedItem)
+	reason := "quarantined on " + time.Now().Format("2006-01-02")
+	if f := selectedItem.Flakiness; f != nil && f.Failures > 0 {
+		reason += fmt.Sprintf(", failed %d of %d runs", f.Failures, f.Runs)
+	}
+	return toggleQuarantineCmd(m.quarantine.Clone(), pkg, selectedItem.Name, reason)
+}
+
+// toggleQuarantineCmd quarantines a test in list, or releases it, and saves the quarantine file.
+// The list is a copy: the model only switches to it once it is saved.
+func toggleQuarantineCmd(list *quarantine.List, pkg, test, reason string) tea.Cmd {
+	return func() tea.Msg {
+		quarantined := list.Toggle(pkg, test, reason)
+		return quarantineToggledMsg{list: list, test: test, pkg: pkg, quarantined: quarantined, err: list.Save()}
+	}
+}
+
+// updateOnQuarantineToggled switches to the saved quarantine list and refreshes the badges.
+func updateOnQuarantineToggled(m *MainModel, msg quarantineToggledMsg) tea.Cmd {
+	if msg.err != nil {
+		m.logger.Errorf("MainModel: %v", msg.err)
+		m.statusMessage = fmt.Sprintf("Error: %v", msg.err)
+		return nil
+	}
+	m.quarantine = msg.list
+	m.reportModel.SetQuarantine(msg.list)
+
+	if msg.quarantined {
+		m.logger.Infof("MainModel: Quarantined %s (%s).", msg.test, msg.pkg)
+		m.statusMessage = fmt.Sprintf("Quarantined %s: its failures no longer fail a run (see %s).", msg.test, quarantine.DefaultFile)
+	} else {
+		m.logger.Infof("MainModel: Released %s (%s) from quarantine.", msg.test, msg.pkg)
+		m.statusMessage = fmt.Sprintf("Released %s from quarantine.", msg.test)
+	}
+	return refreshBadges(m)
+}
+
+// editQuarantineCmd opens the quarantine file in $EDITOR and reloads it once the editor exits.
+// The file is created, if needed, when the command runs rather than when it is built.
+func editQuarantineCmd() tea.Cmd {
+	return func() tea.Msg {
+		list, err := quarantine.Load(".")
+		if err == nil {
+			err = list.EnsureFile()
+		}
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return execQuarantineEditorMsg{path: list.Path()}
+	}
+}
+
+// execQuarantineEditorCmd hands the terminal to $EDITOR on the quarantine file at path and
+// reloads the file once the editor exits.
+func execQuarantineEditorCmd(path string) tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	log.Infof("execQuarantineEditorCmd: Opening %s with %s.", path, editor)
+	return tea.ExecProcess(exec.Command(editor, path), func(err error) tea.Msg {
+		if err != nil {
+			return errorMsg{err: fmt.Errorf("editor exited with an error: %w", err)}
+		}
+		list, err := quarantine.Load(".")
+		return quarantineLoadedMsg{list: list, err: err}
+	})
+}
+
+// sameTests reports whether two lists of discovered tests contain the same tests in the same order.
+func sameTests(a, b []list.Item) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		ai, aok := a[i].(TestItem)
+		bi, bok := b[i].(TestItem)
+		if !aok || !bok || ai.Name != bi.Name || ai.PackageName != bi.PackageName || ai.PackageDir != bi.PackageDir || ai.FilePath != bi.FilePath ||
+			!slices.Equal(ai.Subtests, bi.Subtests) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadHistoryCmd lists the stored runs in the background.
+func loadHistoryCmd(store *history.Store) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := store.List()
+		return historyLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// openHistoryRunCmd loads a stored run and rebuilds its report from the raw events,
+// the same way it was built when the run completed.
+func openHistoryRunCmd(store *history.Store, id string) tea.Cmd {
+	return func() tea.Msg {
+		entry, events, err := store.Load(id)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		results, err := parser.Parse(events)
+		if err != nil {
+			return errorMsg{err: fmt.Errorf("could not parse stored run %s: %w", id, err)}
+		}
+
+		var coverageReport *coverage.Report
+		if profile := store.CoverProfile(entry); profile != "" {
+			rootDir := entry.Config.WorkingDir
+			if rootDir == "" {
+				rootDir = "."
+			}
+			if coverageReport, err = coverage.Load(profile, rootDir); err != nil {
+				log.Warnf("openHistoryRunCmd: Could not load stored coverage of run %s: %v", id, err)
+			}
+		}
+
 		return displayReportMsg{
-			parsedResults: parsedData,
-			runConfig:     *m.currentTestRunConfig,
+			parsedResults: results,
+			runConfig:     entry.Config,
+			coverage:      coverageReport,
+			historyEntry:  entry,
 		}
 	}
 }
+
+// updateOnStressSetup shows the stress setup form for the selected test or its package.
+func updateOnStressSetup(m *MainModel, msg triggerStressMsg) tea.Cmd {
+	if m.stressStream != nil {
+		m.statusMessage = "A stress run is already in progress."
+		return nil
+	}
+	selectedItem, ok := m.listModel.SelectedItem().(TestItem)
+	if !ok {
+		m.statusMessage = "Error: Could not determine selected test."
+		return nil
+	}
+
+	target := runner.TestRunConfig{
+		Type:        runner.SingleTest,
+		PackagePath: "./" + selectedItem.PackageDir,
+		TestName:    selectedItem.Name,
+		Race:        m.raceEnabled,
+	}
+	target.WorkingDir, _ = os.Getwd()
+	applyRunOptions(m, &target)
+	if msg.wholePackage {
+		target.Type = runner.PackageTests
+		target.TestName = ""
+	}
+
+	m.logger.Infof("MainModel: Setting up stress run for %s.", describeRunScope(target))
+	m.stressModel.Setup(target)
+	m.state = stateStressView
+	m.statusMessage = m.stressModel.HelpView()
+	return nil
+}
+
+// updateOnStressStart starts a stress run in the background. Its iterations are streamed
+// back like the output of a test run.
+func updateOnStressStart(m *MainModel, cfg stress.Config) tea.Cmd {
+	if m.stressStream != nil {
+		m.statusMessage = "A stress run is already in progress."
+		return nil
+	}
+
+	m.stressModel.Start(cfg)
+	m.statusMessage = m.stressModel.HelpView()
+	stop := make(chan struct{})
+	m.stressStop = stop
+
+	return func() tea.Msg {
+		stream := make(chan tea.Msg, 1)
+		go func() {
+			defer close(stream)
+			reason := stress.Run(cfg, func(it stress.Iteration) {
+				stream <- stressIterationMsg{iteration: it}
+			}, stop)
+			stream <- stressDoneMsg{reason: reason}
+		}()
+		return stressStreamMsg{stream: stream}
+	}
+}
+
+// compareRunsCmd loads two stored runs and compares the older one (base) with the newer one (head).
+func compareRunsCmd(store *history.Store, firstID, secondID string) tea.Cmd {
+	return func() tea.Msg {
+		var entries [2]*history.Entry
+		var results [2][]*parser.PackageResult
+		for i, id := range []string{firstID, secondID} {
+			entry, events, err := store.Load(id)
+			if err != nil {
+				return errorMsg{err: err}
+			}
+			parsed, err := parser.Parse(events)
+			if err != nil {
+				return errorMsg{err: fmt.Errorf("could not parse stored run %s: %w", id, err)}
+			}
+			entries[i], results[i] = entry, parsed
+		}
+		if entries[1].StartedAt.Before(entries[0].StartedAt) {
+			entries[0], entries[1] = entries[1], entries[0]
+			results[0], results[1] = results[1], results[0]
+		}
+		return displayDiffMsg{
+			diff: history.Compare(results[0], results[1]),
+			base: entries[0],
+			head: entries[1],
+		}
+	}
+}
+
+// resolveChangesCmd finds the packages affected by the git changes against baseRef in the background.
+func resolveChangesCmd(baseRef string) tea.Cmd {
+	return func() tea.Msg {
+		wd, err := os.Getwd()
+		if err != nil {
+			return changesResolvedMsg{err: err}
+		}
+		result, err := changes.Affected(wd, baseRef)
+		return changesResolvedMsg{result: result, err: err}
+	}
+}
+
+// loadImpactIndexCmd loads the test impact index from disk, if one was built before.
+func loadImpactIndexCmd() tea.Cmd {
+	return func() tea.Msg {
+		idx, err := impact.Load(".")
+		if err != nil {
+			if !errors.Is(err, impact.ErrNoIndex) {
+				log.Warnf("loadImpactIndexCmd: %v", err)
+			}
+			return nil
+		}
+		return impactIndexLoadedMsg{index: idx}
+	}
+}
+
+// updateOnImpactUpdate starts a background update of the test impact index, which runs
+// every new or stale test on its own with coverage.
+func updateOnImpactUpdate(m *MainModel) tea.Cmd {
+	if m.impactUpdating {
+		m.statusMessage = "Impact index update already in progress."
+		return nil
+	}
+
+	tests := make([]finder.TestInfo, 0, len(m.allItems))
+	for _, item := range m.allItems {
+		if ti, ok := item.(TestItem); ok {
+			tests = append(tests, ti.TestInfo)
+		}
+	}
+
+	idx := m.impactIndex
+	if idx == nil {
+		idx = impact.NewIndex(".")
+	}
+
+	m.impactUpdating = true
+	m.impactIndex = nil // The index is owned by the background update until it finishes.
+	m.coverageModel.SetImpactIndex(nil)
+	m.statusMessage = fmt.Sprintf("Updating test impact index in the background (%d tests)...", len(tests))
+
+	return func() tea.Msg {
+		result, err := idx.Update(tests, func(done, total int, test string) {
+			log.Debugf("Impact index: running %s (%d/%d)", test, done+1, total)
+		})
+		if err == nil {
+			err = idx.Save()
+		}
+		return impactIndexUpdatedMsg{index: idx, result: result, err: err}
+	}
+}
+
+// updateOnImpactFilter toggles between the full test list and the tests impacted by
+// the changes made since the impact index was built.
+func updateOnImpactFilter(m *MainModel) tea.Cmd {
+	if m.impactFilter {
+		m.impactFilter = false
+		m.statusMessage = "Showing all tests."
+		return applyListView(m)
+	}
+	if m.impactIndex == nil {
+		if m.impactUpdating {
+			m.statusMessage = "Impact index is being updated, try again in a moment."
+		} else {
+			m.statusMessage = fmt.Sprintf("No impact index yet. Press %s to build it.", m.listModel.keys.BuildImpact.Help().Key)
+		}
+		return nil
+	}
+
+	m.impactFilter = true
+	cmd := applyListView(m)
+	m.statusMessage = fmt.Sprintf("Showing %d tests impacted by changes since the index was built. Press %s to show all.", len(m.listModel.list.Items()), m.listModel.keys.FilterImpacted.Help().Key)
+	return cmd
+}
+
+// applyListView rebuilds the list from every discovered test, applying the impact filter,
+// the status filter and the sort order.
+func applyListView(m *MainModel) tea.Cmd {
+	var impacted map[string]bool
+	if m.impactFilter && m.impactIndex != nil {
+		impacted = make(map[string]bool)
+		for _, entry := range m.impactIndex.ImpactedTests() {
+			impacted[entry.Key()] = true
+		}
+	}
+
+	var items []list.Item
+	for _, item := range m.allItems {
+		ti, ok := item.(TestItem)
+		if !ok {
+			continue
+		}
+		if impacted != nil && !impacted[impact.TestKey(ti.PackageDir, ti.Name)] {
+			continue
+		}
+		if !m.statusFilter.matches(ti.Last) {
+			continue
+		}
+		items = append(items, ti)
+	}
+	sortItems(items, m.sortOrder)
+
+	cmd := m.listModel.SetItems(items)
+	refreshTree(m)
+	refreshOutputPane(m)
+	if impacted == nil && m.statusFilter == filterAll && m.sortOrder == sortByName {
+		return cmd // SetItems already set the default title
+	}
+	title := "Tests"
+	if impacted != nil {
+		title = "Impacted Tests"
+	}
+	if m.statusFilter != filterAll {
+		title = fmt.Sprintf("%s, %s", title, m.statusFilter)
+	}
+	if m.sortOrder != sortByName {
+		title = fmt.Sprintf("%s, by %s", title, m.sortOrder)
+	}
+	m.listModel.SetTitle(fmt.Sprintf("%s (%d)", title, len(items)))
+	return cmd
+}
+
+// sortItems sorts test items in place. Discovery order is kept among equal items.
+func sortItems(items []list.Item, order listSortOrder) {
+	if order == sortByName {
+		return
+	}
+	statusRank := func(last *history.LastResult) int {
+		switch {
+		case last == nil:
+			return 3
+		case last.Status == parser.StatusFail || last.Status == parser.StatusTimeout:
+			return 0
+		case last.Status == parser.StatusPass:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].(TestItem).Last, items[j].(TestItem).Last
+		switch order {
+		case sortByStatus:
+			return statusRank(a) < statusRank(b)
+		case sortByDuration:
+			return b == nil && a != nil || a != nil && b != nil && a.Duration > b.Duration
+		default: // sortByRecent
+			return b == nil && a != nil || a != nil && b != nil && a.RunAt.After(b.RunAt)
+		}
+	})
+}
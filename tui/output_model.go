@@ -0,0 +1,206 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gdd/history"
+	"gdd/parser"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// liveTest is the state of a test in the run streaming while the split view is shown.
+type liveTest struct {
+	status parser.TestStatus // StatusRunning until the test ends
+	output []string
+}
+
+// outputTest is a test shown in the output pane.
+type outputTest struct {
+	key   string // history.TestKey of the test
+	name  string
+	depth int                 // Nesting below the selected test, for subtests
+	last  *history.LastResult // Last completed result, nil if the test never ran
+	live  *liveTest           // State in the running run, nil if the test hasn't started in it
+}
+
+// status returns the live status of the test if it is part of the running run, otherwise its last one.
+func (t outputTest) status() parser.TestStatus {
+	switch {
+	case t.live != nil:
+		return t.live.status
+	case t.last != nil:
+		return t.last.Status
+	default:
+		return parser.StatusUnknown
+	}
+}
+
+// outputTarget is what the output pane shows: a test and its subtests, or the tests below
+// a package, a file or the module.
+type outputTarget struct {
+	id    string // Identifies the selection, to keep the scroll position while it stays selected
+	title string
+	group bool // Only the output of failing and running tests is shown
+	tests []outputTest
+}
+
+// OutputModel is the right pane of the split view. It shows the live or latest output
+// of the tests selected in the list or the tree.
+type OutputModel struct {
+	viewport viewport.Model
+	styles   *AppStyles
+	logger   *log.Logger
+
+	width  int
+	height int
+
+	focused bool
+	target  outputTarget
+}
+
+// NewOutputModel creates a new instance of the OutputModel.
+func NewOutputModel(logger *log.Logger, styles *AppStyles) OutputModel {
+	return OutputModel{
+		viewport: viewport.New(0, 0),
+		styles:   styles,
+		logger:   logger,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m OutputModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the OutputModel. Keys only reach it while it has focus.
+func (m OutputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// View renders the title of the selection above its output.
+func (m OutputModel) View() string {
+	title := m.target.title
+	if title == "" {
+		title = "Output"
+	}
+	header := m.styles.Help.Padding(0, 1).Render(title)
+	if m.focused {
+		header = m.styles.ListHeader.Render(title)
+	}
+	return clipToSize(header+"\n"+m.viewport.View(), m.width, m.height)
+}
+
+func (m *OutputModel) setSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.viewport.Width = width
+	m.viewport.Height = max(height-1, 1) // Below the title
+	m.render()
+}
+
+// SetFocused marks the pane as focused, which highlights its title.
+func (m *OutputModel) SetFocused(focused bool) {
+	m.focused = focused
+}
+
+// SetTarget shows the given selection. The scroll position is kept while the same selection
+// is refreshed, and the output of running tests is followed while scrolled to the bottom.
+func (m *OutputModel) SetTarget(target outputTarget) {
+	same := target.id == m.target.id
+	follow := m.viewport.AtBottom()
+	m.target = target
+	m.render()
+
+	switch {
+	case !same && target.running():
+		m.viewport.GotoBottom()
+	case !same:
+		m.viewport.GotoTop()
+	case follow && target.running():
+		m.viewport.GotoBottom()
+	}
+}
+
+// Shows reports whether the test with the given key is part of the shown selection, or is
+// a subtest of it that was not known yet.
+func (m OutputModel) Shows(key string) bool {
+	if m.target.id == "" {
+		return false
+	}
+	if !m.target.group && strings.HasPrefix(key, m.target.id+"/") {
+		return true
+	}
+	for _, t := range m.target.tests {
+		if t.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// running reports whether any test of the target is running.
+func (t outputTarget) running() bool {
+	for _, test := range t.tests {
+		if test.live != nil && test.live.status == parser.StatusRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// render writes the status line and the output of every test of the target into the viewport.
+func (m *OutputModel) render() {
+	if m.viewport.Width <= 0 {
+		return
+	}
+
+	var b strings.Builder
+	if len(m.target.tests) == 0 {
+		b.WriteString(m.styles.ListNoItems.Render("No test selected."))
+	}
+	for _, t := range m.target.tests {
+		indent := strings.Repeat("  ", t.depth)
+		status := t.status()
+		b.WriteString(indent + m.styles.StatusIcon(status) + " " + t.name)
+
+		var output []string
+		switch {
+		case t.live != nil && t.live.status == parser.StatusRunning:
+			b.WriteString("  " + m.styles.ListDescription.Render("running..."))
+			output = t.live.output
+		case t.live != nil:
+			b.WriteString("  " + m.styles.ListDescription.Render(fmt.Sprintf("%s, just now", status)))
+			output = t.live.output
+		case t.last != nil:
+			b.WriteString("  " + m.styles.ListDescription.Render(fmt.Sprintf("%s, %s, %s",
+				status, t.last.Duration.Round(time.Millisecond), formatAge(time.Since(t.last.RunAt)))))
+			output = t.last.Output
+		default:
+			b.WriteString("  " + m.styles.ListDescription.Render("not run yet"))
+		}
+		b.WriteString("\n")
+
+		// Groups can hold many tests; only failures and running tests are worth their output.
+		if m.target.group && status != parser.StatusFail && status != parser.StatusTimeout && status != parser.StatusRunning {
+			continue
+		}
+		for _, line := range output {
+			b.WriteString(indent + "    " + line + "\n")
+		}
+	}
+
+	m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(strings.TrimRight(b.String(), "\n")))
+}
+
+// HelpView returns a string with help for the output pane's keybindings.
+func (m OutputModel) HelpView() string {
+	return "↑/↓/pgup/pgdn → scroll output"
+}
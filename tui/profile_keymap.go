@@ -0,0 +1,23 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// ProfileKeyMap defines keybindings for the profile picker.
+type ProfileKeyMap struct {
+	Select key.Binding `category:"options"`
+	Back   key.Binding `category:"general"`
+}
+
+// DefaultProfileKeyMap returns a new ProfileKeyMap with default keybindings.
+func DefaultProfileKeyMap() ProfileKeyMap {
+	return ProfileKeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "use profile"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc", "q", "b"),
+			key.WithHelp("esc/q/b", "back"),
+		),
+	}
+}
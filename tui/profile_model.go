@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gdd/config"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// profileItem is a list.Item for a profile of the configuration, or for running without one.
+type profileItem struct {
+	name    string // "" for no profile
+	profile config.ProfileConfig
+	active  bool // Used by the runs started from now on
+}
+
+// Title returns the profile's name, marked when it is the active one.
+func (pi profileItem) Title() string {
+	title := pi.name
+	if title == "" {
+		title = "(none)"
+	}
+	if pi.active {
+		title = "✓ " + title
+	}
+	return title
+}
+
+// Description returns the profile's description followed by what it adds to a run.
+func (pi profileItem) Description() string {
+	if pi.name == "" {
+		return "Only the test settings of the configuration"
+	}
+	var parts []string
+	if pi.profile.Description != "" {
+		parts = append(parts, pi.profile.Description)
+	}
+	if len(pi.profile.Flags) > 0 {
+		parts = append(parts, "flags: "+strings.Join(pi.profile.Flags, " "))
+	}
+	if len(pi.profile.Env) > 0 {
+		parts = append(parts, "env: "+strings.Join(slices.Sorted(maps.Keys(pi.profile.Env)), ", "))
+	}
+	if len(pi.profile.Tags) > 0 {
+		parts = append(parts, "tags: "+strings.Join(pi.profile.Tags, ","))
+	}
+	return strings.Join(parts, " — ")
+}
+
+// FilterValue returns the string to filter on.
+func (pi profileItem) FilterValue() string {
+	return pi.name + " " + pi.profile.Description
+}
+
+// openProfilePickerMsg signals an intent to pick the profile of the next runs.
+type openProfilePickerMsg struct{}
+
+// selectProfileMsg carries the profile picked for the next runs, "" for none.
+type selectProfileMsg struct{ name string }
+
+// backFromProfilesMsg signals to leave the profile picker without changing the profile.
+type backFromProfilesMsg struct{}
+
+// ProfileModel lists the profiles of the configuration to pick the one the runs use.
+type ProfileModel struct {
+	list   list.Model
+	keys   *ProfileKeyMap // Also read by the list's help, hence a pointer
+	styles *AppStyles
+	logger *log.Logger
+}
+
+// NewProfileModel creates a new instance of the ProfileModel.
+func NewProfileModel(delegate *list.DefaultDelegate, logger *log.Logger, styles *AppStyles) ProfileModel {
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.Title = "Run Profiles"
+	l.Styles.Title = styles.ListHeader
+	l.Styles.FilterPrompt = styles.ListFilterPrompt
+	l.Styles.FilterCursor = styles.ListFilterCursor
+	l.Styles.PaginationStyle = styles.ListPagination
+	l.Styles.HelpStyle = styles.Help
+	releaseListKeys(&l)
+
+	keys := DefaultProfileKeyMap()
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.Select, keys.Back}
+	}
+
+	return ProfileModel{
+		list:   l,
+		keys:   &keys,
+		styles: styles,
+		logger: logger,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m ProfileModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the ProfileModel.
+func (m ProfileModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		switch {
+		case key.Matches(msg, m.keys.Select):
+			if item, ok := m.list.SelectedItem().(profileItem); ok {
+				m.logger.Debugf("ProfileModel: Selecting profile %q.", item.name)
+				return m, func() tea.Msg { return selectProfileMsg{name: item.name} }
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Back):
+			m.logger.Debug("ProfileModel: Leaving profile picker.")
+			return m, func() tea.Msg { return backFromProfilesMsg{} }
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the list of profiles.
+func (m ProfileModel) View() string {
+	return m.list.View()
+}
+
+func (m *ProfileModel) setSize(width, height int) {
+	m.list.SetSize(width, height)
+}
+
+// SetProfiles replaces the listed profiles, sorted by name after the entry for no profile,
+// and selects the active one.
+func (m *ProfileModel) SetProfiles(profiles map[string]config.ProfileConfig, active string) tea.Cmd {
+	items := []list.Item{profileItem{active: active == ""}}
+	selected := 0
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		if name == active {
+			selected = len(items)
+		}
+		items = append(items, profileItem{name: name, profile: profiles[name], active: name == active})
+	}
+	m.list.Title = fmt.Sprintf("Run Profiles (%d)", len(profiles))
+	cmd := m.list.SetItems(items)
+	m.list.Select(selected)
+	return cmd
+}
+
+// HelpView returns a string with help for the profile picker's keybindings.
+func (m ProfileModel) HelpView() string {
+	return fmt.Sprintf("%s → %s, / → filter, %s → %s",
+		m.keys.Select.Help().Key, m.keys.Select.Help().Desc, m.keys.Back.Help().Key, m.keys.Back.Help().Desc)
+}
@@ -0,0 +1,38 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// QueueKeyMap defines keybindings for the run queue panel.
+type QueueKeyMap struct {
+	MoveUp    key.Binding `category:"run"`
+	MoveDown  key.Binding `category:"run"`
+	Cancel    key.Binding `category:"run"`
+	CancelAll key.Binding `category:"run"`
+	Close     key.Binding `category:"general"`
+}
+
+// DefaultQueueKeyMap returns a new QueueKeyMap with default keybindings.
+func DefaultQueueKeyMap() QueueKeyMap {
+	return QueueKeyMap{
+		MoveUp: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "run earlier"),
+		),
+		MoveDown: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "run later"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("x", "delete"),
+			key.WithHelp("x/del", "cancel run"),
+		),
+		CancelAll: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "cancel all"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "q", "b"),
+			key.WithHelp("esc/q/b", "close"),
+		),
+	}
+}
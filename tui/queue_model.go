@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// queueItem is a list.Item for a queued run.
+type queueItem struct {
+	run      testRun
+	position int // 1 for the run that starts next
+}
+
+// Title returns the run's position and scope.
+func (qi queueItem) Title() string {
+	return fmt.Sprintf("%d. %s", qi.position, describeRunScope(qi.run.config))
+}
+
+// Description returns who queued the run, when, and its options.
+func (qi queueItem) Description() string {
+	source := "requested"
+	if qi.run.watch {
+		source = "watch mode"
+	}
+	desc := fmt.Sprintf("%s at %s", source, qi.run.queuedAt.Format("15:04:05"))
+
+	var options []string
+	if qi.run.config.Race {
+		options = append(options, "-race")
+	}
+	if qi.run.config.Coverage {
+		options = append(options, "-cover")
+	}
+	if qi.run.config.Profile != "" {
+		options = append(options, "profile "+qi.run.config.Profile)
+	}
+	if len(options) > 0 {
+		desc += " — " + strings.Join(options, ", ")
+	}
+	return desc
+}
+
+// FilterValue returns the string to filter on.
+func (qi queueItem) FilterValue() string {
+	return describeRunScope(qi.run.config)
+}
+
+// moveQueuedRunMsg signals an intent to move a queued run by delta places, earlier if negative.
+type moveQueuedRunMsg struct{ id, delta int }
+
+// cancelQueuedRunMsg signals an intent to drop a queued run.
+type cancelQueuedRunMsg struct{ id int }
+
+// cancelQueueMsg signals an intent to drop every queued run.
+type cancelQueueMsg struct{}
+
+// closeQueueMsg signals to close the run queue panel.
+type closeQueueMsg struct{}
+
+// startQueuedRunMsg signals that the first queued run may start, if nothing runs anymore.
+type startQueuedRunMsg struct{}
+
+// QueueModel lists the runs waiting for the run in progress, to reorder or cancel them.
+// MainModel owns the queue; the model only shows it and reports what to change.
+type QueueModel struct {
+	list   list.Model
+	keys   *QueueKeyMap // Also read by the list's help, hence a pointer
+	styles *AppStyles
+	logger *log.Logger
+}
+
+// NewQueueModel creates a new instance of the QueueModel.
+func NewQueueModel(delegate *list.DefaultDelegate, logger *log.Logger, styles *AppStyles) QueueModel {
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.Title = "Run Queue"
+	l.Styles.Title = styles.ListHeader
+	l.Styles.FilterPrompt = styles.ListFilterPrompt
+	l.Styles.FilterCursor = styles.ListFilterCursor
+	l.Styles.PaginationStyle = styles.ListPagination
+	l.Styles.HelpStyle = styles.Help
+	l.SetStatusBarItemName("queued run", "queued runs")
+	releaseListKeys(&l)
+
+	keys := DefaultQueueKeyMap()
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.MoveUp, keys.MoveDown, keys.Cancel, keys.CancelAll, keys.Close}
+	}
+
+	return QueueModel{
+		list:   l,
+		keys:   &keys,
+		styles: styles,
+		logger: logger,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m QueueModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the QueueModel.
+func (m QueueModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		item, selected := m.list.SelectedItem().(queueItem)
+		switch {
+		case key.Matches(msg, m.keys.MoveUp), key.Matches(msg, m.keys.MoveDown):
+			if !selected {
+				return m, nil
+			}
+			delta := 1
+			if key.Matches(msg, m.keys.MoveUp) {
+				delta = -1
+			}
+			m.logger.Debugf("QueueModel: Moving run %d by %d.", item.run.id, delta)
+			return m, func() tea.Msg { return moveQueuedRunMsg{id: item.run.id, delta: delta} }
+		case key.Matches(msg, m.keys.Cancel):
+			if !selected {
+				return m, nil
+			}
+			m.logger.Debugf("QueueModel: Cancelling run %d.", item.run.id)
+			return m, func() tea.Msg { return cancelQueuedRunMsg{id: item.run.id} }
+		case key.Matches(msg, m.keys.CancelAll):
+			m.logger.Debug("QueueModel: Cancelling every queued run.")
+			return m, func() tea.Msg { return cancelQueueMsg{} }
+		case key.Matches(msg, m.keys.Close):
+			m.logger.Debug("QueueModel: Closing run queue.")
+			return m, func() tea.Msg { return closeQueueMsg{} }
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the list of queued runs.
+func (m QueueModel) View() string {
+	return m.list.View()
+}
+
+func (m *QueueModel) setSize(width, height int) {
+	m.list.SetSize(width, height)
+}
+
+// SetRuns replaces the listed runs and selects the run with the given id, or keeps the
+// selection where it was if it isn't queued.
+func (m *QueueModel) SetRuns(runs []testRun, selectID int) tea.Cmd {
+	items := make([]list.Item, len(runs))
+	selected := min(m.list.Index(), max(len(runs)-1, 0))
+	for i, run := range runs {
+		items[i] = queueItem{run: run, position: i + 1}
+		if run.id == selectID {
+			selected = i
+		}
+	}
+	m.list.Title = fmt.Sprintf("Run Queue (%d waiting)", len(runs))
+	cmd := m.list.SetItems(items)
+	m.list.Select(selected)
+	return cmd
+}
+
+// HelpView returns a string with help for the run queue's keybindings.
+func (m QueueModel) HelpView() string {
+	return fmt.Sprintf("%s → %s, %s → %s, %s → %s, %s → %s, %s → %s",
+		m.keys.MoveUp.Help().Key, m.keys.MoveUp.Help().Desc, m.keys.MoveDown.Help().Key, m.keys.MoveDown.Help().Desc,
+		m.keys.Cancel.Help().Key, m.keys.Cancel.Help().Desc, m.keys.CancelAll.Help().Key, m.keys.CancelAll.Help().Desc,
+		m.keys.Close.Help().Key, m.keys.Close.Help().Desc)
+}
@@ -0,0 +1,315 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gdd/history"
+	"gdd/parser"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// diffLayout selects how the report shows the values compared by failed assertions.
+type diffLayout int
+
+const (
+	diffUnified    diffLayout = iota // Expected and actual lines interleaved, like `diff -u`
+	diffSideBySide                   // Expected on the left, actual on the right
+)
+
+func (l diffLayout) String() string {
+	if l == diffSideBySide {
+		return "side by side"
+	}
+	return "unified"
+}
+
+// diffPlaceholder marks where the diff of an assertion goes in the Markdown of the report.
+// Glamour renders it as a paragraph of its own, which renderSections replaces with the
+// diff: colors and columns don't survive Markdown.
+const diffPlaceholder = "gdd-assertion-diff-%d"
+
+var diffPlaceholderRe = regexp.MustCompile(`^\s*gdd-assertion-diff-(\d+)\s*$`)
+
+// Rendering of long lines: unchanged text around a change is cut down to diffElideContext
+// characters on each side when the line doesn't fit.
+const (
+	diffElideContext = 20
+	diffMinWidth     = 40   // Narrowest width diffs are rendered at, even in a narrower window
+	diffMargin       = "  " // Left margin of glamour's document style, to line up with the text
+)
+
+// writeTestOutput writes the output of a test as log blocks, with the failures of equality
+// assertions replaced by a diff of the values they compared.
+func (m *ReportModel) writeTestOutput(md *strings.Builder, lines []string, assertions []*parser.Assertion) {
+	writeLog := func(lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		md.WriteString("```log\n")
+		for _, line := range lines {
+			md.WriteString(strings.TrimRight(line, "\r\n") + "\n")
+		}
+		md.WriteString("```\n\n")
+	}
+
+	next := 0
+	for _, a := range assertions {
+		if a.Start < next || a.End > len(lines) {
+			continue // Not from these lines
+		}
+		writeLog(lines[next:a.Start])
+
+		title := a.Message
+		if title == "" {
+			title = "Values differ"
+		}
+		md.WriteString(fmt.Sprintf("**%s** `%s` *(%s)*\n\n", strings.ReplaceAll(title, "*", "\\*"), a.Location, a.Kind))
+		md.WriteString(fmt.Sprintf(diffPlaceholder+"\n\n", len(m.diffs)))
+		m.diffs = append(m.diffs, a)
+		next = a.End
+	}
+	writeLog(lines[next:])
+}
+
+// expandDiffs replaces the diff placeholders among rendered lines with the diffs.
+func (m *ReportModel) expandDiffs(lines []string) []string {
+	var out []string
+	for _, line := range lines {
+		match := diffPlaceholderRe.FindStringSubmatch(ansi.Strip(line))
+		if match == nil {
+			out = append(out, line)
+			continue
+		}
+		i, _ := strconv.Atoi(match[1])
+		if i >= len(m.diffs) {
+			out = append(out, line)
+			continue
+		}
+		for _, row := range m.renderDiff(m.diffs[i]) {
+			out = append(out, diffMargin+row)
+		}
+	}
+	return out
+}
+
+// diffLine is a line of the diff of an assertion.
+type diffLine struct {
+	op   byte // '-' expected, '+' actual, ' ' both, '@' hunk header
+	text string
+	pair int // Index of the line on the other side it replaces, -1 if none
+}
+
+// diffSegment is a piece of a line rendered in one style.
+type diffSegment struct {
+	text  string
+	style lipgloss.Style
+}
+
+// renderDiff renders the diff of an assertion's values in the current layout.
+func (m *ReportModel) renderDiff(a *parser.Assertion) []string {
+	diff := a.Diff
+	if len(diff) == 0 {
+		diff = history.DiffLines(strings.Split(a.Expected, "\n"), strings.Split(a.Actual, "\n"))
+	}
+	lines := pairDiffLines(diff)
+
+	width := m.viewport.Width - m.viewport.Style.GetHorizontalFrameSize() - 2*len(diffMargin)
+	width = max(width, diffMinWidth)
+	if m.diffLayout == diffSideBySide {
+		return m.renderSideBySide(lines, width)
+	}
+	return m.renderUnified(lines, width)
+}
+
+// renderUnified renders a diff with the removed and added lines interleaved.
+func (m *ReportModel) renderUnified(lines []diffLine, width int) []string {
+	rows := []string{
+		m.styles.DiffRemoved.Render("--- expected"),
+		m.styles.DiffAdded.Render("+++ actual"),
+	}
+	for i, line := range lines {
+		if line.op == '@' {
+			rows = append(rows, m.styles.DiffHunk.Render(limitString(line.text, width)))
+			continue
+		}
+		marker := diffSegment{text: string(line.op) + " ", style: m.styles.DiffContext}
+		switch line.op {
+		case '-':
+			marker.style = m.styles.DiffRemoved
+		case '+':
+			marker.style = m.styles.DiffAdded
+		}
+		for j, row := range wrapSegments(m.diffSegments(lines, i, width-2), width-2) {
+			if j == 0 {
+				rows = append(rows, marker.style.Render(marker.text)+row)
+			} else {
+				rows = append(rows, "  "+row) // Continuation of a wrapped line
+			}
+		}
+	}
+	return rows
+}
+
+// renderSideBySide renders a diff with the expected value on the left and the actual one on the right.
+func (m *ReportModel) renderSideBySide(lines []diffLine, width int) []string {
+	separator := m.styles.DiffHunk.Render(" │ ")
+	col := (width - lipgloss.Width(separator)) / 2
+
+	rows := []string{padCell(m.styles.DiffRemoved.Render("expected"), col) + separator + m.styles.DiffAdded.Render("actual")}
+	cell := func(i int) []string {
+		if i < 0 {
+			return nil
+		}
+		return wrapSegments(m.diffSegments(lines, i, col), col)
+	}
+
+	done := make(map[int]bool) // Added lines already shown next to the line they replace
+	for i, line := range lines {
+		var left, right []string
+		switch {
+		case done[i]:
+			continue
+		case line.op == '@':
+			rows = append(rows, m.styles.DiffHunk.Render(limitString(line.text, width)))
+			continue
+		case line.op == ' ':
+			left, right = cell(i), cell(i)
+		case line.op == '-':
+			left, right = cell(i), cell(line.pair)
+			done[line.pair] = true
+		default:
+			right = cell(i)
+		}
+		for j := 0; j < max(len(left), len(right)); j++ {
+			var l, r string
+			if j < len(left) {
+				l = left[j]
+			}
+			if j < len(right) {
+				r = right[j]
+			}
+			rows = append(rows, padCell(l, col)+separator+r)
+		}
+	}
+	return rows
+}
+
+// pairDiffLines splits the diff lines into their operation and text, and pairs every run of
+// removed lines with the run of added lines that follows it, line by line.
+func pairDiffLines(diff []string) []diffLine {
+	lines := make([]diffLine, len(diff))
+	for i, line := range diff {
+		op, text := byte(' '), line
+		if line != "" {
+			op, text = line[0], line[1:]
+		}
+		lines[i] = diffLine{op: op, text: strings.ReplaceAll(text, "\t", "    "), pair: -1}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].op != '-' {
+			i++
+			continue
+		}
+		removed := i
+		for i < len(lines) && lines[i].op == '-' {
+			i++
+		}
+		added := i
+		for i < len(lines) && lines[i].op == '+' {
+			i++
+		}
+		for k := 0; removed+k < added && added+k < i; k++ {
+			lines[removed+k].pair = added + k
+			lines[added+k].pair = removed + k
+		}
+	}
+	return lines
+}
+
+// diffSegments returns the styled pieces of a diff line. The part of a changed line that differs
+// from its pair is highlighted, with its whitespace made visible; when the line is longer than
+// width, the unchanged text around it is cut short.
+func (m *ReportModel) diffSegments(lines []diffLine, i, width int) []diffSegment {
+	line := lines[i]
+	base, change := m.styles.DiffRemoved, m.styles.DiffRemovedChange
+	switch line.op {
+	case ' ':
+		return []diffSegment{{text: line.text, style: m.styles.DiffContext}}
+	case '+':
+		base, change = m.styles.DiffAdded, m.styles.DiffAddedChange
+	}
+	if line.pair < 0 {
+		return []diffSegment{{text: line.text, style: base}}
+	}
+
+	prefix, changed, suffix := splitChange(line.text, lines[line.pair].text)
+	if ansi.StringWidth(line.text) > width {
+		if r := []rune(prefix); len(r) > diffElideContext {
+			prefix = "…" + string(r[len(r)-diffElideContext:])
+		}
+		if r := []rune(suffix); len(r) > diffElideContext {
+			suffix = string(r[:diffElideContext]) + "…"
+		}
+	}
+	return []diffSegment{
+		{text: prefix, style: base},
+		{text: visibleWhitespace(changed), style: change},
+		{text: suffix, style: base},
+	}
+}
+
+// splitChange splits a into the prefix and suffix it has in common with b, and the part between them.
+func splitChange(a, b string) (prefix, changed, suffix string) {
+	ra, rb := []rune(a), []rune(b)
+	p := 0
+	for p < len(ra) && p < len(rb) && ra[p] == rb[p] {
+		p++
+	}
+	s := 0
+	for s < len(ra)-p && s < len(rb)-p && ra[len(ra)-1-s] == rb[len(rb)-1-s] {
+		s++
+	}
+	return string(ra[:p]), string(ra[p : len(ra)-s]), string(ra[len(ra)-s:])
+}
+
+// visibleWhitespace makes spaces visible, so that changes in whitespace can be seen.
+func visibleWhitespace(s string) string {
+	return strings.ReplaceAll(s, " ", "·")
+}
+
+// wrapSegments lays out styled segments in rows of at most width cells.
+func wrapSegments(segments []diffSegment, width int) []string {
+	var rows []string
+	var row strings.Builder
+	used := 0
+	for _, seg := range segments {
+		var chunk strings.Builder
+		for _, r := range seg.text {
+			w := ansi.StringWidth(string(r))
+			if used+w > width && used > 0 {
+				row.WriteString(seg.style.Render(chunk.String()))
+				rows = append(rows, row.String())
+				row.Reset()
+				chunk.Reset()
+				used = 0
+			}
+			chunk.WriteRune(r)
+			used += w
+		}
+		if chunk.Len() > 0 {
+			row.WriteString(seg.style.Render(chunk.String()))
+		}
+	}
+	return append(rows, row.String())
+}
+
+// padCell pads a rendered cell with spaces to width cells.
+func padCell(cell string, width int) string {
+	return cell + strings.Repeat(" ", max(width-ansi.StringWidth(cell), 0))
+}
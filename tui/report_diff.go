@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gdd/history"
+	"gdd/parser"
+)
+
+// SetDiffContent shows the comparison of two stored runs instead of a single run's report.
+func (m *ReportModel) SetDiffContent(diff *history.RunDiff, base, head *history.Entry) {
+	m.logger.Debugf("ReportModel: Setting diff content for runs %s and %s.", base.ID, head.ID)
+	m.Reset()
+	m.testRunScope = "Run Comparison"
+
+	var md strings.Builder
+	m.beginSection(&md, sectionHeading, 1, "Comparison", "")
+	md.WriteString("# Run Comparison\n\n")
+	md.WriteString("| Run  | Started | Scope | Passed | Failed | Skipped | Duration |\n")
+	md.WriteString("| ---- | ------- | ----- | ------ | ------ | ------- | -------- |\n")
+	for _, run := range []struct {
+		label string
+		entry *history.Entry
+	}{{"Base", base}, {"Head", head}} {
+		md.WriteString(fmt.Sprintf("| %s | %s | %s | %d | %d | %d | %s |\n",
+			run.label, run.entry.StartedAt.Format("2006-01-02 15:04:05"), describeRunScope(run.entry.Config),
+			run.entry.Passed, run.entry.Failed, run.entry.Skipped, run.entry.Duration.Round(time.Millisecond)))
+	}
+	md.WriteString("\n")
+
+	if diff.Unchanged() {
+		md.WriteString("**No test changed outcome between the two runs.**\n\n")
+	}
+
+	m.writeChangeList(&md, m.styles.FailIcon+" Newly Failing", diff.NewlyFailing)
+	m.writeChangeList(&md, m.styles.PassIcon+" Newly Passing", diff.NewlyPassing)
+	m.writeChangeList(&md, "🆕 New Tests", diff.Added)
+	m.writeChangeList(&md, "🗑️ Removed Tests", diff.Removed)
+	m.writeDurationChanges(&md, "🐢 Slower Tests", diff.Slower)
+	m.writeDurationChanges(&md, "🐇 Faster Tests", diff.Faster)
+	m.writePackageChanges(&md, diff.Packages)
+	m.writeStillFailing(&md, diff.StillFailing)
+
+	m.currentContent = md.String()
+	m.logger.Debugf("ReportModel: Diff Markdown content generated (length: %d characters).", len(m.currentContent))
+	m.renderContent()
+}
+
+// writeChangeList renders tests whose outcome changed, with their status in both runs.
+func (m *ReportModel) writeChangeList(md *strings.Builder, title string, changes []history.TestChange) {
+	if len(changes) == 0 {
+		return
+	}
+	m.beginSection(md, sectionHeading, 1, fmt.Sprintf("%s (%d)", title, len(changes)), "")
+	md.WriteString(fmt.Sprintf("## %s (%d)\n\n", title, len(changes)))
+	md.WriteString("| Test | Package | Base | Head |\n")
+	md.WriteString("| ---- | ------- | ---- | ---- |\n")
+	for _, c := range changes {
+		md.WriteString(fmt.Sprintf("| `%s` | `%s` | %s | %s |\n", c.Name, c.Package, testOutcome(c.Base), testOutcome(c.Head)))
+	}
+	md.WriteString("\n")
+
+	// A newly failing test's output is what explains the change.
+	for _, c := range changes {
+		if c.Head == nil || (c.Head.Status != parser.StatusFail && c.Head.Status != parser.StatusTimeout) || len(c.Head.Output) == 0 {
+			continue
+		}
+		m.beginSection(md, sectionFailure, 2, m.styles.FailIcon+" "+c.Name, c.Package)
+		md.WriteString(fmt.Sprintf("### %s `[%s]`\n\n", c.Name, c.Package))
+		m.writeTestOutput(md, c.Head.Output, c.Head.Assertions)
+	}
+}
+
+// writeDurationChanges renders tests whose duration changed significantly.
+func (m *ReportModel) writeDurationChanges(md *strings.Builder, title string, changes []history.TestChange) {
+	if len(changes) == 0 {
+		return
+	}
+	m.beginSection(md, sectionHeading, 1, fmt.Sprintf("%s (%d)", title, len(changes)), "")
+	md.WriteString(fmt.Sprintf("## %s (%d)\n\n", title, len(changes)))
+	md.WriteString("| Test | Package | Base | Head | Change |\n")
+	md.WriteString("| ---- | ------- | ---- | ---- | ------ |\n")
+	for _, c := range changes {
+		md.WriteString(fmt.Sprintf("| `%s` | `%s` | %s | %s | %s |\n", c.Name, c.Package,
+			c.Base.Duration.Round(time.Millisecond), c.Head.Duration.Round(time.Millisecond),
+			formatDurationChange(c.Base.Duration, c.Head.Duration)))
+	}
+	md.WriteString("\n")
+}
+
+// writePackageChanges renders packages whose status or duration changed.
+func (m *ReportModel) writePackageChanges(md *strings.Builder, changes []history.PackageChange) {
+	if len(changes) == 0 {
+		return
+	}
+	m.beginSection(md, sectionHeading, 1, fmt.Sprintf("📦 Package Changes (%d)", len(changes)), "")
+	md.WriteString(fmt.Sprintf("## 📦 Package Changes (%d)\n\n", len(changes)))
+	md.WriteString("| Package | Base | Head | Base Duration | Head Duration | Change |\n")
+	md.WriteString("| ------- | ---- | ---- | ------------- | ------------- | ------ |\n")
+	for _, c := range changes {
+		baseStatus, headStatus, baseDuration, headDuration, change := "—", "—", "—", "—", "—"
+		if c.Base != nil {
+			baseStatus = string(c.Base.Status)
+			baseDuration = c.Base.Duration.Round(time.Millisecond).String()
+		}
+		if c.Head != nil {
+			headStatus = string(c.Head.Status)
+			headDuration = c.Head.Duration.Round(time.Millisecond).String()
+		}
+		if c.Base != nil && c.Head != nil {
+			change = formatDurationChange(c.Base.Duration, c.Head.Duration)
+		}
+		md.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s | %s |\n", c.Package, baseStatus, headStatus, baseDuration, headDuration, change))
+	}
+	md.WriteString("\n")
+}
+
+// writeStillFailing renders tests failing in both runs, with a diff of their output.
+func (m *ReportModel) writeStillFailing(md *strings.Builder, changes []history.TestChange) {
+	if len(changes) == 0 {
+		return
+	}
+	m.beginSection(md, sectionHeading, 1, fmt.Sprintf("%s Still Failing (%d)", m.styles.FailIcon, len(changes)), "")
+	md.WriteString(fmt.Sprintf("## %s Still Failing (%d)\n\n", m.styles.FailIcon, len(changes)))
+	for _, c := range changes {
+		m.beginSection(md, sectionFailure, 2, m.styles.FailIcon+" "+c.Name, c.Package)
+		md.WriteString(fmt.Sprintf("### %s `[%s]`\n\n", c.Name, c.Package))
+		lines := c.OutputDiff()
+		if len(lines) == 0 {
+			md.WriteString("*(No output captured in either run.)*\n\n")
+			continue
+		}
+		md.WriteString("```diff\n")
+		for _, line := range lines {
+			md.WriteString(line + "\n")
+		}
+		md.WriteString("```\n\n")
+	}
+}
+
+// testOutcome describes a test's status and duration in one run, or "—" if it did not exist.
+func testOutcome(t *parser.TestResult) string {
+	if t == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%s (%s)", t.Status, t.Duration.Round(time.Millisecond))
+}
+
+// formatDurationChange formats a duration change as an absolute and relative delta, e.g. "+120ms (+35%)".
+func formatDurationChange(base, head time.Duration) string {
+	delta := (head - base).Round(time.Millisecond)
+	sign := "+"
+	if delta < 0 {
+		sign = ""
+	}
+	if base == 0 {
+		return fmt.Sprintf("%s%s", sign, delta)
+	}
+	return fmt.Sprintf("%s%s (%s%.0f%%)", sign, delta, sign, float64(head-base)/float64(base)*100)
+}
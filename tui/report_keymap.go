@@ -3,8 +3,35 @@ package tui
 import "github.com/charmbracelet/bubbles/key"
 
 // ReportKeyMap defines keybindings for the report view.
+// Actions of the "outline" layer only apply while the outline has focus, and take precedence
+// over the others then.
 type ReportKeyMap struct {
-	BackToList key.Binding
+	BackToList   key.Binding `category:"general"`
+	ViewCoverage key.Binding `category:"view"`
+	ToggleWatch  key.Binding `category:"options"`
+	TestOutput   key.Binding `category:"view"`
+	DiffLayout   key.Binding `category:"view"`
+	Export       key.Binding `category:"general"`
+	Top          key.Binding `category:"navigation"`
+	Bottom       key.Binding `category:"navigation"`
+
+	// Navigation between the sections of the report
+	NextFailure   key.Binding `category:"navigation"`
+	PrevFailure   key.Binding `category:"navigation"`
+	NextPackage   key.Binding `category:"navigation"`
+	PrevPackage   key.Binding `category:"navigation"`
+	NextSection   key.Binding `category:"navigation"`
+	PrevSection   key.Binding `category:"navigation"`
+	Search        key.Binding `category:"search"`
+	NextMatch     key.Binding `category:"search"`
+	PrevMatch     key.Binding `category:"search"`
+	ToggleOutline key.Binding `category:"view"`
+	FocusOutline  key.Binding `category:"view"`
+	OutlineUp     key.Binding `category:"navigation" layer:"outline"`
+	OutlineDown   key.Binding `category:"navigation" layer:"outline"`
+	OutlineJump   key.Binding `category:"navigation" layer:"outline"`
+	OutlineFold   key.Binding `category:"view" layer:"outline"`
+	OutlineLeave  key.Binding `category:"view" layer:"outline"`
 	// Viewport keys are handled by the viewport model itself (up, down, pgup, pgdn, etc.)
 }
 
@@ -15,5 +42,97 @@ func DefaultReportKeyMap() ReportKeyMap {
 			key.WithKeys("esc", "q", "b"), // Allow Esc, q, or b to go back
 			key.WithHelp("esc/q/b", "back to list"),
 		),
+		ViewCoverage: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "view coverage"),
+		),
+		ToggleWatch: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "cycle watch mode"),
+		),
+		TestOutput: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "test output"),
+		),
+		DiffLayout: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "unified/side by side diffs"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "export as Markdown"),
+		),
+		Top: key.NewBinding(
+			key.WithKeys("home", "g"),
+			key.WithHelp("home/g", "top"),
+		),
+		Bottom: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("end/G", "bottom"),
+		),
+		NextFailure: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next failure"),
+		),
+		PrevFailure: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "previous failure"),
+		),
+		NextPackage: key.NewBinding(
+			key.WithKeys("}"),
+			key.WithHelp("}", "next package"),
+		),
+		PrevPackage: key.NewBinding(
+			key.WithKeys("{"),
+			key.WithHelp("{", "previous package"),
+		),
+		NextSection: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "next section"),
+		),
+		PrevSection: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "previous section"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
+		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		PrevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous match"),
+		),
+		ToggleOutline: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "outline"),
+		),
+		FocusOutline: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch to outline"),
+		),
+		OutlineUp: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "previous section"),
+		),
+		OutlineDown: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "next section"),
+		),
+		OutlineJump: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "go to section"),
+		),
+		OutlineFold: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "fold section"),
+		),
+		OutlineLeave: key.NewBinding(
+			key.WithKeys("esc", "tab"),
+			key.WithHelp("esc/tab", "back to the report"),
+		),
 	}
 }
@@ -1,17 +1,23 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"gdd/coverage"
 	"gdd/parser"
+	"gdd/quarantine"
 	"gdd/runner"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 )
 
@@ -34,6 +40,30 @@ type ReportModel struct {
 	passedCount   int
 	failedCount   int
 	skippedCount  int
+	timedOutCount int
+
+	quarantine       *quarantine.List // Known-flaky tests, whose failures are reported separately
+	quarantinedCount int              // Failures of quarantined tests, not counted in failedCount
+
+	results []*parser.PackageResult // Results of the reported run, browsed in the output viewer; nil for comparisons
+
+	exportDir string // Directory the report is exported to, see config.ExportConfig
+
+	diffs      []*parser.Assertion // Failed assertions whose diffs are shown in the report, see report_assert.go
+	diffLayout diffLayout
+
+	// Navigation: the report is rendered section by section, see report_nav.go
+	sections      []reportSection
+	lines         []string     // Rendered report, one entry per line
+	showOutline   bool         // Whether the outline pane is shown left of the report
+	outlineFocus  bool         // Whether keys move through the outline instead of scrolling
+	outlineCursor int          // Selected row of the outline
+	jumpedTo      int          // Section the navigation keys jumped to last, -1 if none
+	jumpedOffset  int          // Scroll position after that jump, to tell if the report was scrolled since
+	folded        map[int]bool // Headings of the outline whose entries are hidden, by section index
+	search        textinput.Model
+	matches       []reportMatch
+	matchIdx      int // Match the report is scrolled to
 }
 
 // NewReportModel creates a new instance of the ReportModel.
@@ -41,23 +71,18 @@ func NewReportModel(logger *log.Logger, styles *AppStyles) ReportModel {
 	vp := viewport.New(0, 0) // Dimensions will be set on WindowSizeMsg
 	vp.Style = styles.ReportViewport
 
-	// Configure Glamour rendering options (optional, defaults are usually good)
-	// For example, to use a specific style:
-	// glamourRenderer, _ := glamour.NewTermRenderer(
-	// 	glamour.WithStandardStyle("dracula"), // or "dark", "light", "notty"
-	// 	glamour.WithWordWrap(0), // glamour handles word wrap, viewport width constraints it
-	// )
-	// vp.SetContentRenderer(func(content string) string {
-	// 	out, _ := glamourRenderer.Render(content)
-	// 	return out
-	// })
-	// For now, we will call glamour.Render directly when content is set.
+	search := textinput.New()
+	search.Prompt = "/"
+	search.PromptStyle = styles.ListFilterPrompt
+	search.Cursor.Style = styles.ListFilterCursor
 
 	return ReportModel{
 		viewport: vp,
 		keys:     DefaultReportKeyMap(),
 		styles:   styles,
 		logger:   logger,
+		search:   search,
+		jumpedTo: -1,
 	}
 }
 
@@ -73,42 +98,92 @@ func (m ReportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) { // Changed retur
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
 		// ReportModel usually takes the full window height or height allocated by MainModel.
-		// Header/footer for the report can be part of the content rendered into the viewport
-		// or styled lipgloss components outside the viewport managed by MainModel.
-		// For simplicity, assume ReportModel gets the full height for its viewport.
-		m.viewport.Width = m.width
-		m.viewport.Height = m.height
+		m.setSize(msg.Width, msg.Height)
 		m.logger.Debugf("ReportModel: WindowSizeMsg. Viewport W: %d, H: %d", m.viewport.Width, m.viewport.Height)
-
-		// Re-render content if it exists, as Glamour's output can depend on width.
-		if m.currentContent != "" {
-			// Determine glamour style based on app styles (basic detection)
-			glamourTheme := "dark"
-			// Example: if your base style suggests a light background, use "light" theme for glamour
-			// This is a placeholder; a more robust theme detection/configuration might be needed.
-			// if m.styles.Base.GetBackground() == lipgloss.Color("light") { // This is hypothetical
-			// 	glamourTheme = "light"
-			// }
-
-			rendered, err := glamour.Render(m.currentContent, glamourTheme)
-			if err != nil {
-				m.logger.Errorf("Error re-rendering report content for new size: %v", err)
-				m.viewport.SetContent(m.styles.Error.Render("Error re-rendering report: " + err.Error()))
-			} else {
-				m.viewport.SetContent(rendered)
-			}
-		}
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.search.Focused() {
+			return m, m.updateOnSearchInput(msg)
+		}
+		if m.outlineFocus && m.updateOnOutlineKeys(msg) {
+			return m, nil
+		}
+		if msg.String() == "esc" && m.search.Value() != "" {
+			m.logger.Debug("ReportModel: Clearing search.")
+			m.search.SetValue("")
+			m.applySearch()
+			m.setSize(m.width, m.height) // The search line is hidden
+			return m, nil
+		}
+		if key.Matches(msg, m.keys.Search) {
+			m.logger.Debug("ReportModel: 'Search' key pressed.")
+			m.outlineFocus = false
+			cmd = m.search.Focus()
+			m.setSize(m.width, m.height) // Room for the search line
+			return m, cmd
+		}
+		if key.Matches(msg, m.keys.ToggleOutline) {
+			m.showOutline = !m.showOutline
+			m.outlineFocus = m.showOutline
+			m.logger.Debugf("ReportModel: Outline toggled. Shown: %t", m.showOutline)
+			m.setSize(m.width, m.height)
+			return m, nil
+		}
+		if key.Matches(msg, m.keys.FocusOutline) && m.showOutline {
+			m.outlineFocus = true
+			return m, nil
+		}
+		if m.updateOnNavigationKeys(msg) {
+			return m, nil
+		}
+		if key.Matches(msg, m.keys.Top) {
+			m.viewport.GotoTop()
+			return m, nil
+		}
+		if key.Matches(msg, m.keys.Bottom) {
+			m.viewport.GotoBottom()
+			return m, nil
+		}
 		if key.Matches(msg, m.keys.BackToList) {
 			m.logger.Debug("ReportModel: 'Back To List' key pressed.")
 			// Send a message to MainModel to transition back to the list view.
 			return m, func() tea.Msg { return backToListMsg{} }
 		}
+		if key.Matches(msg, m.keys.ToggleWatch) {
+			m.logger.Debug("ReportModel: 'Cycle Watch Mode' key pressed.")
+			return m, func() tea.Msg { return cycleWatchMsg{} }
+		}
+		if key.Matches(msg, m.keys.ViewCoverage) {
+			m.logger.Debug("ReportModel: 'View Coverage' key pressed.")
+			return m, func() tea.Msg { return openCoverageViewMsg{} }
+		}
+		if key.Matches(msg, m.keys.DiffLayout) && len(m.diffs) > 0 {
+			m.diffLayout = (m.diffLayout + 1) % (diffSideBySide + 1)
+			m.logger.Debugf("ReportModel: Showing %s diffs.", m.diffLayout)
+			offset := m.viewport.YOffset
+			m.renderContent()
+			m.viewport.SetYOffset(offset)
+			return m, nil
+		}
+		if key.Matches(msg, m.keys.Export) {
+			m.logger.Debug("ReportModel: 'Export' key pressed.")
+			return m, exportReportCmd(m.exportDir, m.currentContent)
+		}
+		if key.Matches(msg, m.keys.TestOutput) {
+			m.logger.Debug("ReportModel: 'Test Output' key pressed.")
+			if m.results == nil {
+				return m, func() tea.Msg { return errorMsg{err: ErrNoTestOutput} }
+			}
+			// Scrolled to a test, its output is shown right away; otherwise the tests are listed.
+			var open openTestOutputMsg
+			if len(m.sections) > 0 {
+				section := m.sections[m.sectionAt(m.position())]
+				open.pkg, open.test = section.pkg, section.test
+			}
+			return m, func() tea.Msg { return open }
+		}
 		// All other keys are passed to the viewport for scrolling.
 	}
 
@@ -124,24 +199,74 @@ func (m ReportModel) View() string {
 	if m.width == 0 || m.height == 0 {
 		return m.styles.Loading.Render("Initializing report view...")
 	}
-	return m.viewport.View()
+
+	view := m.viewport.View()
+	if m.showOutline {
+		view = lipgloss.JoinHorizontal(lipgloss.Top, m.outlineView(m.outlineWidth(), m.viewport.Height), view)
+	}
+	if m.showSearch() {
+		view = lipgloss.JoinVertical(lipgloss.Left, view, m.searchView())
+	}
+	return view
 }
 
-// SetContent processes the parsed test results and updates the viewport.
-// This method is called by MainModel when test results are ready.
-func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.TestRunConfig) tea.Cmd {
-	m.logger.Debugf("ReportModel: Setting content for scope '%s', %d package results.", runCfg.Type.String(), len(results))
+// setSize lays out the outline, the report and the search line in the given size.
+func (m *ReportModel) setSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.viewport.Width = width
+	m.viewport.Height = height
+	if m.showOutline {
+		m.viewport.Width -= m.outlineWidth()
+	}
+	if m.showSearch() {
+		m.viewport.Height-- // Below the report
+	}
+	m.search.Width = width - len(m.search.Prompt) - 16 // Room for the number of matches
+}
+
+// outlineWidth is the width of the outline pane when shown.
+func (m ReportModel) outlineWidth() int {
+	return min(maxOutlineWidth, m.width/3)
+}
 
-	// Set the testRunScope based on the type of run from runCfg
+// describeRunScope returns a human-readable description of what a run tested.
+func describeRunScope(runCfg runner.TestRunConfig) string {
 	switch runCfg.Type {
 	case runner.AllTests:
-		m.testRunScope = "All Project Tests"
+		return "All Project Tests"
 	case runner.PackageTests:
-		m.testRunScope = fmt.Sprintf("Package: %s", runCfg.PackagePath)
+		return fmt.Sprintf("Package: %s", runCfg.PackagePath)
 	case runner.SingleTest:
-		m.testRunScope = fmt.Sprintf("Test: %s (in %s)", runCfg.TestName, runCfg.PackagePath)
+		return fmt.Sprintf("Test: %s (in %s)", runCfg.TestName, runCfg.PackagePath)
+	case runner.ChangedTests:
+		if runCfg.BaseRef != "" {
+			return fmt.Sprintf("Changed: %d packages affected by changes against %s", len(runCfg.Packages), runCfg.BaseRef)
+		}
+		return fmt.Sprintf("Changed: %d packages affected by saved files", len(runCfg.Packages))
+	case runner.SelectedTests:
+		return fmt.Sprintf("Tests: %s (in %s)", strings.Join(runCfg.TestNames, ", "), runCfg.PackagePath)
 	default:
-		m.testRunScope = "Unknown Test Scope"
+		return "Unknown Test Scope"
+	}
+}
+
+// SetContent processes the parsed test results and updates the viewport.
+// This method is called by MainModel when test results are ready. binaries, set for runs with
+// precompiled test binaries, splits the duration between building and running them.
+func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.TestRunConfig, cov *coverage.Report, binaries *runner.BinaryTiming) tea.Cmd {
+	m.logger.Debugf("ReportModel: Setting content for scope '%s', %d package results.", runCfg.Type.String(), len(results))
+
+	m.testRunScope = describeRunScope(runCfg)
+	m.results = results
+	if runCfg.Race {
+		m.testRunScope += " [-race]"
+	}
+	if runCfg.Coverage {
+		m.testRunScope += " [-cover]"
+	}
+	if runCfg.Profile != "" {
+		m.testRunScope += fmt.Sprintf(" [profile: %s]", runCfg.Profile)
 	}
 
 	m.totalDuration = 0
@@ -149,10 +274,16 @@ func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.
 	m.passedCount = 0
 	m.failedCount = 0
 	m.skippedCount = 0
+	m.timedOutCount = 0
+	m.quarantinedCount = 0
+	m.sections = nil
+	m.folded = nil
+	m.diffs = nil
 
 	var md strings.Builder
 
 	// --- Report Header ---
+	m.beginSection(&md, sectionHeading, 1, "Summary", "")
 	md.WriteString(fmt.Sprintf("# Test Report: %s\n\n", m.testRunScope))
 
 	// --- Overall Summary Section ---
@@ -166,14 +297,21 @@ func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.
 		m.totalDuration += pkgResult.Duration // Sum up package durations for an approximate total
 		for _, test := range pkgResult.Tests {
 			m.totalTests++
+			if (test.Status == parser.StatusFail || test.Status == parser.StatusTimeout) && m.quarantine.FailureQuarantined(pkgResult, test.Name) {
+				m.quarantinedCount++ // Reported in their own section, without failing the run
+				continue
+			}
 			switch test.Status {
 			case parser.StatusPass:
 				m.passedCount++
 			case parser.StatusFail:
 				m.failedCount++
-				if overallPackageStatus != parser.StatusFail { // A single test fail makes the package fail
+				if overallPackageStatus != parser.StatusFail && overallPackageStatus != parser.StatusTimeout { // A single test fail makes the package fail
 					overallPackageStatus = parser.StatusFail
 				}
+			case parser.StatusTimeout:
+				m.timedOutCount++
+				overallPackageStatus = parser.StatusTimeout // A timeout is the most specific failure
 			case parser.StatusSkip:
 				m.skippedCount++
 			default: // parser.StatusUnknown or parser.StatusRunning (if something went very wrong)
@@ -182,7 +320,9 @@ func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.
 			}
 		}
 		// If any package failed, the overall run is a fail.
-		if pkgResult.Status == parser.StatusFail && overallPackageStatus != parser.StatusFail {
+		if pkgResult.Status == parser.StatusTimeout {
+			overallPackageStatus = parser.StatusTimeout
+		} else if pkgResult.Status == parser.StatusFail && m.quarantine.Failed(pkgResult) && overallPackageStatus != parser.StatusFail && overallPackageStatus != parser.StatusTimeout {
 			overallPackageStatus = parser.StatusFail
 		} else if pkgResult.Status == parser.StatusSkip && overallPackageStatus == parser.StatusPass {
 			// If previous were passes, a skip makes overall skip (unless a fail occurs later)
@@ -198,6 +338,9 @@ func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.
 	case parser.StatusFail:
 		statusIcon = m.styles.FailIcon
 		statusStyle = m.styles.StatusFail
+	case parser.StatusTimeout:
+		statusIcon = m.styles.TimeoutIcon
+		statusStyle = m.styles.StatusTimeout
 	case parser.StatusSkip:
 		statusIcon = m.styles.SkipIcon
 		statusStyle = m.styles.StatusSkip
@@ -210,12 +353,33 @@ func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.
 	summaryTable += fmt.Sprintf("| %s Passed         | %-10d |\n", m.styles.PassIcon, m.passedCount)
 	summaryTable += fmt.Sprintf("| %s Failed         | %-10d |\n", m.styles.FailIcon, m.failedCount)
 	summaryTable += fmt.Sprintf("| %s Skipped        | %-10d |\n", m.styles.SkipIcon, m.skippedCount)
+	if m.timedOutCount > 0 {
+		summaryTable += fmt.Sprintf("| %s Timed Out      | %-10d |\n", m.styles.TimeoutIcon, m.timedOutCount)
+	}
+	if m.quarantinedCount > 0 {
+		summaryTable += fmt.Sprintf("| %s Quarantined    | %-10d |\n", m.styles.QuarantinedBadge, m.quarantinedCount)
+	}
 	summaryTable += fmt.Sprintf("| ⏱️ Total Duration | %-10s |\n", m.totalDuration.Round(time.Millisecond).String())
+	if binaries != nil {
+		compile := fmt.Sprintf("%s (%d built, %d cached)", binaries.Compile.Round(time.Millisecond), binaries.Built, binaries.Cached)
+		if binaries.Built == 0 {
+			compile = fmt.Sprintf("cached (%d binaries)", binaries.Cached)
+		}
+		summaryTable += fmt.Sprintf("| 🔨 Compile        | %-10s |\n", compile)
+		summaryTable += fmt.Sprintf("| 🏃 Run            | %-10s |\n", binaries.Run.Round(time.Millisecond).String())
+	}
 	md.WriteString(summaryTable)
 	md.WriteString("\n")
 
+	// --- Coverage (only present when running with coverage) ---
+	m.writeCoverageSection(&md, results, cov)
+
+	// --- Timeouts (packages whose test binary hit -timeout) ---
+	m.writeTimeoutSection(&md, results)
+
 	// --- Detailed Results Per Package ---
-	if m.failedCount > 0 {
+	if m.failedCount > 0 || m.timedOutCount > 0 {
+		m.beginSection(&md, sectionHeading, 1, "Failed Tests Details", "")
 		md.WriteString("## Failed Tests Details\n\n")
 	}
 
@@ -223,41 +387,48 @@ func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.
 		// Optionally, print package header even if it passed, for complete logs.
 		// For now, focusing on failures.
 		pkgFailed := false
-		var pkgFailures strings.Builder
 		for _, test := range pkgResult.Tests {
-			if test.Status == parser.StatusFail {
+			if (test.Status == parser.StatusFail || test.Status == parser.StatusTimeout) && !m.quarantine.FailureQuarantined(pkgResult, test.Name) {
 				pkgFailed = true
-				pkgFailures.WriteString(fmt.Sprintf("### %s %s `[%s]`\n", m.styles.FailIcon, test.Name, pkgResult.PackageName))
-				pkgFailures.WriteString(fmt.Sprintf("*Duration: %s*\n\n", test.Duration.Round(time.Millisecond)))
+				icon := m.styles.FailIcon
+				if test.Status == parser.StatusTimeout {
+					icon = m.styles.TimeoutIcon
+				}
+				m.beginSection(&md, sectionFailure, 2, icon+" "+test.Name, pkgResult.PackageName)
+				m.sections[len(m.sections)-1].test = test.Name
+				md.WriteString(fmt.Sprintf("### %s %s `[%s]`\n", icon, test.Name, pkgResult.PackageName))
+				md.WriteString(fmt.Sprintf("*Duration: %s*\n\n", test.Duration.Round(time.Millisecond)))
 				if len(test.Output) > 0 {
-					pkgFailures.WriteString("```log\n")
-					// Clean and indent output for readability
-					for _, line := range test.Output {
-						pkgFailures.WriteString(strings.TrimSpace(line) + "\n")
-					}
-					pkgFailures.WriteString("```\n\n")
+					m.writeTestOutput(&md, test.Output, test.Assertions)
 				} else {
-					pkgFailures.WriteString("*(No output captured for this failed test.)*\n\n")
+					md.WriteString("*(No output captured for this failed test.)*\n\n")
+				}
+				if test.Status == parser.StatusTimeout && pkgResult.Timeout != nil {
+					writeBlockedGoroutines(&md, pkgResult.Timeout.GoroutinesFor(test.Name))
 				}
 			}
 		}
-		if pkgFailed {
-			md.WriteString(pkgFailures.String())
-		}
 
 		// Include package summary output if it exists and the package itself failed or had issues
-		if pkgResult.Status == parser.StatusFail && len(pkgResult.SummaryOutput) > 0 && !pkgFailed {
+		if m.quarantine.Failed(pkgResult) && len(pkgResult.SummaryOutput) > 0 && !pkgFailed {
 			// If package failed but no specific test did, show summary output under package error
+			m.beginSection(&md, sectionFailure, 2, m.styles.FailIcon+" Package error: "+pkgResult.PackageName, pkgResult.PackageName)
 			md.WriteString(fmt.Sprintf("### %s Package Error `[%s]`\n\n", m.styles.FailIcon, pkgResult.PackageName))
 			md.WriteString("*This package reported an error. See output below.*\n\n")
 			md.WriteString("```log\n")
 			for _, line := range pkgResult.SummaryOutput {
-				md.WriteString(strings.TrimSpace(line) + "\n")
+				md.WriteString(strings.TrimRight(line, "\r\n") + "\n")
 			}
 			md.WriteString("```\n\n")
 		}
 	}
 
+	// --- Failures of quarantined tests, which don't fail the run ---
+	m.writeQuarantineSection(&md, results)
+
+	// --- Data Races (only present when running with -race) ---
+	m.writeRaceSection(&md, parser.GroupRaces(results))
+
 	if m.failedCount == 0 && m.totalTests > 0 {
 		md.WriteString("\n**✨ All tests passed! ✨**\n")
 	} else if m.totalTests == 0 && m.overallStatus != parser.StatusFail {
@@ -266,29 +437,218 @@ func (m *ReportModel) SetContent(results []*parser.PackageResult, runCfg runner.
 
 	m.currentContent = md.String()
 	m.logger.Debugf("ReportModel: Markdown content generated (length: %d characters).", len(m.currentContent))
+	m.renderContent()
+	return nil
+}
 
-	// Render Markdown content using Glamour.
-	// Use a specific style for Glamour if desired. "dark", "light", "notty", or a custom glamour.TermRenderer.
+// renderContent renders the Markdown in currentContent into the viewport and scrolls to the top.
+func (m *ReportModel) renderContent() {
+	// Render Markdown content using Glamour, in the style of the theme (see AppStyles.Markdown).
 	// The viewport width is important for glamour's word wrapping.
 	// Ensure m.viewport.Width is up-to-date before this.
-	glamourTheme := "dark"
-	// Basic theme detection (example, might need refinement)
-	// Check a representative style to guess if the theme is light or dark
-	// This is a simplistic way; a more robust method might involve explicit theme setting.
-	// if m.styles.Base.GetBackground() == lipgloss.Color("light") { // Hypothetical check
-	// 	 glamourTheme = "light"
-	// }
-
-	renderedContent, err := glamour.Render(m.currentContent, glamourTheme)
-	if err != nil {
+	// Sections are rendered one by one so that their position in the report is known.
+	if err := m.renderSections(); err != nil {
 		m.logger.Errorf("ReportModel: Error rendering Markdown with Glamour: %v", err)
-		m.viewport.SetContent(m.styles.Error.Render(fmt.Sprintf("Error rendering report: %v\n\nRaw Markdown:\n%s", err, m.currentContent)))
-	} else {
-		m.viewport.SetContent(renderedContent)
+		m.lines = strings.Split(m.styles.Error.Render(fmt.Sprintf("Error rendering report: %v\n\nRaw Markdown:\n%s", err, m.currentContent)), "\n")
+		m.sections = nil
 	}
+	m.outlineCursor = 0
+	m.jumpedTo = -1
+	m.applySearch() // Highlights the current search in the new report
 
 	m.viewport.GotoTop() // Reset scroll to top for the new report.
-	return nil
+}
+
+// maxCoverageFuncs limits the per-function coverage table to the least covered functions.
+const maxCoverageFuncs = 30
+
+// writeCoverageSection renders the overall coverage, a per-package table built from the
+// `coverage:` lines of each package, and the least covered functions from the profile.
+func (m *ReportModel) writeCoverageSection(md *strings.Builder, results []*parser.PackageResult, cov *coverage.Report) {
+	var reported []*parser.PackageResult
+	for _, pkgResult := range results {
+		if pkgResult.Coverage != nil {
+			reported = append(reported, pkgResult)
+		}
+	}
+	if cov == nil && len(reported) == 0 {
+		return
+	}
+
+	m.beginSection(md, sectionHeading, 1, "Coverage", "")
+	md.WriteString("## Coverage\n\n")
+	if cov != nil {
+		md.WriteString(fmt.Sprintf("**Overall: %.1f%% of statements** (%d/%d, mode: %s)\n\n", cov.Percent(), cov.Covered, cov.Total, cov.Mode))
+	}
+
+	if len(reported) > 0 {
+		md.WriteString("| Package | Coverage |\n")
+		md.WriteString("| ------- | -------- |\n")
+		for _, pkgResult := range reported {
+			md.WriteString(fmt.Sprintf("| %s | %.1f%% |\n", pkgResult.PackageName, *pkgResult.Coverage))
+		}
+		md.WriteString("\n")
+	}
+
+	if cov == nil || len(cov.Funcs) == 0 {
+		return
+	}
+	funcs := cov.LeastCovered(maxCoverageFuncs)
+	m.beginSection(md, sectionEntry, 2, "Least covered functions", "")
+	md.WriteString(fmt.Sprintf("### Least Covered Functions (%d of %d)\n\n", len(funcs), len(cov.Funcs)))
+	md.WriteString("| Function | File | Coverage | Statements |\n")
+	md.WriteString("| -------- | ---- | -------- | ---------- |\n")
+	for _, f := range funcs {
+		md.WriteString(fmt.Sprintf("| `%s` | %s:%d | %.1f%% | %d/%d |\n", f.Name, f.File, f.StartLine, f.Percent(), f.Covered, f.Total))
+	}
+	md.WriteString("\n")
+}
+
+// writeQuarantineSection lists the failures of quarantined tests, with their output.
+func (m *ReportModel) writeQuarantineSection(md *strings.Builder, results []*parser.PackageResult) {
+	if m.quarantinedCount == 0 {
+		return
+	}
+
+	m.beginSection(md, sectionHeading, 1, fmt.Sprintf("Quarantined Failures (%d)", m.quarantinedCount), "")
+	md.WriteString(fmt.Sprintf("## Quarantined Failures (%d)\n\n", m.quarantinedCount))
+	md.WriteString(fmt.Sprintf("*These tests are listed in `%s` as known to be flaky; their failures don't fail the run.*\n\n", quarantine.DefaultFile))
+	for _, pkgResult := range results {
+		for _, test := range pkgResult.Tests {
+			if test.Status != parser.StatusFail && test.Status != parser.StatusTimeout {
+				continue
+			}
+			if !m.quarantine.FailureQuarantined(pkgResult, test.Name) {
+				continue
+			}
+			m.beginSection(md, sectionEntry, 2, m.styles.QuarantinedIcon+" "+test.Name, pkgResult.PackageName)
+			m.sections[len(m.sections)-1].test = test.Name
+			md.WriteString(fmt.Sprintf("### %s %s `[%s]`\n", m.styles.QuarantinedBadge, test.Name, pkgResult.PackageName))
+			md.WriteString(fmt.Sprintf("*Duration: %s*\n\n", test.Duration.Round(time.Millisecond)))
+			m.writeTestOutput(md, test.Output, test.Assertions)
+		}
+	}
+}
+
+// writeTimeoutSection lists, for every package that timed out, the tests that were
+// still running when the timeout fired.
+func (m *ReportModel) writeTimeoutSection(md *strings.Builder, results []*parser.PackageResult) {
+	var timedOut []*parser.PackageResult
+	for _, pkgResult := range results {
+		if pkgResult.Timeout != nil {
+			timedOut = append(timedOut, pkgResult)
+		}
+	}
+	if len(timedOut) == 0 {
+		return
+	}
+
+	m.beginSection(md, sectionHeading, 1, "Timeouts", "")
+	md.WriteString("## Timeouts\n\n")
+	for _, pkgResult := range timedOut {
+		m.beginSection(md, sectionEntry, 2, m.styles.TimeoutIcon+" "+pkgResult.PackageName, pkgResult.PackageName)
+		md.WriteString(fmt.Sprintf("### %s `%s` timed out after %s\n\n", m.styles.TimeoutIcon, pkgResult.PackageName, pkgResult.Timeout.After))
+		if len(pkgResult.Timeout.RunningTests) == 0 {
+			md.WriteString("*(No running tests were listed.)*\n\n")
+			continue
+		}
+		md.WriteString("Running tests:\n\n")
+		for _, rt := range pkgResult.Timeout.RunningTests {
+			md.WriteString(fmt.Sprintf("- **%s** (running for %s, %d goroutines)\n", rt.Name, rt.Elapsed, len(pkgResult.Timeout.GoroutinesFor(rt.Name))))
+		}
+		md.WriteString("\n")
+	}
+}
+
+// writeBlockedGoroutines renders the goroutines belonging to a timed-out test, with their
+// blocking state and stack. Frames outside the runtime and testing packages are emphasized,
+// as that is usually where the test is stuck.
+func writeBlockedGoroutines(md *strings.Builder, goroutines []parser.Goroutine) {
+	if len(goroutines) == 0 {
+		return
+	}
+
+	md.WriteString("**Blocked goroutines:**\n\n")
+	for _, g := range goroutines {
+		md.WriteString(fmt.Sprintf("- goroutine %d `[%s]`\n", g.ID, g.State))
+		for _, frame := range g.Stack {
+			if parser.IsStdlibFrame(frame) {
+				md.WriteString(fmt.Sprintf("  - %s\n", formatStackFrame(frame)))
+			} else {
+				md.WriteString(fmt.Sprintf("  - **%s**\n", formatStackFrame(frame)))
+			}
+		}
+		if g.CreatedBy != nil {
+			md.WriteString(fmt.Sprintf("  - *created by* %s\n", formatStackFrame(*g.CreatedBy)))
+		}
+	}
+	md.WriteString("\n")
+}
+
+// writeRaceSection renders deduplicated data races as a side-by-side table of the
+// conflicting accesses ("Read at" / "Previous write at"), followed by the creation
+// sites of the goroutines involved.
+func (m *ReportModel) writeRaceSection(md *strings.Builder, groups []*parser.RaceGroup) {
+	if len(groups) == 0 {
+		return
+	}
+
+	m.beginSection(md, sectionHeading, 1, fmt.Sprintf("Data Races (%d unique)", len(groups)), "")
+	md.WriteString(fmt.Sprintf("## Data Races (%d unique)\n\n", len(groups)))
+
+	for i, group := range groups {
+		report := group.Report
+		m.beginSection(md, sectionEntry, 2, fmt.Sprintf("Race #%d", i+1), "")
+		md.WriteString(fmt.Sprintf("### %s Race #%d (seen %d×)\n\n", m.styles.FailIcon, i+1, group.Count))
+		md.WriteString(fmt.Sprintf("*Detected in: %s*\n\n", strings.Join(group.Tests, ", ")))
+
+		if len(report.Accesses) == 0 {
+			// Could not make sense of the block; fall back to the raw lines.
+			md.WriteString("```log\n" + strings.Join(report.Raw, "\n") + "\n```\n\n")
+			continue
+		}
+
+		// Header row: one column per access, e.g. "Write at (goroutine 8)" | "Previous read at (goroutine 7)".
+		depth := 0
+		var header, divider []string
+		for _, access := range report.Accesses {
+			header = append(header, fmt.Sprintf("%s at (goroutine %s)", access.Kind, access.Goroutine))
+			divider = append(divider, "---")
+			depth = max(depth, len(access.Stack))
+		}
+		md.WriteString("| " + strings.Join(header, " | ") + " |\n")
+		md.WriteString("| " + strings.Join(divider, " | ") + " |\n")
+		for row := 0; row < depth; row++ {
+			var cells []string
+			for _, access := range report.Accesses {
+				cell := ""
+				if row < len(access.Stack) {
+					cell = formatStackFrame(access.Stack[row])
+				}
+				cells = append(cells, cell)
+			}
+			md.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		md.WriteString("\n")
+
+		for _, g := range report.Goroutines {
+			md.WriteString(fmt.Sprintf("**Goroutine %s (%s) created at:**\n\n", g.ID, g.State))
+			for _, frame := range g.CreatedAt {
+				md.WriteString("- " + formatStackFrame(frame) + "\n")
+			}
+			md.WriteString("\n")
+		}
+	}
+}
+
+// formatStackFrame renders a stack frame compactly for race tables and goroutine dumps,
+// using only the base name of the file to keep the columns narrow.
+func formatStackFrame(frame parser.StackFrame) string {
+	function := "`" + strings.ReplaceAll(frame.Function, "|", "\\|") + "`"
+	if frame.File == "" {
+		return function
+	}
+	return fmt.Sprintf("%s %s:%d", function, filepath.Base(frame.File), frame.Line)
 }
 
 // Reset clears the content of the report view, preparing for a new report or view change.
@@ -298,18 +658,71 @@ func (m *ReportModel) Reset() {
 	m.viewport.SetContent("")
 	m.viewport.GotoTop()
 	m.testRunScope = ""
+	m.results = nil
 	m.overallStatus = parser.StatusUnknown
 	m.totalDuration = 0
 	m.totalTests = 0
 	m.passedCount = 0
 	m.failedCount = 0
 	m.skippedCount = 0
+	m.timedOutCount = 0
+	m.quarantinedCount = 0
+	m.diffs = nil
+	m.sections = nil
+	m.lines = nil
+	m.matches = nil
+	m.folded = nil
+	m.outlineCursor = 0
+}
+
+// SetQuarantine sets the list of quarantined tests used by the next reports.
+func (m *ReportModel) SetQuarantine(q *quarantine.List) {
+	m.quarantine = q
 }
 
 // HelpView returns a string containing the help information for the report view.
 func (m ReportModel) HelpView() string {
 	var helpItems []string
 	helpItems = append(helpItems, m.keys.BackToList.Help().Key+" → "+m.keys.BackToList.Help().Desc)
-	helpItems = append(helpItems, "↑/↓/k/j/pgup/pgdn → scroll")
+	helpItems = append(helpItems, m.keys.ViewCoverage.Help().Key+" → "+m.keys.ViewCoverage.Help().Desc)
+	helpItems = append(helpItems, m.keys.ToggleWatch.Help().Key+" → "+m.keys.ToggleWatch.Help().Desc)
+	helpItems = append(helpItems, m.keys.TestOutput.Help().Key+" → "+m.keys.TestOutput.Help().Desc)
+	if len(m.diffs) > 0 {
+		helpItems = append(helpItems, m.keys.DiffLayout.Help().Key+" → "+m.keys.DiffLayout.Help().Desc)
+	}
+	helpItems = append(helpItems, m.keys.Export.Help().Key+" → "+m.keys.Export.Help().Desc)
+	helpItems = append(helpItems,
+		m.keys.NextFailure.Help().Key+"/"+m.keys.PrevFailure.Help().Key+" → failures",
+		m.keys.NextPackage.Help().Key+"/"+m.keys.PrevPackage.Help().Key+" → packages",
+		m.keys.NextSection.Help().Key+"/"+m.keys.PrevSection.Help().Key+" → sections")
+	helpItems = append(helpItems, m.keys.Search.Help().Key+" → "+m.keys.Search.Help().Desc)
+	helpItems = append(helpItems, m.keys.ToggleOutline.Help().Key+" → "+m.keys.ToggleOutline.Help().Desc)
 	return strings.Join(helpItems, ", ")
 }
+
+// reportExportedMsg reports the outcome of exporting the report.
+type reportExportedMsg struct {
+	path string
+	err  error
+}
+
+// exportReportCmd writes the Markdown of the report to a new file in dir, created if needed.
+// An empty dir means the working directory.
+func exportReportCmd(dir, content string) tea.Cmd {
+	return func() tea.Msg {
+		if content == "" {
+			return reportExportedMsg{err: errors.New("the report is empty")}
+		}
+		if dir == "" {
+			dir = "."
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return reportExportedMsg{err: err}
+		}
+		path := filepath.Join(dir, "gdd-report-"+time.Now().Format("20060102-150405")+".md")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return reportExportedMsg{err: err}
+		}
+		return reportExportedMsg{path: path}
+	}
+}
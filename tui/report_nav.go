@@ -0,0 +1,404 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// reportSectionKind tells the navigation keys what a section of the report is about.
+type reportSectionKind int
+
+const (
+	sectionHeading reportSectionKind = iota // A top-level section, e.g. "Summary" or "Data Races"
+	sectionFailure                          // A failed or timed-out test, or a package error
+	sectionEntry                            // Any other entry below a heading, e.g. a race or a quarantined failure
+)
+
+// reportSection is a part of the report, starting at a heading. The report is rendered section
+// by section, so that the navigation keys and the outline know the line each one starts at.
+type reportSection struct {
+	kind  reportSectionKind
+	level int    // 1 for headings, 2 for the entries below them
+	title string // Shown in the outline
+	pkg   string // Package the section is about, if any, for jumping between packages
+	test  string // Test the section is about, if any, to open its output
+
+	start  int // Byte offset of the section in the Markdown
+	offset int // First line of the section in the rendered report
+}
+
+// reportMatch is an occurrence of the search query in the rendered report.
+type reportMatch struct {
+	line       int
+	start, end int // Byte offsets in the line, without its ANSI sequences
+}
+
+// Width of the outline pane, at most a third of the report view.
+const maxOutlineWidth = 40
+
+// beginSection marks the start of a section at the current end of the Markdown.
+func (m *ReportModel) beginSection(md *strings.Builder, kind reportSectionKind, level int, title, pkg string) {
+	m.sections = append(m.sections, reportSection{kind: kind, level: level, title: title, pkg: pkg, start: md.Len()})
+}
+
+// renderSections renders the Markdown in currentContent one section at a time into lines,
+// recording where each section starts.
+func (m *ReportModel) renderSections() error {
+	if len(m.sections) == 0 || m.sections[0].start != 0 {
+		// Content written without sections; treat what comes before the first one as a section.
+		m.sections = append([]reportSection{{kind: sectionHeading, level: 1, title: "Report"}}, m.sections...)
+	}
+
+	renderer, err := m.styles.newMarkdownRenderer()
+	if err != nil {
+		return err
+	}
+
+	m.lines = m.lines[:0]
+	for i := range m.sections {
+		end := len(m.currentContent)
+		if i+1 < len(m.sections) {
+			end = m.sections[i+1].start
+		}
+		rendered, err := renderer.Render(m.currentContent[m.sections[i].start:end])
+		if err != nil {
+			return err
+		}
+		lines := m.expandDiffs(strings.Split(strings.TrimRight(rendered, "\n"), "\n"))
+		m.sections[i].offset = len(m.lines)
+		for _, line := range lines {
+			if strings.TrimSpace(ansi.Strip(line)) != "" {
+				break
+			}
+			m.sections[i].offset++ // Start at the heading, not at the margin above it
+		}
+		m.lines = append(m.lines, lines...)
+	}
+	m.logger.Debugf("ReportModel: Rendered %d sections into %d lines.", len(m.sections), len(m.lines))
+	return nil
+}
+
+// position returns the line of the report the navigation keys start from: the section
+// jumped to last, unless the report was scrolled since. Sections near the end of the
+// report can't be scrolled to the top, so the top line alone would be ambiguous.
+func (m ReportModel) position() int {
+	if m.jumpedTo >= 0 && m.jumpedTo < len(m.sections) && m.viewport.YOffset == m.jumpedOffset {
+		return m.sections[m.jumpedTo].offset
+	}
+	return m.viewport.YOffset
+}
+
+// sectionAt returns the index of the section shown at the given line of the report.
+func (m ReportModel) sectionAt(line int) int {
+	current := 0
+	for i, s := range m.sections {
+		if s.offset > line {
+			break
+		}
+		current = i
+	}
+	return current
+}
+
+// jumpToSection scrolls to the next (dir > 0) or previous (dir < 0) section accepted by match.
+// It reports whether there was one.
+func (m *ReportModel) jumpToSection(dir int, match func(i int) bool) bool {
+	top := m.position()
+	if dir > 0 {
+		for i, s := range m.sections {
+			if s.offset > top && match(i) {
+				m.scrollToSection(i)
+				return true
+			}
+		}
+		return false
+	}
+	for i := len(m.sections) - 1; i >= 0; i-- {
+		if m.sections[i].offset < top && match(i) {
+			m.scrollToSection(i)
+			return true
+		}
+	}
+	return false
+}
+
+// jumpToPackage scrolls to the first section of the next or previous package.
+func (m *ReportModel) jumpToPackage(dir int) bool {
+	current := m.sections[m.sectionAt(m.position())].pkg
+	if dir > 0 {
+		return m.jumpToSection(1, func(i int) bool {
+			return m.sections[i].pkg != "" && m.sections[i].pkg != current
+		})
+	}
+
+	// Find the closest earlier package, then the first of its consecutive sections.
+	found := -1
+	for i := m.sectionAt(m.position()) - 1; i >= 0; i-- {
+		pkg := m.sections[i].pkg
+		if found >= 0 && pkg != m.sections[found].pkg {
+			break
+		}
+		if pkg != "" && pkg != current {
+			found = i
+		}
+	}
+	if found < 0 {
+		return false
+	}
+	m.scrollToSection(found)
+	return true
+}
+
+// scrollToSection scrolls the report so that the section starts at the top, and selects it in the outline.
+func (m *ReportModel) scrollToSection(i int) {
+	m.viewport.SetYOffset(m.sections[i].offset)
+	m.jumpedTo, m.jumpedOffset = i, m.viewport.YOffset
+	for row, idx := range m.outlineRows() {
+		if idx == i {
+			m.outlineCursor = row
+		}
+	}
+}
+
+// updateOnNavigationKeys handles the keys jumping between sections. It reports whether the key was handled.
+func (m *ReportModel) updateOnNavigationKeys(msg tea.KeyMsg) bool {
+	if len(m.sections) == 0 {
+		return false
+	}
+	isFailure := func(i int) bool { return m.sections[i].kind == sectionFailure }
+	isHeading := func(i int) bool { return m.sections[i].level == 1 }
+
+	var found bool
+	switch {
+	case key.Matches(msg, m.keys.NextFailure):
+		found = m.jumpToSection(1, isFailure)
+	case key.Matches(msg, m.keys.PrevFailure):
+		found = m.jumpToSection(-1, isFailure)
+	case key.Matches(msg, m.keys.NextPackage):
+		found = m.jumpToPackage(1)
+	case key.Matches(msg, m.keys.PrevPackage):
+		found = m.jumpToPackage(-1)
+	case key.Matches(msg, m.keys.NextSection):
+		found = m.jumpToSection(1, isHeading)
+	case key.Matches(msg, m.keys.PrevSection):
+		found = m.jumpToSection(-1, isHeading)
+	case key.Matches(msg, m.keys.NextMatch):
+		found = m.jumpToMatch(1)
+	case key.Matches(msg, m.keys.PrevMatch):
+		found = m.jumpToMatch(-1)
+	default:
+		return false
+	}
+	if !found {
+		m.logger.Debugf("ReportModel: Nothing to jump to for key %q.", msg.String())
+	}
+	return true
+}
+
+// --- Outline ---
+
+// outlineRows returns the indexes of the sections shown in the outline, skipping the entries of folded headings.
+func (m ReportModel) outlineRows() []int {
+	var rows []int
+	folded := false
+	for i, s := range m.sections {
+		if s.level == 1 {
+			folded = m.folded[i]
+			rows = append(rows, i)
+			continue
+		}
+		if !folded {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// updateOnOutlineKeys handles keys while the outline has focus. It reports whether the key was handled.
+func (m *ReportModel) updateOnOutlineKeys(msg tea.KeyMsg) bool {
+	rows := m.outlineRows()
+	switch {
+	case key.Matches(msg, m.keys.OutlineLeave):
+		m.outlineFocus = false
+	case key.Matches(msg, m.keys.OutlineUp):
+		m.outlineCursor = max(m.outlineCursor-1, 0)
+	case key.Matches(msg, m.keys.OutlineDown):
+		m.outlineCursor = min(m.outlineCursor+1, len(rows)-1)
+	case key.Matches(msg, m.keys.OutlineJump):
+		if m.outlineCursor < len(rows) {
+			m.scrollToSection(rows[m.outlineCursor])
+		}
+	case key.Matches(msg, m.keys.OutlineFold):
+		if m.outlineCursor >= len(rows) {
+			return true
+		}
+		// Fold the heading of the selected entry, keeping the cursor on the heading.
+		i := rows[m.outlineCursor]
+		for i > 0 && m.sections[i].level > 1 {
+			i--
+		}
+		if m.folded == nil {
+			m.folded = make(map[int]bool)
+		}
+		m.folded[i] = !m.folded[i]
+		for row, idx := range m.outlineRows() {
+			if idx == i {
+				m.outlineCursor = row
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// outlineView renders the headings of the report and, unless folded, their entries.
+func (m ReportModel) outlineView(width, height int) string {
+	rows := m.outlineRows()
+	current := m.sectionAt(m.position())
+
+	// Keep the cursor visible.
+	first := 0
+	if m.outlineCursor >= height-1 {
+		first = m.outlineCursor - height + 2
+	}
+
+	lines := []string{m.styles.ListHeader.Render("Outline")}
+	for row := first; row < len(rows) && len(lines) < height; row++ {
+		i := rows[row]
+		s := m.sections[i]
+		marker := "    "
+		if s.level == 1 {
+			marker = "▾ "
+			if m.folded[i] {
+				marker = "▸ "
+			}
+		}
+		here := " "
+		if i == current {
+			here = "•" // The section at the top of the report
+		}
+		line := ansi.Truncate(here+marker+s.title, width-m.styles.ReportOutline.GetHorizontalFrameSize(), "…")
+		if m.outlineFocus && row == m.outlineCursor {
+			line = m.styles.ListSelectedItem.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	style := m.styles.ReportOutline
+	return style.Width(width - style.GetHorizontalFrameSize()).Height(height).MaxHeight(height).Render(strings.Join(lines, "\n"))
+}
+
+// --- Search ---
+
+// updateOnSearchInput handles keys while the search input has focus: the report is searched
+// as the query is typed, enter keeps the matches and esc clears them.
+func (m *ReportModel) updateOnSearchInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		m.search.Blur()
+		return nil
+	case "esc":
+		m.search.Blur()
+		m.search.SetValue("")
+		m.applySearch()
+		m.setSize(m.width, m.height) // The search line is hidden
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.applySearch()
+	m.jumpToMatch(0)
+	return cmd
+}
+
+// applySearch finds the matches of the query in the rendered report and highlights them.
+func (m *ReportModel) applySearch() {
+	m.matches = nil
+	m.matchIdx = 0
+	query := m.search.Value()
+	if query != "" {
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+		for i, line := range m.lines {
+			for _, loc := range re.FindAllStringIndex(ansi.Strip(line), -1) {
+				m.matches = append(m.matches, reportMatch{line: i, start: loc[0], end: loc[1]})
+			}
+		}
+	}
+	m.refreshViewport()
+}
+
+// jumpToMatch scrolls to the next (dir > 0) or previous (dir < 0) match, or for dir == 0 to
+// the first match at or below the top of the report. It reports whether there is a match.
+func (m *ReportModel) jumpToMatch(dir int) bool {
+	if len(m.matches) == 0 {
+		return false
+	}
+	switch {
+	case dir > 0:
+		m.matchIdx = (m.matchIdx + 1) % len(m.matches)
+	case dir < 0:
+		m.matchIdx = (m.matchIdx + len(m.matches) - 1) % len(m.matches)
+	default:
+		m.matchIdx = 0
+		for i, match := range m.matches {
+			if match.line >= m.viewport.YOffset {
+				m.matchIdx = i
+				break
+			}
+		}
+	}
+	m.refreshViewport()
+	m.viewport.SetYOffset(m.matches[m.matchIdx].line - m.viewport.Height/3)
+	return true
+}
+
+// refreshViewport sets the rendered report into the viewport, highlighting the search matches.
+// Highlighted lines lose their own styling, since matches can span styled parts of the line.
+func (m *ReportModel) refreshViewport() {
+	if len(m.matches) == 0 {
+		m.viewport.SetContent(strings.Join(m.lines, "\n"))
+		return
+	}
+
+	lines := make([]string, len(m.lines))
+	copy(lines, m.lines)
+	for i := 0; i < len(m.matches); {
+		line := m.matches[i].line
+		plain := ansi.Strip(m.lines[line])
+		var b strings.Builder
+		last := 0
+		for ; i < len(m.matches) && m.matches[i].line == line; i++ {
+			match := m.matches[i]
+			style := m.styles.SearchMatch
+			if i == m.matchIdx {
+				style = m.styles.SearchCurrent
+			}
+			b.WriteString(plain[last:match.start] + style.Render(plain[match.start:match.end]))
+			last = match.end
+		}
+		b.WriteString(plain[last:])
+		lines[line] = b.String()
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// searchView renders the search input with the number of matches.
+func (m ReportModel) searchView() string {
+	status := "no matches"
+	if len(m.matches) > 0 {
+		status = fmt.Sprintf("%d/%d", m.matchIdx+1, len(m.matches))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, m.search.View(), "  ", m.styles.Help.Render(status))
+}
+
+// showSearch reports whether the search line is shown below the report.
+func (m ReportModel) showSearch() bool {
+	return m.search.Focused() || m.search.Value() != ""
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"reflect"
+	"slices"
+	"time"
+
+	"gdd/runner"
+)
+
+// testRun is a test run requested with a key, from the tree or by watch mode. It starts right
+// away, or waits in the run queue while another run is in progress.
+type testRun struct {
+	id       int // Identifies the run in the queue, whose order changes
+	config   runner.TestRunConfig
+	status   string // Status message while it runs
+	watch    bool   // Requested by watch mode
+	cover    bool   // Opens the coverage viewer when it completes
+	queuedAt time.Time
+}
+
+// runQueue holds the runs waiting for the run in progress, in the order they start.
+type runQueue struct {
+	runs   []testRun
+	nextID int
+}
+
+// add queues run according to policy, one of config.QueuePolicies. It returns false if the run
+// was coalesced with one already queued rather than added.
+func (q *runQueue) add(run testRun, policy string) bool {
+	q.nextID++
+	run.id = q.nextID
+
+	switch policy {
+	case "replace":
+		q.runs = q.runs[:0]
+	case "coalesce":
+		for i, queued := range q.runs {
+			if reflect.DeepEqual(queued.config, run.config) {
+				return false
+			}
+			if queued.watch && run.watch && queued.config.Type == runner.ChangedTests && run.config.Type == runner.ChangedTests {
+				// The packages of both runs are tested when the first of them starts.
+				packages := slices.Concat(queued.config.Packages, run.config.Packages)
+				slices.Sort(packages)
+				q.runs[i].config.Packages = slices.Compact(packages)
+				return false
+			}
+		}
+	}
+	q.runs = append(q.runs, run)
+	return true
+}
+
+// pop removes and returns the run to start next.
+func (q *runQueue) pop() (testRun, bool) {
+	if len(q.runs) == 0 {
+		return testRun{}, false
+	}
+	run := q.runs[0]
+	q.runs = slices.Delete(q.runs, 0, 1)
+	return run, true
+}
+
+// move moves the run with the given id by delta places, toward the front if negative.
+func (q *runQueue) move(id, delta int) {
+	i := q.index(id)
+	j := i + delta
+	if i < 0 || j < 0 || j >= len(q.runs) {
+		return
+	}
+	run := q.runs[i]
+	q.runs = slices.Insert(slices.Delete(q.runs, i, i+1), j, run)
+}
+
+// remove drops the runs for which drop returns true, returning how many were dropped.
+func (q *runQueue) remove(drop func(testRun) bool) int {
+	n := len(q.runs)
+	q.runs = slices.DeleteFunc(q.runs, drop)
+	return n - len(q.runs)
+}
+
+// index returns the position of the run with the given id, or -1 if it isn't queued.
+func (q *runQueue) index(id int) int {
+	return slices.IndexFunc(q.runs, func(run testRun) bool { return run.id == id })
+}
@@ -0,0 +1,34 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// SplitKeyMap defines keybindings for the split layout of the test browser and the output pane.
+// They are handled by MainModel while the list or the tree is shown and not being filtered.
+type SplitKeyMap struct {
+	ToggleSplit key.Binding `category:"view"`
+	SwitchFocus key.Binding `category:"view"`
+	Grow        key.Binding `category:"view"`
+	Shrink      key.Binding `category:"view"`
+}
+
+// DefaultSplitKeyMap returns a new SplitKeyMap with default keybindings.
+func DefaultSplitKeyMap() SplitKeyMap {
+	return SplitKeyMap{
+		ToggleSplit: key.NewBinding(
+			key.WithKeys("|"),
+			key.WithHelp("|", "toggle split view"),
+		),
+		SwitchFocus: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch pane"),
+		),
+		Grow: key.NewBinding(
+			key.WithKeys(">"),
+			key.WithHelp(">", "widen tests"),
+		),
+		Shrink: key.NewBinding(
+			key.WithKeys("<"),
+			key.WithHelp("<", "narrow tests"),
+		),
+	}
+}
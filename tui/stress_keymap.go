@@ -0,0 +1,44 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// StressKeyMap defines keybindings for the stress view.
+type StressKeyMap struct {
+	PrevField key.Binding `category:"navigation"`
+	NextField key.Binding `category:"navigation"`
+	Decrease  key.Binding `category:"options"`
+	Increase  key.Binding `category:"options"`
+	Start     key.Binding `category:"run"`
+	Back      key.Binding `category:"general"`
+	// Viewport keys are handled by the viewport model itself (up, down, pgup, pgdn, etc.)
+}
+
+// DefaultStressKeyMap returns a new StressKeyMap with default keybindings.
+func DefaultStressKeyMap() StressKeyMap {
+	return StressKeyMap{
+		PrevField: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "previous setting"),
+		),
+		NextField: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "next setting"),
+		),
+		Decrease: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "decrease"),
+		),
+		Increase: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "increase"),
+		),
+		Start: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "start"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc", "q", "b"),
+			key.WithHelp("esc/q/b", "stop/back"),
+		),
+	}
+}
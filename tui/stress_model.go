@@ -0,0 +1,491 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gdd/runner"
+	"gdd/stress"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// stressViewMode selects what the stress view is currently showing.
+type stressViewMode int
+
+const (
+	stressSetup   stressViewMode = iota // Choosing the limits and flags of the stress run
+	stressResults                       // Showing the (live) results
+)
+
+// Choices offered by the stress setup form. The first entry is the default.
+var (
+	stressRunChoices     = []int{50, 100, 200, 500, 1000, 0, 10, 20}
+	stressFailureChoices = []int{0, 1, 3, 5, 10}
+	stressBudgetChoices  = []time.Duration{2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 30 * time.Minute, 0, 30 * time.Second, time.Minute}
+	stressCountChoices   = []int{1, 5, 10, 50}
+	stressCPUChoices     = []string{"", "1,2,4", "1,2,4,8"}
+)
+
+// Fields of the stress setup form.
+const (
+	stressFieldRuns = iota
+	stressFieldFailures
+	stressFieldBudget
+	stressFieldCount
+	stressFieldShuffle
+	stressFieldCPU
+	numStressFields // Number of fields, not a field
+)
+
+// maxStressDetails limits how many flaky or failing tests get a detailed section.
+const maxStressDetails = 20
+
+// stressHistogramBuckets is the number of bars in a test's duration histogram.
+const stressHistogramBuckets = 12
+
+// triggerStressMsg signals an intent to stress the selected test, or its whole package.
+type triggerStressMsg struct{ wholePackage bool }
+
+// startStressMsg signals an intent to start a stress run with the given configuration.
+type startStressMsg struct{ config stress.Config }
+
+// stressStreamMsg carries the channel on which a running stress run sends its messages.
+type stressStreamMsg struct{ stream <-chan tea.Msg }
+
+// stressIterationMsg carries the result of one iteration of a stress run.
+type stressIterationMsg struct{ iteration stress.Iteration }
+
+// stressDoneMsg is sent once a stress run has ended.
+type stressDoneMsg struct{ reason stress.StopReason }
+
+// cancelStressMsg signals an intent to stop the stress run, killing the running iteration.
+type cancelStressMsg struct{}
+
+// backFromStressMsg signals to leave the stress view.
+type backFromStressMsg struct{}
+
+// StressModel sets up stress runs and shows their aggregated results while they run.
+type StressModel struct {
+	viewport viewport.Model
+	keys     StressKeyMap
+	styles   *AppStyles
+	logger   *log.Logger
+
+	width  int
+	height int
+
+	mode   stressViewMode
+	target runner.TestRunConfig // The run to repeat
+
+	// Setup form state: the selected field and the index of the chosen value of each field.
+	field      int
+	runsIdx    int
+	failIdx    int
+	budgetIdx  int
+	countIdx   int
+	shuffle    bool
+	cpuIdx     int
+	result     *stress.Result
+	running    bool
+	cancelling bool
+}
+
+// NewStressModel creates a new instance of the StressModel.
+func NewStressModel(logger *log.Logger, styles *AppStyles) StressModel {
+	vp := viewport.New(0, 0)
+	vp.Style = styles.ReportViewport
+
+	return StressModel{
+		viewport: vp,
+		keys:     DefaultStressKeyMap(),
+		styles:   styles,
+		logger:   logger,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m StressModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the StressModel.
+func (m StressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if key.Matches(msg, m.keys.Back) {
+			if m.running {
+				m.logger.Debug("StressModel: Cancelling stress run.")
+				m.cancelling = true
+				m.render()
+				return m, func() tea.Msg { return cancelStressMsg{} }
+			}
+			if m.mode == stressResults {
+				m.mode = stressSetup // Back to the form, e.g. to adjust limits and run again
+				return m, nil
+			}
+			m.logger.Debug("StressModel: Leaving stress view.")
+			return m, func() tea.Msg { return backFromStressMsg{} }
+		}
+
+		if m.mode == stressSetup {
+			switch {
+			case key.Matches(msg, m.keys.PrevField):
+				m.field = (m.field + numStressFields - 1) % numStressFields
+			case key.Matches(msg, m.keys.NextField):
+				m.field = (m.field + 1) % numStressFields
+			case key.Matches(msg, m.keys.Decrease):
+				m.changeField(-1)
+			case key.Matches(msg, m.keys.Increase):
+				m.changeField(1)
+			case key.Matches(msg, m.keys.Start):
+				cfg := m.config()
+				if cfg.Runs == 0 && cfg.Budget == 0 && cfg.MaxFailures == 0 {
+					return m, func() tea.Msg {
+						return errorMsg{err: fmt.Errorf("set a number of runs, a failure limit or a time budget")}
+					}
+				}
+				return m, func() tea.Msg { return startStressMsg{config: cfg} }
+			}
+			return m, nil
+		}
+
+		if !m.running && key.Matches(msg, m.keys.Start) {
+			// Run again with the same settings, e.g. to prove a fix.
+			cfg := m.config()
+			return m, func() tea.Msg { return startStressMsg{config: cfg} }
+		}
+	}
+
+	var cmd tea.Cmd
+	if m.mode == stressResults {
+		m.viewport, cmd = m.viewport.Update(msg)
+	}
+	return m, cmd
+}
+
+// View renders the setup form or the results.
+func (m StressModel) View() string {
+	if m.mode == stressSetup {
+		return m.setupView()
+	}
+	return m.viewport.View()
+}
+
+func (m *StressModel) setSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.viewport.Width = width
+	m.viewport.Height = height
+	if m.result != nil {
+		m.render()
+	}
+}
+
+// Setup shows the setup form for stressing the given run.
+func (m *StressModel) Setup(target runner.TestRunConfig) {
+	m.target = target
+	m.mode = stressSetup
+	m.result = nil
+	m.running = false
+	m.cancelling = false
+}
+
+// Start shows the results of a stress run that is starting.
+func (m *StressModel) Start(cfg stress.Config) {
+	m.mode = stressResults
+	m.result = stress.NewResult(cfg)
+	m.running = true
+	m.cancelling = false
+	m.render()
+	m.viewport.GotoTop()
+}
+
+// AddIteration merges an iteration into the live results.
+func (m *StressModel) AddIteration(it stress.Iteration) {
+	if m.result == nil {
+		return
+	}
+	m.result.Add(it)
+	m.render()
+}
+
+// Finish records why the stress run ended.
+func (m *StressModel) Finish(reason stress.StopReason) {
+	m.running = false
+	m.cancelling = false
+	if m.result != nil {
+		m.result.Stopped = reason
+		m.render()
+	}
+}
+
+// config builds the stress configuration from the setup form.
+func (m StressModel) config() stress.Config {
+	base := m.target
+	base.Count = stressCountChoices[m.countIdx]
+	base.Shuffle = m.shuffle
+	base.CPU = stressCPUChoices[m.cpuIdx]
+	base.SlowTestThreshold = 0 // Iterations are not streamed, slow-test warnings would be dropped anyway
+	base.Coverage = false
+	return stress.Config{
+		Base:        base,
+		Runs:        stressRunChoices[m.runsIdx],
+		MaxFailures: stressFailureChoices[m.failIdx],
+		Budget:      stressBudgetChoices[m.budgetIdx],
+	}
+}
+
+// changeField moves the selected field to its previous (delta -1) or next (delta 1) choice.
+func (m *StressModel) changeField(delta int) {
+	step := func(idx, n int) int { return (idx + delta + n) % n }
+	switch m.field {
+	case stressFieldRuns:
+		m.runsIdx = step(m.runsIdx, len(stressRunChoices))
+	case stressFieldFailures:
+		m.failIdx = step(m.failIdx, len(stressFailureChoices))
+	case stressFieldBudget:
+		m.budgetIdx = step(m.budgetIdx, len(stressBudgetChoices))
+	case stressFieldCount:
+		m.countIdx = step(m.countIdx, len(stressCountChoices))
+	case stressFieldShuffle:
+		m.shuffle = !m.shuffle
+	case stressFieldCPU:
+		m.cpuIdx = step(m.cpuIdx, len(stressCPUChoices))
+	}
+}
+
+// setupView renders the setup form.
+func (m StressModel) setupView() string {
+	cfg := m.config()
+	fields := []struct{ label, value string }{
+		{"Runs (go test invocations)", limitText(cfg.Runs, "unlimited")},
+		{"Stop after failures", limitText(cfg.MaxFailures, "never")},
+		{"Time budget", durationLimitText(cfg.Budget)},
+		{"-count per run", fmt.Sprintf("%d", cfg.Base.Count)},
+		{"-shuffle", map[bool]string{true: "on", false: "off"}[cfg.Base.Shuffle]},
+		{"-cpu", map[bool]string{true: "default", false: cfg.Base.CPU}[cfg.Base.CPU == ""]},
+	}
+
+	var b strings.Builder
+	b.WriteString(m.styles.ListHeader.Render("Stress: "+describeRunScope(m.target)) + "\n\n")
+	for i, f := range fields {
+		line := fmt.Sprintf("  %-28s ‹ %s ›", f.label, f.value)
+		if i == m.field {
+			b.WriteString(m.styles.ListSelectedItem.Render(line) + "\n")
+		} else {
+			b.WriteString(m.styles.ListItem.Render(line) + "\n")
+		}
+	}
+	b.WriteString("\n" + m.styles.ListDescription.Render("The run stops at whichever limit is reached first.") + "\n")
+	return lipgloss.NewStyle().Padding(1, 2).Render(b.String())
+}
+
+// limitText formats a count limit, where zero means no limit.
+func limitText(n int, none string) string {
+	if n == 0 {
+		return none
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// durationLimitText formats a time budget, where zero means no limit.
+func durationLimitText(d time.Duration) string {
+	if d == 0 {
+		return "none"
+	}
+	return d.String()
+}
+
+// render builds the results Markdown and renders it into the viewport, keeping the scroll position.
+func (m *StressModel) render() {
+	if m.result == nil {
+		return
+	}
+	r := m.result
+	tests := r.Tests()
+
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# Stress: %s\n\n", describeRunScope(r.Config.Base)))
+
+	var flags []string
+	if r.Config.Base.Count > 1 {
+		flags = append(flags, fmt.Sprintf("-count=%d", r.Config.Base.Count))
+	}
+	if r.Config.Base.Shuffle {
+		flags = append(flags, "-shuffle=on")
+	}
+	if r.Config.Base.CPU != "" {
+		flags = append(flags, "-cpu="+r.Config.Base.CPU)
+	}
+	if r.Config.Base.Race {
+		flags = append(flags, "-race")
+	}
+	if len(flags) > 0 {
+		md.WriteString(fmt.Sprintf("*Flags: `%s`*\n\n", strings.Join(flags, " ")))
+	}
+
+	progress := fmt.Sprintf("Iteration %d", r.Iterations)
+	if r.Config.Runs > 0 {
+		progress += fmt.Sprintf("/%d", r.Config.Runs)
+	}
+	progress += fmt.Sprintf(" — %d failures — %s elapsed", r.Failures(), r.Elapsed.Round(time.Second))
+	switch {
+	case m.cancelling:
+		md.WriteString(fmt.Sprintf("**%s — stopping after this iteration...**\n\n", progress))
+	case m.running:
+		md.WriteString(fmt.Sprintf("**%s — running...**\n\n", progress))
+	default:
+		md.WriteString(fmt.Sprintf("**%s — stopped: %s.**\n\n", progress, r.Stopped))
+	}
+
+	if r.Err != nil {
+		md.WriteString(fmt.Sprintf("### %s `go test` failed without running tests\n\n```log\n%s\n%s\n```\n\n",
+			m.styles.FailIcon, r.Err, strings.Join(r.ErrOutput, "\n")))
+	}
+
+	if len(tests) > 0 {
+		md.WriteString(m.verdictLine(tests) + "\n\n")
+		md.WriteString("| Test | Verdict | Pass Rate | Runs | Failures | Median | P90 | Max |\n")
+		md.WriteString("| ---- | ------- | --------- | ---- | -------- | ------ | --- | --- |\n")
+		for _, t := range tests {
+			d := t.DurationDistribution(stressHistogramBuckets)
+			md.WriteString(fmt.Sprintf("| `%s` | %s | %.1f%% | %d | %d | %s | %s | %s |\n",
+				t.Name, m.verdictText(t.Verdict()), t.PassRate(), t.Executions(), t.Failures,
+				d.Median.Round(time.Millisecond), d.P90.Round(time.Millisecond), d.Max.Round(time.Millisecond)))
+		}
+		md.WriteString("\n")
+	}
+
+	details := 0
+	for _, t := range tests {
+		if t.Verdict() == stress.VerdictStable || details >= maxStressDetails {
+			continue
+		}
+		details++
+		m.writeStressDetails(&md, t)
+	}
+
+	renderer, err := m.styles.newMarkdownRenderer()
+	var rendered string
+	if err == nil {
+		rendered, err = renderer.Render(md.String())
+	}
+	if err != nil {
+		m.logger.Errorf("StressModel: Error rendering Markdown with Glamour: %v", err)
+		rendered = m.styles.Error.Render(fmt.Sprintf("Error rendering stress results: %v\n\n%s", err, md.String()))
+	}
+	offset := m.viewport.YOffset
+	m.viewport.SetContent(rendered)
+	m.viewport.SetYOffset(offset)
+}
+
+// verdictLine summarizes the stress run in one sentence.
+func (m *StressModel) verdictLine(tests []*stress.TestStats) string {
+	flaky, failing, executions := 0, 0, 0
+	for _, t := range tests {
+		executions += t.Executions()
+		switch t.Verdict() {
+		case stress.VerdictFlaky:
+			flaky++
+		case stress.VerdictFailing:
+			failing++
+		}
+	}
+	if flaky == 0 && failing == 0 {
+		return fmt.Sprintf("%s **No failures in %d executions of %d tests.**", m.styles.PassIcon, executions, len(tests))
+	}
+	return fmt.Sprintf("%s **%d flaky and %d consistently failing tests out of %d.**", m.styles.FailIcon, flaky, failing, len(tests))
+}
+
+// verdictText renders a verdict with the matching status style.
+func (m *StressModel) verdictText(v stress.Verdict) string {
+	switch v {
+	case stress.VerdictFlaky:
+		return m.styles.StatusTimeout.Render(string(v))
+	case stress.VerdictFailing:
+		return m.styles.StatusFail.Render(string(v))
+	default:
+		return m.styles.StatusPass.Render(string(v))
+	}
+}
+
+// writeStressDetails renders the duration distribution and failure clusters of a test.
+func (m *StressModel) writeStressDetails(md *strings.Builder, t *stress.TestStats) {
+	md.WriteString(fmt.Sprintf("## %s `[%s]`\n\n", t.Name, t.Package))
+	md.WriteString(fmt.Sprintf("Passed %d, failed %d, skipped %d — pass rate %.1f%%\n\n", t.Passes, t.Failures, t.Skips, t.PassRate()))
+
+	d := t.DurationDistribution(stressHistogramBuckets)
+	if len(t.Durations) > 1 {
+		md.WriteString(fmt.Sprintf("Durations: `%s %s %s` (min %s, median %s, p90 %s)\n\n",
+			d.Min.Round(time.Millisecond), sparkline(d.Buckets), d.Max.Round(time.Millisecond),
+			d.Min.Round(time.Millisecond), d.Median.Round(time.Millisecond), d.P90.Round(time.Millisecond)))
+	}
+
+	for i, c := range t.Clusters {
+		md.WriteString(fmt.Sprintf("### Failure %d — %d times (iterations %s)\n\n", i+1, c.Count, formatIterations(c.Iterations)))
+		if len(c.Message) == 0 {
+			md.WriteString("*(No output captured for this failure.)*\n\n")
+			continue
+		}
+		md.WriteString("```log\n" + strings.Join(c.Message, "\n") + "\n```\n\n")
+	}
+}
+
+// sparkline renders histogram buckets as a line of block characters.
+func sparkline(buckets []int) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	peak := 0
+	for _, n := range buckets {
+		peak = max(peak, n)
+	}
+	var b strings.Builder
+	for _, n := range buckets {
+		if n == 0 || peak == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(bars[(n*(len(bars)-1))/peak])
+	}
+	return b.String()
+}
+
+// formatIterations lists the iterations of a failure cluster, abbreviated when there are many.
+func formatIterations(iterations []int) string {
+	const maxShown = 10
+	var parts []string
+	seen := make(map[int]bool)
+	for _, it := range iterations {
+		if seen[it] {
+			continue
+		}
+		seen[it] = true
+		parts = append(parts, fmt.Sprintf("%d", it))
+	}
+	if len(parts) > maxShown {
+		return strings.Join(parts[:maxShown], ", ") + fmt.Sprintf(", … (%d more)", len(parts)-maxShown)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// HelpView returns a string with help for the stress view's keybindings.
+func (m StressModel) HelpView() string {
+	if m.mode == stressSetup {
+		return fmt.Sprintf("%s/%s → select, %s/%s → change, %s → start, %s → back",
+			m.keys.PrevField.Help().Key, m.keys.NextField.Help().Key, m.keys.Decrease.Help().Key, m.keys.Increase.Help().Key,
+			m.keys.Start.Help().Key, m.keys.Back.Help().Key)
+	}
+	if m.running {
+		return fmt.Sprintf("↑/↓/pgup/pgdn → scroll, %s → stop", m.keys.Back.Help().Key)
+	}
+	return fmt.Sprintf("%s → run again, ↑/↓/pgup/pgdn → scroll, %s → settings", m.keys.Start.Help().Key, m.keys.Back.Help().Key)
+}
@@ -1,6 +1,12 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"gdd/parser"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+)
 
 // AppStyles holds various lipgloss styles used throughout the application.
 type AppStyles struct {
@@ -33,47 +39,86 @@ type AppStyles struct {
 	ReportSummaryHeader lipgloss.Style // Header for the summary section (e.g., "## Summary")
 	ReportDetailsHeader lipgloss.Style // Header for the failed tests details section
 	ReportMeta          lipgloss.Style // For metadata like "Run duration: 1.2s"
+	ReportOutline       lipgloss.Style // Pane listing the sections of the report
+	SearchMatch         lipgloss.Style // Matches of the search in the report
+	SearchCurrent       lipgloss.Style // The match the report is scrolled to
 
 	// Test Status specific styles
 	StatusPass    lipgloss.Style // For "PASS" text and icons
 	StatusFail    lipgloss.Style // For "FAIL" text and icons
 	StatusSkip    lipgloss.Style // For "SKIP" text and icons
 	StatusUnknown lipgloss.Style // For tests with unknown status
+	StatusTimeout lipgloss.Style // For tests that were running when the binary timed out
 	PassIcon      string
 	FailIcon      string
 	SkipIcon      string
 	UnknownIcon   string
+	TimeoutIcon   string
+
+	// Badges shown after the name of a test, and the icon of the quarantine badge where space is short
+	FlakyBadge       string
+	QuarantinedBadge string
+	QuarantinedIcon  string
 
 	// Code blocks within report
 	ReportCodeBlock lipgloss.Style
+	Markdown        ansi.StyleConfig // Glamour style of the rendered reports, matching the theme
+
+	// Diffs of failed assertions in the report
+	DiffRemoved       lipgloss.Style // Lines of the expected value missing from the actual one
+	DiffAdded         lipgloss.Style // Lines of the actual value missing from the expected one
+	DiffRemovedChange lipgloss.Style // The part of a removed line that differs from its added counterpart
+	DiffAddedChange   lipgloss.Style // The part of an added line that differs from its removed counterpart
+	DiffContext       lipgloss.Style // Lines in both values
+	DiffHunk          lipgloss.Style // Hunk headers and column titles
+
+	// Coverage Viewer
+	CoverageCovered   lipgloss.Style // Source code inside blocks that ran
+	CoverageUncovered lipgloss.Style // Source code inside blocks that never ran
+	CoverageGutter    lipgloss.Style // Line numbers and hit counts
+	CoverageCurrent   lipgloss.Style // Marker for the currently selected uncovered block
+
+	// Split Layout
+	SplitDivider      lipgloss.Style // Line between the test browser and the output pane
+	SplitDividerFocus lipgloss.Style // The line while the output pane has focus
+
+	// Help Overlay
+	HelpGroup lipgloss.Style // Titles of the categories of keybindings
+	HelpKey   lipgloss.Style // Keys of an action
 
 	// Footer / Global Status Bar
 	FooterStatus lipgloss.Style
 }
 
-// DefaultStyles initializes and returns an AppStyles struct with sensible default styling.
+// DefaultStyles returns the styles of the dark theme.
 func DefaultStyles() *AppStyles {
+	return NewStyles(Theme{Name: "dark", Palette: darkPalette, Markdown: *glamour.DefaultStyles["dark"]})
+}
+
+// NewStyles initializes and returns an AppStyles struct styled with the colors of a theme.
+func NewStyles(t Theme) *AppStyles {
 	s := new(AppStyles)
+	p := t.Palette
 
 	// --- General UI Elements ---
 	s.Base = lipgloss.NewStyle()
-	s.Help = lipgloss.NewStyle().Faint(true)                                    // Dimmed text for help
-	s.Error = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555"))         // Bright Red for errors
-	s.Title = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("62"))   // Purple, bold
-	s.Debug = lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Faint(true) // Dim gray for debug
+	s.Help = lipgloss.NewStyle().Faint(true)                      // Dimmed text for help
+	s.Error = lipgloss.NewStyle().Foreground(p.Error)             // For errors
+	s.Title = lipgloss.NewStyle().Bold(true).Foreground(p.Accent) // Bold, in the accent color
+	s.Debug = lipgloss.NewStyle().Foreground(p.Muted).Faint(true) // Dim for debug
 
 	// --- List View ---
 	s.ListHeader = lipgloss.NewStyle().
 		Bold(true).
 		Padding(0, 1).
-		Background(lipgloss.Color("62")). // Purple background
-		Foreground(lipgloss.Color("230")) // Light foreground for contrast
+		Background(p.Accent).    // Accent background
+		Foreground(p.AccentText) // Contrasting foreground
 
 	s.ListItem = lipgloss.NewStyle().Padding(0, 0, 0, 2) // Indent items
 
 	s.ListSelectedItem = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("212")). // Bright Pink/Purple
-		Background(lipgloss.Color("237")). // Darker gray background for selection
+		Foreground(p.Selected).
+		Background(p.SelectedBackground).
 		Padding(0, 0, 0, 1).Bold(true)
 
 	s.ListDescription = lipgloss.NewStyle().
@@ -85,47 +130,118 @@ func DefaultStyles() *AppStyles {
 		PaddingLeft(1). // Adjust padding for description within the selected item style
 		Bold(false)     // Description usually isn't bold even when selected
 
-	s.ListFilterPrompt = lipgloss.NewStyle().Foreground(lipgloss.Color("205")) // Magenta/Pink
-	s.ListFilterCursor = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	s.ListFilterPrompt = lipgloss.NewStyle().Foreground(p.Highlight)
+	s.ListFilterCursor = lipgloss.NewStyle().Foreground(p.Highlight)
 
 	s.ListPagination = lipgloss.NewStyle().Faint(true).Padding(0, 1)
 	s.ListStatus = lipgloss.NewStyle().Faint(true).Padding(0, 1)
 	s.ListNoItems = lipgloss.NewStyle().Faint(true).Padding(1, 2).SetString("No tests discovered.")
 
 	// --- Spinner / Loading State ---
-	s.Spinner = lipgloss.NewStyle().Foreground(lipgloss.Color("205")) // Magenta/Pink, matches filter prompt
-	s.Loading = lipgloss.NewStyle().Padding(1, 2)                     // For "Loading tests..." text
+	s.Spinner = lipgloss.NewStyle().Foreground(p.Highlight) // Matches filter prompt
+	s.Loading = lipgloss.NewStyle().Padding(1, 2)           // For "Loading tests..." text
 
 	// --- Report View ---
 	s.ReportViewport = lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("62")). // Purple border, consistent with titles
-		Padding(0, 1)                           // Padding inside the border, before Glamour content
+		BorderForeground(p.Accent). // Consistent with titles
+		Padding(0, 1)               // Padding inside the border, before Glamour content
 
 	s.ReportTitle = s.Title.MarginBottom(1) // Reuse general title style
 	s.ReportSummaryHeader = lipgloss.NewStyle().Bold(true).MarginTop(1).MarginBottom(1)
 	s.ReportDetailsHeader = lipgloss.NewStyle().Bold(true).MarginTop(1).MarginBottom(1)
 	s.ReportMeta = lipgloss.NewStyle().Faint(true).MarginBottom(1)
+	s.ReportOutline = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, true, false, false).BorderForeground(p.Muted)
+	s.SearchMatch = lipgloss.NewStyle().Background(p.Match).Foreground(p.MatchText)
+	s.SearchCurrent = lipgloss.NewStyle().Background(p.CurrentMatch).Foreground(p.CurrentMatchText).Bold(true)
 
 	// Test Status specific styles
 	s.PassIcon = "✅"
 	s.FailIcon = "❌"
 	s.SkipIcon = "⏭️"
 	s.UnknownIcon = "❓"
+	s.TimeoutIcon = "⏰"
+	s.FlakyBadge = "🌀 flaky"
+	s.QuarantinedIcon = "🔒"
+	s.QuarantinedBadge = s.QuarantinedIcon + " quarantined"
 
-	s.StatusPass = lipgloss.NewStyle().Foreground(lipgloss.Color("#50FA7B")) // Green
-	s.StatusFail = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5555")) // Red
-	s.StatusSkip = lipgloss.NewStyle().Foreground(lipgloss.Color("#F1FA8C")) // Yellow
-	s.StatusUnknown = lipgloss.NewStyle().Faint(true)                        // Dim for unknown status
+	s.StatusPass = lipgloss.NewStyle().Foreground(p.Pass)
+	s.StatusFail = lipgloss.NewStyle().Foreground(p.Fail)
+	s.StatusSkip = lipgloss.NewStyle().Foreground(p.Skip)
+	s.StatusUnknown = lipgloss.NewStyle().Faint(true) // Dim for unknown status
+	s.StatusTimeout = lipgloss.NewStyle().Foreground(p.Timeout)
 
 	// Glamour handles internal code block styling. This is if we wrap it.
 	s.ReportCodeBlock = lipgloss.NewStyle().Padding(0, 1)
+	s.Markdown = t.Markdown
+
+	// --- Assertion Diffs ---
+	s.DiffRemoved = lipgloss.NewStyle().Foreground(p.Fail) // Matches StatusFail
+	s.DiffAdded = lipgloss.NewStyle().Foreground(p.Pass)   // Matches StatusPass
+	s.DiffRemovedChange = s.DiffRemoved.Background(p.RemovedBackground).Bold(true)
+	s.DiffAddedChange = s.DiffAdded.Background(p.AddedBackground).Bold(true)
+	s.DiffContext = lipgloss.NewStyle().Faint(true)
+	s.DiffHunk = lipgloss.NewStyle().Foreground(p.Accent)
+
+	// --- Coverage Viewer ---
+	s.CoverageCovered = lipgloss.NewStyle().Foreground(p.Pass)   // Matches StatusPass
+	s.CoverageUncovered = lipgloss.NewStyle().Foreground(p.Fail) // Matches StatusFail
+	s.CoverageGutter = lipgloss.NewStyle().Faint(true)
+	s.CoverageCurrent = lipgloss.NewStyle().Foreground(p.Highlight).Bold(true)
+
+	// --- Split Layout ---
+	s.SplitDivider = lipgloss.NewStyle().Foreground(p.Muted)
+	s.SplitDividerFocus = lipgloss.NewStyle().Foreground(p.Accent)
+
+	// --- Help Overlay ---
+	s.HelpGroup = s.Title.MarginTop(1)
+	s.HelpKey = lipgloss.NewStyle().Foreground(p.Highlight) // Like the spinner
 
 	// --- Footer / Global Status Bar ---
 	s.FooterStatus = lipgloss.NewStyle().
 		Padding(0, 1).
-		Background(lipgloss.Color("236")). // Dark gray background
-		Foreground(lipgloss.Color("246"))  // Light gray text
+		Background(p.Footer).
+		Foreground(p.FooterText)
+
+	if t.Monochrome {
+		// Without colors, what they would set apart is marked with reverse video and underlines.
+		s.ListHeader = s.ListHeader.Reverse(true)
+		s.ListSelectedItem = s.ListSelectedItem.Reverse(true)
+		s.ListSelectedDesc = s.ListSelectedDesc.Reverse(true)
+		s.SearchMatch = s.SearchMatch.Underline(true)
+		s.SearchCurrent = s.SearchCurrent.Reverse(true)
+		s.DiffRemovedChange = s.DiffRemovedChange.Underline(true)
+		s.DiffAddedChange = s.DiffAddedChange.Underline(true)
+		s.CoverageUncovered = s.CoverageUncovered.Underline(true)
+		s.CoverageCurrent = s.CoverageCurrent.Reverse(true)
+		s.SplitDividerFocus = s.SplitDividerFocus.Bold(true)
+		s.FooterStatus = s.FooterStatus.Reverse(true)
+		// Emoji have colors of their own; the badges are plain text instead.
+		s.FlakyBadge = "[flaky]"
+		s.QuarantinedIcon = "[q]"
+		s.QuarantinedBadge = "[quarantined]"
+	}
 
 	return s
 }
+
+// StatusIcon returns the icon of a test status.
+func (s *AppStyles) StatusIcon(status parser.TestStatus) string {
+	switch status {
+	case parser.StatusPass:
+		return s.PassIcon
+	case parser.StatusFail:
+		return s.FailIcon
+	case parser.StatusSkip:
+		return s.SkipIcon
+	case parser.StatusTimeout:
+		return s.TimeoutIcon
+	default:
+		return s.UnknownIcon
+	}
+}
+
+// newMarkdownRenderer returns a glamour renderer in the Markdown style of the theme.
+func (s *AppStyles) newMarkdownRenderer() (*glamour.TermRenderer, error) {
+	return glamour.NewTermRenderer(glamour.WithStyles(s.Markdown))
+}
@@ -0,0 +1,50 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// TestOutputKeyMap defines keybindings for the per-test output viewer.
+// The mode tags tell the key registry whether an action belongs to the picker or the viewer.
+type TestOutputKeyMap struct {
+	OpenTest   key.Binding `category:"view" mode:"picker"`
+	TestPicker key.Binding `category:"view" mode:"viewer"`
+	CycleMode  key.Binding `category:"view" mode:"viewer"`
+	Timestamps key.Binding `category:"view" mode:"viewer"`
+	Top        key.Binding `category:"navigation" mode:"viewer"`
+	Bottom     key.Binding `category:"navigation" mode:"viewer"`
+	Back       key.Binding `category:"general"`
+	// Viewport keys are handled by the viewport model itself (up, down, pgup, pgdn, etc.)
+}
+
+// DefaultTestOutputKeyMap returns a new TestOutputKeyMap with default keybindings.
+func DefaultTestOutputKeyMap() TestOutputKeyMap {
+	return TestOutputKeyMap{
+		OpenTest: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "show output"),
+		),
+		TestPicker: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "tests"),
+		),
+		CycleMode: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "cycle output mode"),
+		),
+		Timestamps: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "toggle timestamps"),
+		),
+		Top: key.NewBinding(
+			key.WithKeys("home", "g"),
+			key.WithHelp("home/g", "top"),
+		),
+		Bottom: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("end/G", "bottom"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc", "q", "b"),
+			key.WithHelp("esc/q/b", "back"),
+		),
+	}
+}
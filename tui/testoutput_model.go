@@ -0,0 +1,394 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gdd/parser"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// testOutputViewMode selects what the output viewer is currently showing.
+type testOutputViewMode int
+
+const (
+	testOutputPicker testOutputViewMode = iota // Choosing a test of the run
+	testOutputViewer                           // Showing the output of a test
+)
+
+// testOutputFormat selects how the output of a test is shown.
+type testOutputFormat int
+
+const (
+	outputCleaned  testOutputFormat = iota // The output lines exactly as the test printed them
+	outputFiltered                         // The same without the "=== RUN"/"--- PASS" lines framing every test
+	outputRaw                              // The `go test -json` events of the test
+)
+
+func (f testOutputFormat) String() string {
+	switch f {
+	case outputFiltered:
+		return "without framing lines"
+	case outputRaw:
+		return "raw JSON events"
+	default:
+		return "output"
+	}
+}
+
+// outputTabWidth is the distance between tab stops when expanding tabs in test output.
+const outputTabWidth = 8
+
+// openTestOutputMsg signals an intent to browse the output of the tests of the report's run.
+type openTestOutputMsg struct {
+	pkg  string // Test to show right away, if set; otherwise the test picker is shown
+	test string
+}
+
+// backFromTestOutputMsg signals to leave the output viewer.
+type backFromTestOutputMsg struct{}
+
+// testOutputItem is a list.Item for a test of the run in the test picker.
+type testOutputItem struct {
+	test *parser.TestResult
+	icon string
+}
+
+// Title returns the test name with its status icon.
+func (ti testOutputItem) Title() string { return ti.icon + " " + ti.test.Name }
+
+// Description returns the test's package, status and duration.
+func (ti testOutputItem) Description() string {
+	return fmt.Sprintf("%s — %s, %s", ti.test.PackageName, ti.test.Status, ti.test.Duration.Round(time.Millisecond))
+}
+
+// FilterValue returns the string to filter on. It includes the status, so that e.g.
+// filtering on "SKIP" finds the skipped tests.
+func (ti testOutputItem) FilterValue() string {
+	return fmt.Sprintf("%s %s %s", ti.test.Name, ti.test.PackageName, ti.test.Status)
+}
+
+// outputLine is a line of test output with the time of the event that started it.
+type outputLine struct {
+	time time.Time // Zero if unknown, e.g. for results without events
+	text string
+}
+
+// TestOutputModel shows the complete output of any test of a run, passing and skipped ones
+// included, as printed, without the framing lines, or as the raw events.
+type TestOutputModel struct {
+	picker   list.Model
+	viewport viewport.Model
+	keys     *TestOutputKeyMap // Read by the picker's help as well
+	styles   *AppStyles
+	logger   *log.Logger
+
+	width  int
+	height int
+
+	mode           testOutputViewMode
+	format         testOutputFormat
+	showTimestamps bool
+	current        *parser.TestResult
+}
+
+// NewTestOutputModel creates a new instance of the TestOutputModel.
+func NewTestOutputModel(delegate *list.DefaultDelegate, logger *log.Logger, styles *AppStyles) TestOutputModel {
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.Styles.Title = styles.ListHeader
+	l.Styles.FilterPrompt = styles.ListFilterPrompt
+	l.Styles.FilterCursor = styles.ListFilterCursor
+	l.Styles.PaginationStyle = styles.ListPagination
+	l.Styles.HelpStyle = styles.Help
+	releaseListKeys(&l)
+
+	keys := DefaultTestOutputKeyMap()
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.OpenTest, keys.Back}
+	}
+
+	vp := viewport.New(0, 0)
+	vp.Style = styles.ReportViewport
+
+	return TestOutputModel{
+		picker:   l,
+		viewport: vp,
+		keys:     &keys,
+		styles:   styles,
+		logger:   logger,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m TestOutputModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the TestOutputModel.
+func (m TestOutputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.mode == testOutputPicker {
+			if m.picker.FilterState() == list.Filtering {
+				break
+			}
+			switch {
+			case key.Matches(msg, m.keys.OpenTest):
+				if item, ok := m.picker.SelectedItem().(testOutputItem); ok {
+					m.openTest(item.test)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Back):
+				m.logger.Debug("TestOutputModel: Leaving output viewer.")
+				return m, func() tea.Msg { return backFromTestOutputMsg{} }
+			}
+			break
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.CycleMode):
+			m.format = (m.format + 1) % (outputRaw + 1)
+			m.logger.Debugf("TestOutputModel: Showing %s.", m.format)
+			m.render()
+			return m, nil
+		case key.Matches(msg, m.keys.Timestamps):
+			m.showTimestamps = !m.showTimestamps
+			m.render()
+			return m, nil
+		case key.Matches(msg, m.keys.Top):
+			m.viewport.GotoTop()
+			return m, nil
+		case key.Matches(msg, m.keys.Bottom):
+			m.viewport.GotoBottom()
+			return m, nil
+		case key.Matches(msg, m.keys.TestPicker), key.Matches(msg, m.keys.Back):
+			m.mode = testOutputPicker
+			return m, nil
+		}
+	}
+
+	if m.mode == testOutputPicker {
+		m.picker, cmd = m.picker.Update(msg)
+	} else {
+		m.viewport, cmd = m.viewport.Update(msg)
+	}
+	return m, cmd
+}
+
+// View renders the TestOutputModel.
+func (m TestOutputModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return m.styles.Loading.Render("Initializing output view...")
+	}
+	if m.mode == testOutputPicker {
+		return m.picker.View()
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.viewport.View())
+}
+
+// headerView renders the name, status and duration of the current test and the output format.
+func (m TestOutputModel) headerView() string {
+	if m.current == nil {
+		return ""
+	}
+	format := m.format.String()
+	if m.showTimestamps {
+		format += " with timestamps"
+	}
+	header := fmt.Sprintf("%s [%s] — %s, %s — %s", m.current.Name, m.current.PackageName,
+		m.current.Status, m.current.Duration.Round(time.Millisecond), format)
+	return m.styles.ListHeader.Render(limitString(header, max(m.width-2, 0)))
+}
+
+func (m *TestOutputModel) setSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.picker.SetSize(width, height)
+	m.viewport.Width = width
+	m.viewport.Height = max(height-1, 0) // One line for the header
+	m.render()                           // Long lines are wrapped to the width
+}
+
+// SetResults loads the tests of a run into the picker. If pkg and test name one of them,
+// its output is shown right away.
+func (m *TestOutputModel) SetResults(results []*parser.PackageResult, title, pkg, test string) tea.Cmd {
+	m.mode = testOutputPicker
+	m.current = nil
+
+	var items []list.Item
+	selected := 0
+	for _, pkgResult := range results {
+		for _, t := range pkgResult.Tests {
+			if t.PackageName == pkg && t.Name == test {
+				selected = len(items)
+				m.openTest(t)
+			}
+			items = append(items, testOutputItem{test: t, icon: m.styles.StatusIcon(t.Status)})
+		}
+	}
+	m.picker.Title = fmt.Sprintf("%s (%d tests)", title, len(items))
+	m.logger.Debugf("TestOutputModel: Loaded %d tests for %q.", len(items), title)
+
+	cmd := m.picker.SetItems(items)
+	m.picker.Select(selected)
+	return cmd
+}
+
+// openTest shows the output of a test.
+func (m *TestOutputModel) openTest(test *parser.TestResult) {
+	m.logger.Debugf("TestOutputModel: Showing output of %s/%s.", test.PackageName, test.Name)
+	m.current = test
+	m.mode = testOutputViewer
+	m.render()
+	m.viewport.GotoTop()
+}
+
+// render writes the output of the current test into the viewport in the selected format.
+func (m *TestOutputModel) render() {
+	if m.current == nil {
+		return
+	}
+	lines := m.outputLines()
+	if len(lines) == 0 {
+		m.viewport.SetContent(m.styles.Help.Render("(No output captured for this test.)"))
+		return
+	}
+
+	// Lines are wrapped here rather than by the viewport, which would lose track of
+	// how many lines there are to scroll through.
+	width := m.viewport.Width - m.viewport.Style.GetHorizontalFrameSize()
+	gutter := ""
+	if m.showTimestamps {
+		gutter = strings.Repeat(" ", len("15:04:05.000 "))
+	}
+	width = max(width-len(gutter), 10)
+
+	var b strings.Builder
+	for _, line := range lines {
+		// Escape sequences are passed through: colored output is shown in color.
+		wrapped := strings.Split(ansi.Hardwrap(expandTabs(line.text), width, true), "\n")
+		for i, part := range wrapped {
+			if m.showTimestamps {
+				stamp := gutter
+				if i == 0 && !line.time.IsZero() {
+					stamp = line.time.Local().Format("15:04:05.000 ")
+				}
+				b.WriteString(m.styles.Help.Render(stamp))
+			}
+			b.WriteString(part + "\n")
+		}
+	}
+	m.viewport.SetContent(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// outputLines returns the lines of the current test in the selected format.
+func (m *TestOutputModel) outputLines() []outputLine {
+	test := m.current
+	if len(test.Events) == 0 {
+		// Results made up by the parser, e.g. for build failures, have output but no events.
+		var lines []outputLine
+		for _, text := range test.Output {
+			if m.format != outputFiltered || !parser.IsFraming(text) {
+				lines = append(lines, outputLine{text: text})
+			}
+		}
+		return lines
+	}
+
+	if m.format == outputRaw {
+		lines := make([]outputLine, 0, len(test.Events))
+		for _, event := range test.Events {
+			lines = append(lines, outputLine{time: event.Time, text: marshalEvent(event)})
+		}
+		return lines
+	}
+
+	// Output events usually hold one line each, but long lines and output without a
+	// trailing newline are split across events; join them back into lines.
+	var lines []outputLine
+	partial := false
+	for _, event := range test.Events {
+		if event.Action != "output" {
+			continue
+		}
+		for _, text := range strings.SplitAfter(event.Output, "\n") {
+			if text == "" {
+				continue
+			}
+			if partial {
+				lines[len(lines)-1].text += text
+			} else {
+				lines = append(lines, outputLine{time: event.Time, text: text})
+			}
+			partial = !strings.HasSuffix(text, "\n")
+		}
+	}
+
+	kept := lines[:0]
+	for _, line := range lines {
+		line.text = strings.TrimRight(line.text, "\r\n")
+		if m.format == outputFiltered && parser.IsFraming(line.text) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+// marshalEvent formats an event the way `go test -json` prints it.
+func marshalEvent(event parser.TestEvent) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // test2json doesn't escape <, > and &
+	if err := enc.Encode(event); err != nil {
+		return fmt.Sprintf("(could not encode event: %v)", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop, which keeps the columns of
+// diffs and tables aligned whatever the terminal does with tabs.
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for i, part := range strings.Split(line, "\t") {
+		if i > 0 {
+			pad := outputTabWidth - width%outputTabWidth
+			b.WriteString(strings.Repeat(" ", pad))
+			width += pad
+		}
+		b.WriteString(part)
+		width += ansi.StringWidth(part)
+	}
+	return b.String()
+}
+
+// HelpView returns a string containing the help information for the output viewer.
+func (m TestOutputModel) HelpView() string {
+	if m.mode == testOutputPicker {
+		return fmt.Sprintf("%s → %s, / → filter, %s → %s",
+			m.keys.OpenTest.Help().Key, m.keys.OpenTest.Help().Desc, m.keys.Back.Help().Key, m.keys.Back.Help().Desc)
+	}
+	return fmt.Sprintf("%s → %s (%s), %s → %s, %s → %s, ↑/↓/pgup/pgdn → scroll, %s → back",
+		m.keys.CycleMode.Help().Key, m.keys.CycleMode.Help().Desc, m.format, m.keys.Timestamps.Help().Key, m.keys.Timestamps.Help().Desc,
+		m.keys.TestPicker.Help().Key, m.keys.TestPicker.Help().Desc, m.keys.Back.Help().Key)
+}
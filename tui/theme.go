@@ -0,0 +1,265 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gdd/config"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Palette holds the colors of a theme by role. Custom themes of the configuration set them by
+// the names of the fields in snake case, e.g. "accent_text" for AccentText.
+type Palette struct {
+	Accent             lipgloss.TerminalColor // Titles, borders and headers
+	AccentText         lipgloss.TerminalColor // Text on the accent color, e.g. the title of a list
+	Highlight          lipgloss.TerminalColor // Prompts, the spinner, keys in the help
+	Selected           lipgloss.TerminalColor // Text of the selected item of a list
+	SelectedBackground lipgloss.TerminalColor // Background of the selected item of a list
+	Muted              lipgloss.TerminalColor // Dividers and other lines that stay in the background
+	Pass               lipgloss.TerminalColor // Passed tests, covered code, added lines of diffs
+	Fail               lipgloss.TerminalColor // Failed tests, uncovered code, removed lines of diffs
+	Skip               lipgloss.TerminalColor // Skipped tests
+	Timeout            lipgloss.TerminalColor // Tests that timed out, slow test warnings
+	Error              lipgloss.TerminalColor // Error messages
+	Match              lipgloss.TerminalColor // Background of search matches
+	MatchText          lipgloss.TerminalColor // Text of search matches
+	CurrentMatch       lipgloss.TerminalColor // Background of the search match scrolled to
+	CurrentMatchText   lipgloss.TerminalColor // Text of the search match scrolled to
+	RemovedBackground  lipgloss.TerminalColor // Background of the changed part of a removed line
+	AddedBackground    lipgloss.TerminalColor // Background of the changed part of an added line
+	Footer             lipgloss.TerminalColor // Background of the status bar
+	FooterText         lipgloss.TerminalColor // Text of the status bar
+}
+
+// Theme is a palette and the glamour style of the Markdown rendered with it.
+type Theme struct {
+	Name       string
+	Palette    Palette
+	Markdown   ansi.StyleConfig
+	Monochrome bool // Set apart with reverse video and underlines what the colors can't
+}
+
+// builtinTheme is a theme shipped with gdd. Its Markdown style is a glamour standard style,
+// or "auto" for the dark or light one depending on the terminal's background.
+type builtinTheme struct {
+	palette    Palette
+	markdown   string
+	monochrome bool
+}
+
+// builtinThemes are the themes of config.BuiltinThemes, except "auto", which picks "dark" or "light".
+var builtinThemes = map[string]builtinTheme{
+	"dark":          {palette: darkPalette, markdown: "dark"},
+	"light":         {palette: lightPalette, markdown: "light"},
+	"high-contrast": {palette: highContrastPalette, markdown: "auto"},
+	"no-color":      {palette: noColorPalette, markdown: "notty", monochrome: true},
+}
+
+var darkPalette = Palette{
+	Accent:             lipgloss.Color("62"),  // Purple
+	AccentText:         lipgloss.Color("230"), // Light, for contrast
+	Highlight:          lipgloss.Color("205"), // Magenta/Pink
+	Selected:           lipgloss.Color("212"), // Bright Pink/Purple
+	SelectedBackground: lipgloss.Color("237"), // Darker gray
+	Muted:              lipgloss.Color("240"),
+	Pass:               lipgloss.Color("#50FA7B"), // Green
+	Fail:               lipgloss.Color("#FF5555"), // Red
+	Skip:               lipgloss.Color("#F1FA8C"), // Yellow
+	Timeout:            lipgloss.Color("#FFB86C"), // Orange
+	Error:              lipgloss.Color("#FF5555"),
+	Match:              lipgloss.Color("58"),
+	MatchText:          lipgloss.Color("230"),
+	CurrentMatch:       lipgloss.Color("214"),
+	CurrentMatchText:   lipgloss.Color("0"),
+	RemovedBackground:  lipgloss.Color("52"),
+	AddedBackground:    lipgloss.Color("22"),
+	Footer:             lipgloss.Color("236"), // Dark gray
+	FooterText:         lipgloss.Color("246"), // Light gray
+}
+
+// lightPalette uses darker text colors and light backgrounds, which stay readable on a white terminal.
+var lightPalette = Palette{
+	Accent:             lipgloss.Color("62"),
+	AccentText:         lipgloss.Color("230"),
+	Highlight:          lipgloss.Color("162"), // Deep pink
+	Selected:           lipgloss.Color("90"),  // Dark magenta
+	SelectedBackground: lipgloss.Color("254"), // Light gray
+	Muted:              lipgloss.Color("248"),
+	Pass:               lipgloss.Color("#1A7F37"), // Dark green
+	Fail:               lipgloss.Color("#CF222E"), // Dark red
+	Skip:               lipgloss.Color("#9A6700"), // Ochre
+	Timeout:            lipgloss.Color("#BC4C00"), // Burnt orange
+	Error:              lipgloss.Color("#CF222E"),
+	Match:              lipgloss.Color("229"), // Pale yellow
+	MatchText:          lipgloss.Color("0"),
+	CurrentMatch:       lipgloss.Color("214"),
+	CurrentMatchText:   lipgloss.Color("0"),
+	RemovedBackground:  lipgloss.Color("224"), // Pale red
+	AddedBackground:    lipgloss.Color("194"), // Pale green
+	Footer:             lipgloss.Color("252"),
+	FooterText:         lipgloss.Color("238"),
+}
+
+// highContrastPalette uses the basic ANSI colors at their brightest against the background,
+// and black and white instead of grays, on dark and light terminals alike.
+var highContrastPalette = Palette{
+	Accent:             lipgloss.AdaptiveColor{Light: "4", Dark: "14"},
+	AccentText:         lipgloss.AdaptiveColor{Light: "15", Dark: "0"},
+	Highlight:          lipgloss.AdaptiveColor{Light: "5", Dark: "13"},
+	Selected:           lipgloss.AdaptiveColor{Light: "15", Dark: "0"},
+	SelectedBackground: lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+	Muted:              lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+	Pass:               lipgloss.AdaptiveColor{Light: "2", Dark: "10"},
+	Fail:               lipgloss.AdaptiveColor{Light: "1", Dark: "9"},
+	Skip:               lipgloss.AdaptiveColor{Light: "3", Dark: "11"},
+	Timeout:            lipgloss.AdaptiveColor{Light: "130", Dark: "214"},
+	Error:              lipgloss.AdaptiveColor{Light: "1", Dark: "9"},
+	Match:              lipgloss.Color("11"),
+	MatchText:          lipgloss.Color("0"),
+	CurrentMatch:       lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+	CurrentMatchText:   lipgloss.AdaptiveColor{Light: "15", Dark: "0"},
+	RemovedBackground:  lipgloss.AdaptiveColor{Light: "224", Dark: "52"},
+	AddedBackground:    lipgloss.AdaptiveColor{Light: "194", Dark: "22"},
+	Footer:             lipgloss.AdaptiveColor{Light: "0", Dark: "15"},
+	FooterText:         lipgloss.AdaptiveColor{Light: "15", Dark: "0"},
+}
+
+// noColorPalette leaves every color to the terminal.
+var noColorPalette = Palette{
+	Accent:             lipgloss.NoColor{},
+	AccentText:         lipgloss.NoColor{},
+	Highlight:          lipgloss.NoColor{},
+	Selected:           lipgloss.NoColor{},
+	SelectedBackground: lipgloss.NoColor{},
+	Muted:              lipgloss.NoColor{},
+	Pass:               lipgloss.NoColor{},
+	Fail:               lipgloss.NoColor{},
+	Skip:               lipgloss.NoColor{},
+	Timeout:            lipgloss.NoColor{},
+	Error:              lipgloss.NoColor{},
+	Match:              lipgloss.NoColor{},
+	MatchText:          lipgloss.NoColor{},
+	CurrentMatch:       lipgloss.NoColor{},
+	CurrentMatchText:   lipgloss.NoColor{},
+	RemovedBackground:  lipgloss.NoColor{},
+	AddedBackground:    lipgloss.NoColor{},
+	Footer:             lipgloss.NoColor{},
+	FooterText:         lipgloss.NoColor{},
+}
+
+// loadTheme returns the theme selected by the configuration. NO_COLOR in the environment
+// (see https://no-color.org) selects the no-color theme whatever the configuration says.
+// A custom color or Markdown style that can't be used keeps that of the base theme, with
+// a problem returned for it.
+func loadTheme(cfg config.Config) (Theme, []error) {
+	name := cfg.Theme
+	if os.Getenv("NO_COLOR") != "" {
+		name = "no-color"
+	}
+	custom, isCustom := cfg.Themes[name]
+
+	base := name
+	if isCustom {
+		base = custom.Base
+	}
+	if base == "" || base == "auto" {
+		base = "light"
+		if lipgloss.HasDarkBackground() {
+			base = "dark"
+		}
+	}
+	builtin, ok := builtinThemes[base]
+	if !ok {
+		// The configuration only names known themes once validated.
+		return Theme{}, []error{fmt.Errorf("theme: unknown theme %q", base)}
+	}
+
+	var errs []error
+	theme := Theme{Name: name, Palette: builtin.palette, Monochrome: builtin.monochrome}
+	markdown := builtin.markdown
+	if isCustom {
+		errs = append(errs, theme.Palette.set(name, custom.Colors)...)
+		if custom.Markdown != "" {
+			markdown = custom.Markdown
+		}
+	}
+
+	style, err := markdownStyle(markdown)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("themes.%s.markdown: %w, using %q", name, err, builtin.markdown))
+		style, _ = markdownStyle(builtin.markdown)
+	}
+	if isCustom && custom.Markdown == "" {
+		// The headings of the base theme's style take the colors of the custom theme.
+		style = tintHeadings(style, theme.Palette)
+	}
+	theme.Markdown = style
+	return theme, errs
+}
+
+// set sets the colors of the palette from those of the custom theme name, by role.
+func (p *Palette) set(name string, colors map[string]string) []error {
+	roles := make(map[string]reflect.Value)
+	v := reflect.ValueOf(p).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		roles[snakeCase(v.Type().Field(i).Name)] = v.Field(i)
+	}
+
+	var errs []error
+	for _, role := range slices.Sorted(maps.Keys(colors)) {
+		field, ok := roles[role]
+		if !ok {
+			errs = append(errs, fmt.Errorf("themes.%s.colors: unknown color %q (one of %s)", name, role, strings.Join(slices.Sorted(maps.Keys(roles)), ", ")))
+			continue
+		}
+		field.Set(reflect.ValueOf(lipgloss.Color(colors[role])))
+	}
+	return errs
+}
+
+// markdownStyle returns the glamour style named style, "auto" for the dark or light one
+// depending on the terminal's background, or read from the JSON file at path style.
+func markdownStyle(style string) (ansi.StyleConfig, error) {
+	if style == glamour.AutoStyle {
+		style = glamour.LightStyle
+		if lipgloss.HasDarkBackground() {
+			style = glamour.DarkStyle
+		}
+	}
+	if standard, ok := glamour.DefaultStyles[style]; ok {
+		return *standard, nil
+	}
+
+	data, err := os.ReadFile(style)
+	if err != nil {
+		return ansi.StyleConfig{}, err
+	}
+	var config ansi.StyleConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ansi.StyleConfig{}, fmt.Errorf("%s: %w", style, err)
+	}
+	return config, nil
+}
+
+// tintHeadings returns style with headings in the accent colors of p, where they are plain colors.
+func tintHeadings(style ansi.StyleConfig, p Palette) ansi.StyleConfig {
+	if accent, ok := p.Accent.(lipgloss.Color); ok {
+		color := string(accent)
+		style.Heading.Color = &color
+		style.H1.BackgroundColor = &color
+	}
+	if text, ok := p.AccentText.(lipgloss.Color); ok {
+		color := string(text)
+		style.H1.Color = &color
+	}
+	return style
+}
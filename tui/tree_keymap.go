@@ -0,0 +1,68 @@
+package tui
+
+import "github.com/charmbracelet/bubbles/key"
+
+// TreeKeyMap defines keybindings for the test tree view.
+type TreeKeyMap struct {
+	Up          key.Binding `category:"navigation"`
+	Down        key.Binding `category:"navigation"`
+	Expand      key.Binding `category:"navigation"`
+	Collapse    key.Binding `category:"navigation"`
+	Toggle      key.Binding `category:"navigation"`
+	ExpandAll   key.Binding `category:"navigation"`
+	CollapseAll key.Binding `category:"navigation"`
+	Run         key.Binding `category:"run"`
+	Filter      key.Binding `category:"search"`
+	ClearFilter key.Binding `category:"search"`
+	ToList      key.Binding `category:"view"`
+}
+
+// DefaultTreeKeyMap returns a new TreeKeyMap with default keybindings.
+func DefaultTreeKeyMap() TreeKeyMap {
+	return TreeKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Expand: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "expand"),
+		),
+		Collapse: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "collapse"),
+		),
+		Toggle: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "toggle"),
+		),
+		ExpandAll: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", "expand all"),
+		),
+		CollapseAll: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "collapse all"),
+		),
+		Run: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "run node"),
+		),
+		Filter: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "filter"),
+		),
+		ClearFilter: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "clear filter"),
+		),
+		ToList: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "flat list"),
+		),
+	}
+}
@@ -0,0 +1,528 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gdd/history"
+	"gdd/parser"
+	"gdd/runner"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// treeNodeKind is the level of a node in the test tree.
+type treeNodeKind int
+
+const (
+	nodeModule treeNodeKind = iota
+	nodePackage
+	nodeFile
+	nodeTest
+	nodeSubtest
+)
+
+// treeNode is a node of the test tree: the module, a package directory, a test file,
+// a test function or a statically known subtest.
+type treeNode struct {
+	kind     treeNodeKind
+	id       string // Stable across rebuilds, to keep the expansion state and the cursor
+	name     string
+	item     TestItem // Test of a test or subtest node
+	testName string   // Full name of a test or subtest node, e.g. "TestFoo/empty"
+	pkgDir   string
+	last     *history.LastResult // Last result of a test or subtest node
+	parent   *treeNode
+	children []*treeNode
+	expanded bool
+
+	// Aggregates over the test functions below the node (or the node itself)
+	passed, failed, skipped, notRun int
+
+	match, childMatch bool // Filter state, see TreeModel.applyFilter
+}
+
+// status returns the aggregate status of the node: failing if any test below it failed,
+// otherwise passing if any passed.
+func (n *treeNode) status() parser.TestStatus {
+	if n.last != nil {
+		return n.last.Status
+	}
+	switch {
+	case n.failed > 0:
+		return parser.StatusFail
+	case n.passed > 0:
+		return parser.StatusPass
+	case n.skipped > 0:
+		return parser.StatusSkip
+	default:
+		return parser.StatusUnknown
+	}
+}
+
+// treeRow is a visible line of the tree.
+type treeRow struct {
+	node  *treeNode
+	depth int
+}
+
+// toggleTreeViewMsg signals an intent to switch between the flat test list and the tree.
+type toggleTreeViewMsg struct{}
+
+// runTreeNodeMsg signals an intent to run the tests below a node of the tree.
+// Config holds the scope of the run (type, package, test names); MainModel fills in the rest.
+type runTreeNodeMsg struct {
+	config runner.TestRunConfig
+	desc   string // What is run, for the status bar
+}
+
+// TreeModel shows the tests as a tree of module, packages, files, tests and subtests.
+type TreeModel struct {
+	keys   TreeKeyMap
+	styles *AppStyles
+	logger *log.Logger
+	filter textinput.Model
+
+	width  int
+	height int
+
+	root   *treeNode
+	rows   []treeRow
+	cursor int
+	offset int // First visible row
+}
+
+// NewTreeModel creates a new instance of the TreeModel.
+func NewTreeModel(logger *log.Logger, styles *AppStyles) TreeModel {
+	ti := textinput.New()
+	ti.Prompt = "Filter: "
+	ti.PromptStyle = styles.ListFilterPrompt
+	ti.Cursor.Style = styles.ListFilterCursor
+
+	return TreeModel{
+		keys:   DefaultTreeKeyMap(),
+		styles: styles,
+		logger: logger,
+		filter: ti,
+	}
+}
+
+// Init is part of the tea.Model interface.
+func (m TreeModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages for the TreeModel.
+func (m TreeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.filter.Focused() {
+			switch msg.String() {
+			case "enter", "tab":
+				m.filter.Blur()
+			case "esc":
+				m.filter.Blur()
+				m.filter.SetValue("")
+				m.flatten()
+			default:
+				var cmd tea.Cmd
+				m.filter, cmd = m.filter.Update(msg)
+				m.flatten()
+				return m, cmd
+			}
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Up):
+			m.moveCursor(-1)
+		case key.Matches(msg, m.keys.Down):
+			m.moveCursor(1)
+		case key.Matches(msg, m.keys.Expand):
+			if n := m.selected(); n != nil && len(n.children) > 0 {
+				n.expanded = true
+				m.flatten()
+			}
+		case key.Matches(msg, m.keys.Collapse):
+			m.collapse()
+		case key.Matches(msg, m.keys.Toggle):
+			if n := m.selected(); n != nil && len(n.children) > 0 {
+				n.expanded = !n.expanded
+				m.flatten()
+			}
+		case key.Matches(msg, m.keys.ExpandAll), key.Matches(msg, m.keys.CollapseAll):
+			expand := key.Matches(msg, m.keys.ExpandAll)
+			walkTree(m.root, func(n *treeNode) {
+				n.expanded = expand || n.kind == nodeModule
+			})
+			m.flatten()
+		case key.Matches(msg, m.keys.Filter):
+			m.filter.Focus()
+			return m, textinput.Blink
+		case key.Matches(msg, m.keys.ClearFilter):
+			if m.filter.Value() != "" {
+				m.filter.SetValue("")
+				m.flatten()
+			}
+		case key.Matches(msg, m.keys.Run):
+			if n := m.selected(); n != nil {
+				m.logger.Debugf("TreeModel: Running node %s.", n.id)
+				run := runTreeNode(n)
+				return m, func() tea.Msg { return run }
+			}
+		case key.Matches(msg, m.keys.ToList):
+			m.logger.Debug("TreeModel: Switching to the flat list.")
+			return m, func() tea.Msg { return toggleTreeViewMsg{} }
+		}
+	}
+	return m, nil
+}
+
+// View renders the visible rows of the tree.
+func (m TreeModel) View() string {
+	if m.width == 0 || m.height == 0 {
+		return m.styles.Loading.Render("Initializing tree view...")
+	}
+
+	var b strings.Builder
+	total := 0
+	if m.root != nil {
+		total = m.root.passed + m.root.failed + m.root.skipped + m.root.notRun
+	}
+	b.WriteString(m.styles.ListHeader.Render(fmt.Sprintf("Test Tree (%d tests)", total)) + "\n")
+	if m.filter.Focused() || m.filter.Value() != "" {
+		b.WriteString(" " + m.filter.View() + "\n")
+	} else {
+		b.WriteString("\n")
+	}
+
+	bodyHeight := m.bodyHeight()
+	if len(m.rows) == 0 {
+		b.WriteString(m.styles.ListNoItems.Render("No tests match."))
+	}
+	for i := m.offset; i < len(m.rows) && i < m.offset+bodyHeight; i++ {
+		line := m.renderRow(m.rows[i])
+		if i == m.cursor {
+			b.WriteString(m.styles.ListSelectedItem.Width(m.width).Render(line) + "\n")
+		} else {
+			b.WriteString(m.styles.ListItem.Render(line) + "\n")
+		}
+	}
+	return lipgloss.NewStyle().Height(m.height).MaxHeight(m.height).Render(b.String())
+}
+
+// renderRow renders a node with its expansion marker, status icon and aggregate counts.
+func (m TreeModel) renderRow(row treeRow) string {
+	n := row.node
+	marker := "  "
+	if len(n.children) > 0 {
+		marker = "▸ "
+		if n.expanded || n.childMatch {
+			marker = "▾ "
+		}
+	}
+	line := strings.Repeat("  ", row.depth) + marker + m.styles.StatusIcon(n.status()) + " " + n.name
+
+	switch n.kind {
+	case nodeTest, nodeSubtest:
+		if n.last != nil {
+			line += "  " + m.styles.ListDescription.Render(fmt.Sprintf("%s, %s", n.last.Duration.Round(time.Millisecond), formatAge(time.Since(n.last.RunAt))))
+		}
+		if n.item.Quarantined && n.kind == nodeTest {
+			line += "  " + m.styles.QuarantinedBadge
+		}
+	default:
+		var counts []string
+		for _, c := range []struct {
+			n     int
+			label string
+			style lipgloss.Style
+		}{
+			{n.failed, "failed", m.styles.StatusFail},
+			{n.passed, "passed", m.styles.StatusPass},
+			{n.skipped, "skipped", m.styles.StatusSkip},
+			{n.notRun, "not run", m.styles.StatusUnknown},
+		} {
+			if c.n > 0 {
+				counts = append(counts, c.style.Render(fmt.Sprintf("%d %s", c.n, c.label)))
+			}
+		}
+		line += "  " + strings.Join(counts, ", ")
+	}
+	return line
+}
+
+func (m *TreeModel) setSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.filter.Width = width - len(m.filter.Prompt) - 2
+	m.scrollToCursor()
+}
+
+// bodyHeight is the number of rows that fit below the title and filter lines.
+func (m TreeModel) bodyHeight() int {
+	return max(m.height-2, 1)
+}
+
+// SetTests rebuilds the tree from the discovered tests, keeping the expansion state,
+// the cursor and the filter. lastResult returns the last result of a subtest of a test.
+func (m *TreeModel) SetTests(modulePath string, items []list.Item, lastResult func(ti TestItem, name string) *history.LastResult) {
+	expanded := make(map[string]bool)
+	selectedID := ""
+	if m.root != nil {
+		walkTree(m.root, func(n *treeNode) { expanded[n.id] = n.expanded })
+		if n := m.selected(); n != nil {
+			selectedID = n.id
+		}
+	}
+
+	if modulePath == "" {
+		modulePath = "."
+	}
+	root := &treeNode{kind: nodeModule, id: "module", name: modulePath, expanded: true}
+	nodes := map[string]*treeNode{root.id: root}
+	child := func(parent *treeNode, kind treeNodeKind, id, name string) *treeNode {
+		if n, ok := nodes[id]; ok {
+			return n
+		}
+		n := &treeNode{kind: kind, id: id, name: name, parent: parent, expanded: expanded[id]}
+		nodes[id] = n
+		parent.children = append(parent.children, n)
+		return n
+	}
+
+	for _, item := range items {
+		ti, ok := item.(TestItem)
+		if !ok {
+			continue
+		}
+		pkg := child(root, nodePackage, "pkg:"+ti.PackageDir, ti.PackageDir)
+		pkg.pkgDir = ti.PackageDir
+		file := child(pkg, nodeFile, "file:"+ti.FilePath, filepath.Base(ti.FilePath))
+		file.pkgDir = ti.PackageDir
+		test := child(file, nodeTest, "test:"+ti.PackageDir+"/"+ti.Name, ti.Name)
+		test.item, test.testName, test.pkgDir, test.last = ti, ti.Name, ti.PackageDir, ti.Last
+
+		for _, name := range ti.Subtests {
+			parent := test
+			if i := strings.LastIndex(name, "/"); i > len(ti.Name) {
+				if p, ok := nodes["sub:"+ti.PackageDir+"/"+name[:i]]; ok {
+					parent = p
+				}
+			}
+			sub := child(parent, nodeSubtest, "sub:"+ti.PackageDir+"/"+name, name[strings.LastIndex(name, "/")+1:])
+			sub.item, sub.testName, sub.pkgDir, sub.last = ti, name, ti.PackageDir, lastResult(ti, name)
+		}
+	}
+
+	sort.SliceStable(root.children, func(i, j int) bool { return root.children[i].name < root.children[j].name })
+	aggregate(root)
+	if !expanded["module"] && m.root != nil {
+		root.expanded = false
+	}
+
+	m.root = root
+	m.flatten()
+	for i, row := range m.rows {
+		if row.node.id == selectedID {
+			m.cursor = i
+		}
+	}
+	m.scrollToCursor()
+	log.Debugf("TreeModel: Built tree of %d nodes.", len(nodes))
+}
+
+// aggregate computes the counts of every node from the test functions below it.
+func aggregate(n *treeNode) {
+	n.passed, n.failed, n.skipped, n.notRun = 0, 0, 0, 0
+	if n.kind == nodeTest {
+		for _, c := range n.children {
+			aggregate(c)
+		}
+		switch {
+		case n.last == nil:
+			n.notRun = 1
+		case n.last.Status == parser.StatusPass:
+			n.passed = 1
+		case n.last.Status == parser.StatusFail || n.last.Status == parser.StatusTimeout:
+			n.failed = 1
+		default:
+			n.skipped = 1
+		}
+		return
+	}
+	if n.kind == nodeSubtest {
+		return // Subtests are not counted; their test function is
+	}
+	for _, c := range n.children {
+		aggregate(c)
+		n.passed += c.passed
+		n.failed += c.failed
+		n.skipped += c.skipped
+		n.notRun += c.notRun
+	}
+}
+
+// walkTree calls fn for every node of the tree, parents first.
+func walkTree(n *treeNode, fn func(*treeNode)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	for _, c := range n.children {
+		walkTree(c, fn)
+	}
+}
+
+// flatten computes the visible rows from the expansion state and the filter. While filtering,
+// the ancestors of matching nodes are shown (and expanded), as are the descendants of
+// matching packages and files, following their expansion state.
+func (m *TreeModel) flatten() {
+	selected := m.selected()
+	query := strings.ToLower(strings.TrimSpace(m.filter.Value()))
+	m.applyFilter(m.root, query)
+
+	m.rows = m.rows[:0]
+	var walk func(n *treeNode, depth int, ancestorMatched bool)
+	walk = func(n *treeNode, depth int, ancestorMatched bool) {
+		if query != "" && !n.match && !n.childMatch && !ancestorMatched {
+			return
+		}
+		m.rows = append(m.rows, treeRow{node: n, depth: depth})
+		showChildren := n.expanded
+		if query != "" {
+			showChildren = n.childMatch || (n.expanded && (n.match || ancestorMatched))
+		}
+		if showChildren {
+			for _, c := range n.children {
+				walk(c, depth+1, ancestorMatched || (query != "" && n.match))
+			}
+		}
+	}
+	if m.root != nil {
+		walk(m.root, 0, false)
+	}
+
+	m.cursor = min(m.cursor, max(len(m.rows)-1, 0))
+	for i, row := range m.rows {
+		if row.node == selected {
+			m.cursor = i
+		}
+	}
+	m.scrollToCursor()
+}
+
+// applyFilter marks the nodes whose name contains the query and the nodes with a matching descendant.
+func (m *TreeModel) applyFilter(n *treeNode, query string) bool {
+	if n == nil {
+		return false
+	}
+	n.match = query != "" && strings.Contains(strings.ToLower(n.name), query)
+	n.childMatch = false
+	for _, c := range n.children {
+		if m.applyFilter(c, query) {
+			n.childMatch = true
+		}
+	}
+	return n.match || n.childMatch
+}
+
+// selected returns the node under the cursor, or nil if the tree is empty.
+func (m TreeModel) selected() *treeNode {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.cursor].node
+}
+
+func (m *TreeModel) moveCursor(delta int) {
+	m.cursor = max(0, min(len(m.rows)-1, m.cursor+delta))
+	m.scrollToCursor()
+}
+
+// collapse collapses the selected node, or moves to its parent if it is already collapsed.
+func (m *TreeModel) collapse() {
+	n := m.selected()
+	if n == nil {
+		return
+	}
+	if n.expanded && len(n.children) > 0 {
+		n.expanded = false
+		m.flatten()
+		return
+	}
+	if n.parent == nil {
+		return
+	}
+	for i, row := range m.rows {
+		if row.node == n.parent {
+			m.cursor = i
+		}
+	}
+	m.scrollToCursor()
+}
+
+// scrollToCursor adjusts the first visible row so that the cursor is on screen.
+func (m *TreeModel) scrollToCursor() {
+	height := m.bodyHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	m.offset = max(0, min(m.offset, max(len(m.rows)-height, 0)))
+}
+
+// runTreeNode returns the run of the tests below a node.
+func runTreeNode(n *treeNode) runTreeNodeMsg {
+	switch n.kind {
+	case nodeModule:
+		return runTreeNodeMsg{config: runner.TestRunConfig{Type: runner.AllTests, PackagePath: "./..."}, desc: "all project tests"}
+	case nodePackage:
+		return runTreeNodeMsg{
+			config: runner.TestRunConfig{Type: runner.PackageTests, PackagePath: "./" + n.pkgDir},
+			desc:   fmt.Sprintf("package %s", n.name),
+		}
+	case nodeFile:
+		var names []string
+		for _, c := range n.children {
+			names = append(names, c.testName)
+		}
+		return runTreeNodeMsg{
+			config: runner.TestRunConfig{Type: runner.SelectedTests, PackagePath: "./" + n.pkgDir, TestNames: names},
+			desc:   fmt.Sprintf("%d tests of %s", len(names), n.name),
+		}
+	default:
+		return runTreeNodeMsg{
+			config: runner.TestRunConfig{Type: runner.SingleTest, PackagePath: "./" + n.pkgDir, TestName: n.testName},
+			desc:   fmt.Sprintf("test %s", n.testName),
+		}
+	}
+}
+
+// Filtering reports whether the filter input has focus, so that MainModel doesn't treat keys as global.
+func (m TreeModel) Filtering() bool {
+	return m.filter.Focused()
+}
+
+// HelpView returns a string with help for the tree view's keybindings.
+func (m TreeModel) HelpView() string {
+	var helpItems []string
+	for _, k := range []key.Binding{m.keys.Run, m.keys.Toggle, m.keys.Expand, m.keys.Collapse, m.keys.ExpandAll, m.keys.CollapseAll, m.keys.Filter, m.keys.ToList} {
+		helpItems = append(helpItems, k.Help().Key+" → "+k.Help().Desc)
+	}
+	return strings.Join(helpItems, ", ")
+}
@@ -0,0 +1,159 @@
+package watch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// DefaultInterval is how often the module is scanned for changes.
+const DefaultInterval = 500 * time.Millisecond
+
+// DefaultDebounce is how long the files must stay unchanged before a batch of changes
+// is reported, so that a burst of saves (e.g. a formatter rewriting several files) triggers a single run.
+const DefaultDebounce = 300 * time.Millisecond
+
+// fileState is what a scan records about a file to detect changes.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher detects changes to the Go sources, testdata and go.mod of a module by polling.
+// It is not safe for concurrent use; the TUI polls it from one command at a time.
+type Watcher struct {
+	rootDir  string
+	debounce time.Duration
+
+	files      map[string]fileState
+	pending    map[string]bool
+	lastChange time.Time
+}
+
+// New creates a Watcher for the module rooted at rootDir and takes the initial snapshot.
+func New(rootDir string, debounce time.Duration) (*Watcher, error) {
+	w := &Watcher{
+		rootDir:  rootDir,
+		debounce: debounce,
+		pending:  make(map[string]bool),
+	}
+	files, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	w.files = files
+	log.Infof("Watching %d files under %s.", len(files), rootDir)
+	return w, nil
+}
+
+// Poll scans the module and returns the files (relative to the module root) that were
+// created, modified or removed, once no further change has been seen for the debounce period.
+// It returns nil while there are no changes or while a burst of changes is still settling.
+func (w *Watcher) Poll() ([]string, error) {
+	files, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for path, state := range files {
+		if prev, ok := w.files[path]; !ok || prev != state {
+			w.pending[path] = true
+			w.lastChange = now
+		}
+	}
+	for path := range w.files {
+		if _, ok := files[path]; !ok {
+			w.pending[path] = true
+			w.lastChange = now
+		}
+	}
+	w.files = files
+
+	if len(w.pending) == 0 || now.Sub(w.lastChange) < w.debounce {
+		return nil, nil
+	}
+
+	changed := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		changed = append(changed, path)
+	}
+	sort.Strings(changed)
+	w.pending = make(map[string]bool)
+	log.Debugf("Watcher: %d files changed: %v", len(changed), changed)
+	return changed, nil
+}
+
+// scan records the state of every watched file under the module root.
+func (w *Watcher) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(w.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // Removed while walking
+			}
+			return err
+		}
+		rel, relErr := filepath.Rel(w.rootDir, path)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			name := d.Name()
+			if rel != "." && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !Watched(rel) {
+			return nil
+		}
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return nil // Removed while walking
+		}
+		files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
+
+// Fingerprint returns a hash of the state (path, size and modification time) of every watched
+// file under rootDir. Two equal fingerprints mean the code did not change in between.
+func Fingerprint(rootDir string) (string, error) {
+	w := &Watcher{rootDir: rootDir}
+	files, err := w.scan()
+	if err != nil {
+		return "", err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", path, files[path].size, files[path].modTime.UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Watched reports whether a change to the file (slash-separated, relative to the module root)
+// can affect test results: Go sources, anything under a testdata directory, and go.mod.
+func Watched(relPath string) bool {
+	if strings.HasSuffix(relPath, ".go") || relPath == "go.mod" {
+		return true
+	}
+	return strings.HasPrefix(relPath, "testdata/") || strings.Contains(relPath, "/testdata/")
+}